@@ -1,85 +1,897 @@
 package buckler_test
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"testing"
 
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
 	"github.com/sp301415/ringo-snark/buckler"
+	"github.com/sp301415/ringo-snark/buckler/internal/kat"
 	"github.com/sp301415/ringo-snark/buckler/internal/zp110"
 	"github.com/sp301415/ringo-snark/buckler/internal/zp220"
 	"github.com/sp301415/ringo-snark/buckler/internal/zp440"
 	"github.com/sp301415/ringo-snark/buckler/internal/zp880"
+	"github.com/sp301415/ringo-snark/jindo/security"
 	"github.com/sp301415/ringo-snark/math/bignum"
 	"github.com/sp301415/ringo-snark/math/bigpoly"
+	"github.com/sp301415/ringo-snark/profile"
+	"github.com/sp301415/ringo-snark/transcript"
 	"github.com/stretchr/testify/assert"
+	"github.com/tuneinsight/lattigo/v6/ring"
 )
 
-type PublicKeyCircuit[E bignum.Uint[E]] struct {
-	NTT buckler.LinearChecker[E]
+// PublicKeyCircuit is shared with the known-answer test vectors.
+type PublicKeyCircuit[E bignum.Uint[E]] = kat.PublicKeyCircuit[E]
+
+func newPkCircuit[E bignum.Uint[E]](rank int) *PublicKeyCircuit[E] {
+	sk := make([]int64, rank)
+	noise := make([]int64, rank)
+	pk0 := make([]E, rank)
+	for i := range rank {
+		sk[i] = rand.Int63()%3 - 1
+		noise[i] = rand.Int63()%3 - 1
+
+		var z E
+		pk0[i] = z.New().MustSetRandom()
+	}
+	return kat.NewPublicKeyCircuit(rank, sk, noise, pk0)
+}
+
+func TestPublicKey(t *testing.T) {
+	crs := []byte("Buckler!")
+	N := 1 << 10
+
+	c := PublicKeyCircuit[*zp220.Uint]{
+		NTT: buckler.NewNTTChecker[*zp220.Uint](N),
+	}
+
+	prv, vrf, err := buckler.Compile(N, &c, crs)
+	assert.NoError(t, err)
+
+	pk := newPkCircuit[*zp220.Uint](N)
+	pf, err := prv.Prove(pk)
+	assert.NoError(t, err)
+
+	ok := vrf.Verify(pk, pf)
+	assert.True(t, ok)
+}
+
+type FixedCircuit[E bignum.Uint[E]] struct {
+	F []E
+	G []E
 
-	Sk    buckler.Witness[E]
-	SkNTT buckler.Witness[E]
+	X buckler.Witness[E]
+	Y buckler.Witness[E]
+}
+
+func (c *FixedCircuit[E]) Define(ctx *buckler.Context[E]) {
+	f := ctx.NewFixedWitness(c.F)
+	g := ctx.NewFixedWitness(c.G)
 
-	PkNTT [2]buckler.PublicWitness[E]
+	// Y = F * X + G
+	var constraint buckler.ArithmeticConstraint[E]
+	constraint.AddTerm(nil, c.Y)
+	constraint.SubTerm(f, c.X)
+	constraint.SubTerm(g)
+	ctx.AddArithmeticConstraint(constraint)
 
-	Noise    buckler.Witness[E]
-	NoiseNTT buckler.Witness[E]
+	ctx.AddSqTwoNormConstraint(c.X, 1<<12)
 }
 
-func (c *PublicKeyCircuit[E]) Define(ctx *buckler.Context[E]) {
+func newFixedCircuit[E bignum.Uint[E]](rank int) *FixedCircuit[E] {
 	var z E
 
-	ctx.AddLinearConstraint(c.SkNTT, c.Sk, c.NTT)
-	ctx.AddLinearConstraint(c.NoiseNTT, c.Noise, c.NTT)
+	c := &FixedCircuit[E]{
+		F: make([]E, rank),
+		G: make([]E, rank),
+		X: make(buckler.Witness[E], rank),
+		Y: make(buckler.Witness[E], rank),
+	}
+	for i := range rank {
+		c.F[i] = z.New().SetInt64(int64(i))
+		c.G[i] = z.New().SetInt64(int64(i * i))
+		c.X[i] = z.New().SetInt64(int64(i%3 - 1))
+		c.Y[i] = z.New().Mul(c.F[i], c.X[i])
+		c.Y[i].Add(c.Y[i], c.G[i])
+	}
+	return c
+}
 
-	// pk[1] - pk[0] * sk - noise = 0
-	var pkCircuit buckler.ArithmeticConstraint[E]
-	pkCircuit.AddTermWithConst(z.New().SetInt64(1), c.PkNTT[1])
-	pkCircuit.AddTermWithConst(z.New().SetInt64(-1), c.PkNTT[0], c.SkNTT)
-	pkCircuit.AddTermWithConst(z.New().SetInt64(-1), nil, c.NoiseNTT)
-	ctx.AddArithmeticConstraint(pkCircuit)
+type PartialCircuit[E bignum.Uint[E]] struct {
+	Pub buckler.PublicWitness[E]
 
-	ctx.AddInfNormConstraint(c.Sk, 1)
-	ctx.AddInfNormConstraint(c.Noise, 1)
+	X buckler.Witness[E]
 }
 
-func newPkCircuit[E bignum.Uint[E]](rank int) *PublicKeyCircuit[E] {
-	polyEval := bigpoly.NewCyclotomicEvaluator[E](rank)
+func (c *PartialCircuit[E]) Define(ctx *buckler.Context[E]) {
+	ctx.AddPublicRangeConstraint(c.X, c.Pub, [2]int{0, 16}, [2]int{100, 101})
+	ctx.AddInfNormConstraint(c.X, 1)
+}
+
+func newPartialCircuit[E bignum.Uint[E]](rank int) (*PartialCircuit[E], *PartialCircuit[E]) {
+	var z E
+
+	c := &PartialCircuit[E]{
+		Pub: make(buckler.PublicWitness[E], rank),
+		X:   make(buckler.Witness[E], rank),
+	}
+	pub := &PartialCircuit[E]{
+		Pub: make(buckler.PublicWitness[E], rank),
+	}
+	for i := range rank {
+		c.X[i] = z.New().SetInt64(int64(i%3 - 1))
+		c.Pub[i] = z.New().Set(c.X[i])
+		pub.Pub[i] = z.New()
+		if i < 16 || i == 100 {
+			pub.Pub[i].Set(c.X[i])
+		}
+	}
+	return c, pub
+}
+
+type SparseCircuit[E bignum.Uint[E]] struct {
+	S buckler.Witness[E]
+	T buckler.Witness[E]
+}
+
+func (c *SparseCircuit[E]) Define(ctx *buckler.Context[E]) {
+	ctx.AddHammingWeightConstraint(c.S, 96)
+	ctx.AddSignedHammingWeightConstraint(c.T, 64, 32)
+}
+
+func newSparseCircuit[E bignum.Uint[E]](rank int) *SparseCircuit[E] {
+	var z E
+
+	c := &SparseCircuit[E]{
+		S: make(buckler.Witness[E], rank),
+		T: make(buckler.Witness[E], rank),
+	}
+	for i := range rank {
+		c.S[i], c.T[i] = z.New(), z.New()
+	}
+	for _, i := range rand.Perm(rank)[:96] {
+		c.S[i].SetInt64(int64(2*rand.Intn(2) - 1))
+	}
+	for j, i := range rand.Perm(rank)[:96] {
+		if j < 64 {
+			c.T[i].SetInt64(1)
+		} else {
+			c.T[i].SetInt64(-1)
+		}
+	}
+	return c
+}
+
+type BitCircuit[E bignum.Uint[E]] struct {
+	B buckler.Witness[E]
+	X buckler.Witness[E]
+}
+
+func (c *BitCircuit[E]) Define(ctx *buckler.Context[E]) {
+	ctx.AddBinaryConstraint(c.B)
+	bits := ctx.AddBitDecompositionConstraint(c.X, 8)
+
+	// The lowest bit of X is B.
+	var constraint buckler.ArithmeticConstraint[E]
+	constraint.AddTerm(nil, bits[0])
+	constraint.SubTerm(nil, c.B)
+	ctx.AddArithmeticConstraint(constraint)
+}
+
+// BitWidthCircuit decomposes X into NumBits[0] bits,
+// and again into NumBits[1] bits if it is nonzero.
+type BitWidthCircuit[E bignum.Uint[E]] struct {
+	X       buckler.Witness[E]
+	NumBits [2]int
+	Same    bool
+}
+
+func (c *BitWidthCircuit[E]) Define(ctx *buckler.Context[E]) {
+	bits := ctx.AddBitDecompositionConstraint(c.X, c.NumBits[0])
+	if c.NumBits[1] != 0 {
+		c.Same = reflect.DeepEqual(bits, ctx.AddBitDecompositionConstraint(c.X, c.NumBits[1]))
+	}
+}
+
+func newBitCircuit[E bignum.Uint[E]](rank int) *BitCircuit[E] {
+	var z E
+
+	c := &BitCircuit[E]{
+		B: make(buckler.Witness[E], rank),
+		X: make(buckler.Witness[E], rank),
+	}
+	for i := range rank {
+		x := rand.Int63n(1 << 8)
+		c.X[i] = z.New().SetInt64(x)
+		c.B[i] = z.New().SetInt64(x & 1)
+	}
+	return c
+}
+
+type GadgetCircuit[E bignum.Uint[E]] struct {
+	X      buckler.Witness[E]
+	Digits []buckler.Witness[E]
+}
+
+func (c *GadgetCircuit[E]) Define(ctx *buckler.Context[E]) {
+	ctx.AddGadgetDecompositionConstraint(c.Digits, c.X, big.NewInt(1<<10))
+}
+
+type GadgetBaseCircuit[E bignum.Uint[E]] struct {
+	X      buckler.Witness[E]
+	Digits []buckler.Witness[E]
+	Base   *big.Int
+}
+
+func (c *GadgetBaseCircuit[E]) Define(ctx *buckler.Context[E]) {
+	ctx.AddGadgetDecompositionConstraint(c.Digits, c.X, c.Base)
+}
 
-	sk := polyEval.NewPoly(false)
+func newGadgetCircuit[E bignum.Uint[E]](rank int) *GadgetCircuit[E] {
+	var z E
+
+	c := &GadgetCircuit[E]{
+		X:      make(buckler.Witness[E], rank),
+		Digits: make([]buckler.Witness[E], 3),
+	}
 	for i := range rank {
-		sk.Coeffs[i].SetInt64(rand.Int63()%3 - 1)
+		c.X[i] = z.New().SetInt64(rand.Int63n(1<<29) - 1<<28)
 	}
-	skNTT := polyEval.NTT(sk)
+	return c
+}
+
+// BatchingCircuit has two constraints which cancel out when they are batched by the same constant,
+// and two two-norm constraints whose squared norms must be decomposed separately.
+type BatchingCircuit[E bignum.Uint[E]] struct {
+	X buckler.Witness[E]
+	Y buckler.Witness[E]
+}
+
+func (c *BatchingCircuit[E]) Define(ctx *buckler.Context[E]) {
+	var xy, yx buckler.ArithmeticConstraint[E]
+	xy.AddTerm(nil, c.X)
+	xy.SubTerm(nil, c.Y)
+	yx.AddTerm(nil, c.Y)
+	yx.SubTerm(nil, c.X)
+	ctx.AddArithmeticConstraint(xy)
+	ctx.AddArithmeticConstraint(yx)
+
+	ctx.AddSqTwoNormConstraint(c.X, 1<<12)
+	ctx.AddSqTwoNormConstraint(c.Y, 1<<12)
+}
+
+func newBatchingCircuit[E bignum.Uint[E]](rank int) *BatchingCircuit[E] {
+	var z E
 
-	noise := polyEval.NewPoly(false)
+	c := &BatchingCircuit[E]{
+		X: make(buckler.Witness[E], rank),
+		Y: make(buckler.Witness[E], rank),
+	}
 	for i := range rank {
-		noise.Coeffs[i].SetInt64(rand.Int63()%3 - 1)
+		c.X[i] = z.New().SetInt64(int64(i%3 - 1))
+		c.Y[i] = z.New().Set(c.X[i])
 	}
-	noiseNTT := polyEval.NTT(noise)
+	return c
+}
+
+// NestedCircuit decomposes the digits of a gadget decomposition further.
+// The middle digit of X is gadget-decomposed again,
+// and the lowest digit, which is signed, is bit-decomposed.
+type NestedCircuit[E bignum.Uint[E]] struct {
+	X      buckler.Witness[E]
+	Digits []buckler.Witness[E]
+	Nested []buckler.Witness[E]
+}
 
-	pkNTT := [2]*bigpoly.Poly[E]{polyEval.NewPoly(true), polyEval.NewPoly(true)}
+func (c *NestedCircuit[E]) Define(ctx *buckler.Context[E]) {
+	ctx.AddGadgetDecompositionConstraint(c.Digits, c.X, big.NewInt(1<<10))
+	ctx.AddGadgetDecompositionConstraint(c.Nested, c.Digits[1], big.NewInt(1<<4))
+	ctx.AddBitDecompositionConstraint(c.Digits[0], 9)
+}
+
+func newNestedCircuit[E bignum.Uint[E]](rank int) *NestedCircuit[E] {
+	var z E
+
+	c := &NestedCircuit[E]{
+		X:      make(buckler.Witness[E], rank),
+		Digits: make([]buckler.Witness[E], 3),
+		Nested: make([]buckler.Witness[E], 3),
+	}
 	for i := range rank {
-		pkNTT[0].Coeffs[i].MustSetRandom()
+		// The lowest digit is in [0, 512), so that it has a bit decomposition.
+		x := rand.Int63n(1<<9) + (rand.Int63n(1<<10)-1<<9)<<10 + (rand.Int63n(1<<10)-1<<9)<<20
+		c.X[i] = z.New().SetInt64(x)
 	}
-	polyEval.MulTo(pkNTT[1], pkNTT[0], skNTT)
-	polyEval.AddTo(pkNTT[1], pkNTT[1], noiseNTT)
+	return c
+}
 
-	return &PublicKeyCircuit[E]{
-		Sk:    sk.Coeffs,
-		SkNTT: skNTT.Coeffs,
+// constraintCase is an assignment of a circuit together with its public assignment.
+type constraintCase struct {
+	name   string
+	assign func() (c, pub buckler.Circuit[*zp220.Uint])
+}
+
+// constraintTest tests a circuit built around a single kind of constraint.
+type constraintTest struct {
+	name string
+	// circuit is compiled, and valid returns an assignment which is accepted.
+	circuit buckler.Circuit[*zp220.Uint]
+	valid   func() (c, pub buckler.Circuit[*zp220.Uint])
+	// invalid are the assignments which are proven but rejected,
+	// and failing are the assignments which the prover refuses.
+	invalid []constraintCase
+	failing []constraintCase
+	// extra runs the subtests specific to the constraint.
+	extra func(t *testing.T, prv *buckler.Prover[*zp220.Uint], vrf *buckler.Verifier[*zp220.Uint])
+}
 
-		PkNTT: [2]buckler.PublicWitness[E]{
-			pkNTT[0].Coeffs,
-			pkNTT[1].Coeffs,
+// constraintTests returns the test cases of the constraints with rank N.
+func constraintTests(N int, crs []byte) []constraintTest {
+	fixed := newFixedCircuit[*zp220.Uint](N)
+	bitPub := &BitCircuit[*zp220.Uint]{}
+	gadgetPub := &GadgetCircuit[*zp220.Uint]{Digits: make([]buckler.Witness[*zp220.Uint], 3)}
+	nestedPub := &NestedCircuit[*zp220.Uint]{
+		Digits: make([]buckler.Witness[*zp220.Uint], 3),
+		Nested: make([]buckler.Witness[*zp220.Uint], 3),
+	}
+
+	return []constraintTest{
+		{
+			name:    "FixedWitness",
+			circuit: &FixedCircuit[*zp220.Uint]{F: fixed.F, G: fixed.G},
+			valid: func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+				return newFixedCircuit[*zp220.Uint](N), &FixedCircuit[*zp220.Uint]{}
+			},
+			invalid: []constraintCase{
+				{"Invalid", func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+					c := newFixedCircuit[*zp220.Uint](N)
+					c.Y[1].Add(c.Y[1], new(zp220.Uint).SetUint64(1))
+					return c, &FixedCircuit[*zp220.Uint]{}
+				}},
+			},
+			extra: func(t *testing.T, prv *buckler.Prover[*zp220.Uint], vrf *buckler.Verifier[*zp220.Uint]) {
+				pf, err := prv.Prove(fixed)
+				assert.NoError(t, err)
+
+				t.Run("Committed", func(t *testing.T) {
+					// F, G and the base and the mask of the two-norm constraint are committed when compiling.
+					assert.Len(t, pf.Witness, prv.JindoParams.Batch()-4)
+				})
+
+				t.Run("OtherFixed", func(t *testing.T) {
+					gOther := slices.Clone(fixed.G)
+					gOther[0] = new(zp220.Uint).SetUint64(1)
+					_, vrfOther, err := buckler.Compile(N, &FixedCircuit[*zp220.Uint]{F: fixed.F, G: gOther}, crs)
+					assert.NoError(t, err)
+					assert.False(t, vrfOther.Verify(&FixedCircuit[*zp220.Uint]{}, pf))
+				})
+			},
 		},
+		{
+			name:    "PublicRange",
+			circuit: &PartialCircuit[*zp220.Uint]{},
+			valid: func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+				return newPartialCircuit[*zp220.Uint](N)
+			},
+			invalid: []constraintCase{
+				{"Invalid", func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+					c, pub := newPartialCircuit[*zp220.Uint](N)
+					pub.Pub[100].Add(pub.Pub[100], new(zp220.Uint).SetUint64(1))
+					return c, pub
+				}},
+			},
+		},
+		{
+			name:    "HammingWeight",
+			circuit: &SparseCircuit[*zp220.Uint]{},
+			valid: func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+				return newSparseCircuit[*zp220.Uint](N), &SparseCircuit[*zp220.Uint]{}
+			},
+			invalid: []constraintCase{
+				{"InvalidWeight", func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+					c := newSparseCircuit[*zp220.Uint](N)
+					i := slices.IndexFunc(c.S, func(x *zp220.Uint) bool { return x.IsZero() })
+					c.S[i].SetInt64(1)
+					return c, &SparseCircuit[*zp220.Uint]{}
+				}},
+				{"InvalidSign", func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+					c := newSparseCircuit[*zp220.Uint](N)
+					i := slices.IndexFunc(c.T, func(x *zp220.Uint) bool { return x.IsOne() })
+					c.T[i].SetInt64(-1)
+					return c, &SparseCircuit[*zp220.Uint]{}
+				}},
+			},
+		},
+		{
+			name:    "BitDecomposition",
+			circuit: &BitCircuit[*zp220.Uint]{},
+			valid: func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+				return newBitCircuit[*zp220.Uint](N), bitPub
+			},
+			invalid: []constraintCase{
+				{"InvalidBinary", func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+					c := newBitCircuit[*zp220.Uint](N)
+					c.B[0].SetInt64(2)
+					c.X[0].SetInt64(2)
+					return c, bitPub
+				}},
+			},
+			failing: []constraintCase{
+				{"OutOfRange", func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+					c := newBitCircuit[*zp220.Uint](N)
+					c.X[0].SetInt64(1 << 8)
+					c.B[0].SetInt64(0)
+					return c, bitPub
+				}},
+				{"Negative", func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+					c := newBitCircuit[*zp220.Uint](N)
+					c.X[0].SetInt64(-1)
+					c.B[0].SetInt64(1)
+					return c, bitPub
+				}},
+			},
+			extra: func(t *testing.T, prv *buckler.Prover[*zp220.Uint], vrf *buckler.Verifier[*zp220.Uint]) {
+				t.Run("NumBits", func(t *testing.T) {
+					logQ := bignum.Modulus[*zp220.Uint]().BitLen()
+
+					_, _, err := buckler.Compile(N, &BitWidthCircuit[*zp220.Uint]{NumBits: [2]int{logQ - 2}}, crs)
+					assert.NoError(t, err)
+					assert.Panics(t, func() { buckler.Compile(N, &BitWidthCircuit[*zp220.Uint]{NumBits: [2]int{logQ - 1}}, crs) })
+					assert.Panics(t, func() { buckler.Compile(N, &BitWidthCircuit[*zp220.Uint]{NumBits: [2]int{0}}, crs) })
 
-		Noise:    noise.Coeffs,
-		NoiseNTT: noiseNTT.Coeffs,
+					c := &BitWidthCircuit[*zp220.Uint]{NumBits: [2]int{8, 8}}
+					_, _, err = buckler.Compile(N, c, crs)
+					assert.NoError(t, err)
+					assert.True(t, c.Same)
+					assert.Panics(t, func() { buckler.Compile(N, &BitWidthCircuit[*zp220.Uint]{NumBits: [2]int{8, 9}}, crs) })
+				})
+			},
+		},
+		{
+			name:    "GadgetDecomposition",
+			circuit: &GadgetCircuit[*zp220.Uint]{Digits: make([]buckler.Witness[*zp220.Uint], 3)},
+			valid: func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+				c := newGadgetCircuit[*zp220.Uint](N)
+				// The lowest digit is -512, which is on the bound.
+				c.X[0].SetInt64(-512)
+				return c, gadgetPub
+			},
+			failing: []constraintCase{
+				{"OutOfRange", func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+					c := newGadgetCircuit[*zp220.Uint](N)
+					c.X[0].SetInt64(1 << 40)
+					return c, gadgetPub
+				}},
+			},
+			extra: func(t *testing.T, prv *buckler.Prover[*zp220.Uint], vrf *buckler.Verifier[*zp220.Uint]) {
+				t.Run("Base", func(t *testing.T) {
+					for _, base := range []int64{-2, 0, 1} {
+						c := &GadgetBaseCircuit[*zp220.Uint]{Digits: make([]buckler.Witness[*zp220.Uint], 3), Base: big.NewInt(base)}
+						assert.Panics(t, func() { buckler.Compile(N, c, crs) }, base)
+					}
+					c := &GadgetBaseCircuit[*zp220.Uint]{Digits: make([]buckler.Witness[*zp220.Uint], 3), Base: big.NewInt(2)}
+					_, _, err := buckler.Compile(N, c, crs)
+					assert.NoError(t, err)
+				})
+			},
+		},
+		{
+			// Regression test for batching every constraint by the same constant,
+			// and for accumulating the squared norms across two-norm constraints.
+			name:    "Batching",
+			circuit: &BatchingCircuit[*zp220.Uint]{},
+			valid: func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+				return newBatchingCircuit[*zp220.Uint](N), &BatchingCircuit[*zp220.Uint]{}
+			},
+			invalid: []constraintCase{
+				{"Cancelling", func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+					c := newBatchingCircuit[*zp220.Uint](N)
+					c.Y[0].Add(c.Y[0], new(zp220.Uint).SetUint64(1))
+					return c, &BatchingCircuit[*zp220.Uint]{}
+				}},
+			},
+		},
+		{
+			name:    "NestedDecomposition",
+			circuit: nestedPub,
+			valid: func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+				return newNestedCircuit[*zp220.Uint](N), nestedPub
+			},
+			failing: []constraintCase{
+				{"NegativeDigit", func() (buckler.Circuit[*zp220.Uint], buckler.Circuit[*zp220.Uint]) {
+					c := newNestedCircuit[*zp220.Uint](N)
+					c.X[0].SetInt64(-1)
+					return c, nestedPub
+				}},
+			},
+		},
 	}
 }
 
-func TestPublicKey(t *testing.T) {
+func TestConstraints(t *testing.T) {
+	crs := []byte("Buckler!")
+	N := 1 << 10
+
+	for _, tc := range constraintTests(N, crs) {
+		t.Run(tc.name, func(t *testing.T) {
+			prv, vrf, err := buckler.Compile(N, tc.circuit, crs)
+			assert.NoError(t, err)
+
+			t.Run("Valid", func(t *testing.T) {
+				c, pub := tc.valid()
+				pf, err := prv.Prove(c)
+				assert.NoError(t, err)
+				assert.True(t, vrf.Verify(pub, pf))
+			})
+
+			for _, cc := range tc.invalid {
+				t.Run(cc.name, func(t *testing.T) {
+					c, pub := cc.assign()
+					pf, err := prv.Prove(c)
+					assert.NoError(t, err)
+					assert.False(t, vrf.Verify(pub, pf))
+				})
+			}
+
+			for _, cc := range tc.failing {
+				t.Run(cc.name, func(t *testing.T) {
+					c, _ := cc.assign()
+					_, err := prv.Prove(c)
+					assert.Error(t, err)
+				})
+			}
+
+			t.Run("Many", func(t *testing.T) {
+				cs := make([]buckler.Circuit[*zp220.Uint], 2)
+				pubs := make([]buckler.Circuit[*zp220.Uint], 2)
+				for i := range cs {
+					cs[i], pubs[i] = tc.valid()
+				}
+				pf, err := prv.ProveMany(cs)
+				assert.NoError(t, err)
+				assert.True(t, vrf.VerifyMany(pubs, pf))
+
+				pfs := make([]*buckler.Proof[*zp220.Uint], 2)
+				for i := range pfs {
+					pfs[i], err = prv.Prove(cs[i])
+					assert.NoError(t, err)
+				}
+				ok, _ := vrf.BatchVerify(pubs, pfs)
+				assert.True(t, ok)
+			})
+
+			if tc.extra != nil {
+				tc.extra(t, prv, vrf)
+			}
+		})
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	crs := []byte("Buckler!")
+	N := 1 << 10
+
+	c := PublicKeyCircuit[*zp220.Uint]{
+		NTT: buckler.NewNTTChecker[*zp220.Uint](N),
+	}
+
+	prv, vrf, err := buckler.Compile(N, &c, crs)
+	assert.NoError(t, err)
+
+	pks := make([]buckler.Circuit[*zp220.Uint], 3)
+	pfs := make([]*buckler.Proof[*zp220.Uint], 3)
+	for i := range pks {
+		pk := newPkCircuit[*zp220.Uint](N)
+		pf, err := prv.Prove(pk)
+		assert.NoError(t, err)
+		pks[i], pfs[i] = pk, pf
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		ok, res := vrf.BatchVerify(pks, pfs)
+		assert.True(t, ok)
+		assert.Equal(t, []bool{true, true, true}, res)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		pfsInvalid := []*buckler.Proof[*zp220.Uint]{pfs[0], pfs[2], pfs[2]}
+		ok, res := vrf.BatchVerify(pks, pfsInvalid)
+		assert.False(t, ok)
+		assert.Equal(t, []bool{true, false, true}, res)
+	})
+}
+
+func TestProveMany(t *testing.T) {
+	crs := []byte("Buckler!")
+	N := 1 << 10
+
+	c := PublicKeyCircuit[*zp220.Uint]{
+		NTT: buckler.NewNTTChecker[*zp220.Uint](N),
+	}
+
+	prv, vrf, err := buckler.Compile(N, &c, crs)
+	assert.NoError(t, err)
+
+	pks := make([]buckler.Circuit[*zp220.Uint], 3)
+	for i := range pks {
+		pks[i] = newPkCircuit[*zp220.Uint](N)
+	}
+
+	pf, err := prv.ProveMany(pks)
+	assert.NoError(t, err)
+
+	t.Run("Valid", func(t *testing.T) {
+		assert.True(t, vrf.VerifyMany(pks, pf))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		pksInvalid := []buckler.Circuit[*zp220.Uint]{pks[0], pks[2], pks[1]}
+		assert.False(t, vrf.VerifyMany(pksInvalid, pf))
+		assert.False(t, vrf.VerifyMany(pks[:2], pf))
+	})
+}
+
+func TestProveContext(t *testing.T) {
+	crs := []byte("Buckler!")
+	N := 1 << 10
+
+	c := PublicKeyCircuit[*zp220.Uint]{
+		NTT: buckler.NewNTTChecker[*zp220.Uint](N),
+	}
+
+	prv, vrf, err := buckler.Compile(N, &c, crs)
+	assert.NoError(t, err)
+
+	pk := newPkCircuit[*zp220.Uint](N)
+
+	t.Run("Observer", func(t *testing.T) {
+		var phases []buckler.Phase
+		prv.SetObserver(buckler.ObserverFunc(func(e buckler.PhaseEvent) {
+			phases = append(phases, e.Phase)
+		}))
+		defer prv.SetObserver(nil)
+
+		// The circuit has no sumcheck constraints, so PhaseSumCheck is skipped.
+		pf, err := prv.ProveContext(context.Background(), pk)
+		assert.NoError(t, err)
+		assert.True(t, vrf.Verify(pk, pf))
+
+		assert.Equal(t, []buckler.Phase{
+			buckler.PhaseWitnessCommit,
+			buckler.PhaseProjection,
+			buckler.PhaseMaskCommit,
+			buckler.PhaseArithCheck,
+			buckler.PhaseLinCheck,
+			buckler.PhaseEvaluation,
+		}, phases)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := prv.ProveContext(ctx, pk)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("CancelDuringProve", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		prv.SetObserver(buckler.ObserverFunc(func(e buckler.PhaseEvent) {
+			if e.Phase == buckler.PhaseWitnessCommit {
+				cancel()
+			}
+		}))
+		defer prv.SetObserver(nil)
+
+		_, err := prv.ProveContext(ctx, pk)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestTranscript(t *testing.T) {
+	crs := []byte("Buckler!")
+	N := 1 << 10
+
+	c := PublicKeyCircuit[*zp220.Uint]{
+		NTT: buckler.NewNTTChecker[*zp220.Uint](N),
+	}
+
+	prv, vrf, err := buckler.Compile(N, &c, crs)
+	assert.NoError(t, err)
+
+	pk := newPkCircuit[*zp220.Uint](N)
+
+	t.Run("SHA256", func(t *testing.T) {
+		pf, err := prv.ProveWithTranscript(context.Background(), pk, transcript.NewSHA256())
+		assert.NoError(t, err)
+		assert.True(t, vrf.Verify(pk, pf))
+		assert.True(t, vrf.VerifyWithTranscript(pk, pf, transcript.NewSHA256()))
+	})
+
+	t.Run("SHAKE", func(t *testing.T) {
+		pf, err := prv.ProveWithTranscript(context.Background(), pk, transcript.NewSHAKE128())
+		assert.NoError(t, err)
+		assert.True(t, vrf.VerifyWithTranscript(pk, pf, transcript.NewSHAKE128()))
+		assert.False(t, vrf.Verify(pk, pf))
+	})
+
+	t.Run("Interactive", func(t *testing.T) {
+		toVerifier := make(chan transcript.Message)
+		toProver := make(chan []byte)
+		chal := transcript.NewChallenger(toVerifier, toProver)
+
+		errCh := make(chan error)
+		go func() { errCh <- chal.Run() }()
+
+		pf, err := prv.ProveWithTranscript(context.Background(), pk, transcript.NewChannel(toVerifier, toProver))
+		close(toVerifier)
+		assert.NoError(t, err)
+		assert.NoError(t, <-errCh)
+
+		assert.True(t, vrf.VerifyWithTranscript(pk, pf, chal.Replay()))
+		assert.False(t, vrf.Verify(pk, pf))
+
+		pfInvalid, err := prv.Prove(pk)
+		assert.NoError(t, err)
+		assert.False(t, vrf.VerifyWithTranscript(pk, pfInvalid, chal.Replay()))
+	})
+
+	t.Run("Framing", func(t *testing.T) {
+		// Splitting a message, or moving bytes between the label and the message,
+		// must change the challenge.
+		msgs := [][][2]string{
+			{{"a", "bc"}},
+			{{"a", "b"}, {"a", "c"}},
+			{{"ab", "c"}},
+			{{"b", "bc"}},
+		}
+		for _, newTranscript := range []func() transcript.Transcript{
+			func() transcript.Transcript { return transcript.NewSHA256() },
+			func() transcript.Transcript { return transcript.NewSHAKE128() },
+		} {
+			chals := make(map[string]bool)
+			for _, m := range msgs {
+				tr := newTranscript()
+				for _, lm := range m {
+					assert.NoError(t, tr.Append(lm[0], []byte(lm[1])))
+				}
+				chal, err := tr.Challenge(m[0][0], 32)
+				assert.NoError(t, err)
+				chals[string(chal)] = true
+			}
+			assert.Len(t, chals, len(msgs))
+		}
+	})
+}
+
+func TestEvaluateInterpolant(t *testing.T) {
+	rank := 1 << 6
+	ntt := bigpoly.NewCyclicTransformer[*zp220.Uint](rank)
+
+	v := make([]*zp220.Uint, rank)
+	for i := range v {
+		v[i] = new(zp220.Uint).MustSetRandom()
+	}
+	p := bigpoly.NewPoly[*zp220.Uint](rank, false)
+	ntt.InvNTTTo(p.Coeffs, v)
+
+	t.Run("Random", func(t *testing.T) {
+		x := new(zp220.Uint).MustSetRandom()
+		assert.Equal(t, 0, ntt.EvaluateInterpolant(v, x).Cmp(p.Evaluate(x)))
+	})
+
+	t.Run("Domain", func(t *testing.T) {
+		X := make([]*zp220.Uint, rank)
+		for i := range X {
+			X[i] = new(zp220.Uint)
+		}
+		X[1].SetUint64(1)
+		ntt.FwdNTTTo(X, X)
+
+		for _, i := range []int{0, 1, rank / 2, rank - 1} {
+			assert.Equal(t, 0, ntt.EvaluateInterpolant(v, X[i]).Cmp(v[i]))
+		}
+	})
+}
+
+func TestSetSeed(t *testing.T) {
+	crs := []byte("Buckler!")
+	N := 1 << 10
+
+	c := PublicKeyCircuit[*zp220.Uint]{
+		NTT: buckler.NewNTTChecker[*zp220.Uint](N),
+	}
+
+	prv, vrf, err := buckler.Compile(N, &c, crs)
+	assert.NoError(t, err)
+
+	pk := newPkCircuit[*zp220.Uint](N)
+
+	seed := []byte("seed")
+	prv.SetSeed(seed)
+	pf0, err := prv.Prove(pk)
+	assert.NoError(t, err)
+	assert.True(t, vrf.Verify(pk, pf0))
+
+	prv.SetSeed(seed)
+	pf1, err := prv.Prove(pk)
+	assert.NoError(t, err)
+	assert.Equal(t, pf0, pf1)
+
+	prv.SetSeed([]byte("other seed"))
+	pf2, err := prv.Prove(pk)
+	assert.NoError(t, err)
+	assert.True(t, vrf.Verify(pk, pf2))
+	assert.NotEqual(t, pf0, pf2)
+
+	prv.SetSeed(nil)
+	pf3, err := prv.Prove(pk)
+	assert.NoError(t, err)
+	assert.True(t, vrf.Verify(pk, pf3))
+}
+
+func TestSoundnessBits(t *testing.T) {
+	crs := []byte("Buckler!")
+	N := 1 << 10
+
+	c := PublicKeyCircuit[*zp220.Uint]{
+		NTT: buckler.NewNTTChecker[*zp220.Uint](N),
+	}
+
+	prv, vrf, err := buckler.Compile(N, &c, crs)
+	assert.NoError(t, err)
+
+	bits := prv.SoundnessBits()
+	assert.Equal(t, bits, vrf.SoundnessBits())
+	assert.Greater(t, bits, 200.0)
+	assert.Less(t, bits, 220.0)
+
+	t.Run("SecurityReport", func(t *testing.T) {
+		r := prv.SecurityReport()
+		assert.Equal(t, float64(buckler.DefaultSecurityTarget), r.TargetBits)
+		assert.NotEmpty(t, r.Soundness)
+		assert.NotEmpty(t, r.ZeroKnowledge)
+		assert.LessOrEqual(t, r.SoundnessBits, bits)
+		for _, c := range r.Soundness {
+			assert.GreaterOrEqual(t, c.Bits, r.SoundnessBits, c.Name)
+		}
+		for _, c := range r.ZeroKnowledge {
+			assert.GreaterOrEqual(t, c.Bits, r.ZeroKnowledgeBits, c.Name)
+		}
+		assert.Equal(t, r.SoundnessBits < r.TargetBits || r.ZeroKnowledgeBits < r.TargetBits, r.BelowTarget)
+		assert.Equal(t, r, vrf.SecurityReport())
+
+		pcs := security.EstimateParameters(prv.JindoParams, security.CoreSVPClassical)
+		assert.Contains(t, r.Soundness, buckler.SecurityComponent{Name: "PCSBinding", Bits: pcs.BindingBits})
+		assert.Contains(t, r.ZeroKnowledge, buckler.SecurityComponent{Name: "PCSHiding", Bits: pcs.HidingBits})
+
+		defer prv.SetSecurityTarget(buckler.DefaultSecurityTarget)
+		defer vrf.SetSecurityTarget(buckler.DefaultSecurityTarget)
+
+		prv.SetSecurityTarget(min(r.SoundnessBits, r.ZeroKnowledgeBits) - 1)
+		vrf.SetSecurityTarget(min(r.SoundnessBits, r.ZeroKnowledgeBits) - 1)
+		assert.False(t, prv.SecurityReport().BelowTarget)
+		assert.False(t, vrf.SecurityReport().BelowTarget)
+
+		prv.SetSecurityTarget(min(r.SoundnessBits, r.ZeroKnowledgeBits) + 1)
+		vrf.SetSecurityTarget(min(r.SoundnessBits, r.ZeroKnowledgeBits) + 1)
+		assert.True(t, prv.SecurityReport().BelowTarget)
+		assert.True(t, vrf.SecurityReport().BelowTarget)
+	})
+}
+
+func TestProfiler(t *testing.T) {
 	crs := []byte("Buckler!")
 	N := 1 << 10
 
@@ -90,12 +902,546 @@ func TestPublicKey(t *testing.T) {
 	prv, vrf, err := buckler.Compile(N, &c, crs)
 	assert.NoError(t, err)
 
+	prof := profile.NewProfiler()
+	prv.SetProfiler(prof)
+	vrf.SetProfiler(prof)
+	defer prv.SetProfiler(nil)
+	defer vrf.SetProfiler(nil)
+
 	pk := newPkCircuit[*zp220.Uint](N)
 	pf, err := prv.Prove(pk)
 	assert.NoError(t, err)
+	assert.True(t, vrf.Verify(pk, pf))
 
-	ok := vrf.Verify(pk, pf)
-	assert.True(t, ok)
+	report := prof.Report()
+
+	phases := make(map[string]profile.PhaseReport)
+	for _, ph := range report.Phases {
+		if _, ok := phases[ph.Name]; !ok {
+			phases[ph.Name] = ph
+		}
+	}
+
+	for _, name := range []string{"buckler.Prove", "buckler.WitnessCommit", "buckler.Evaluation", "jindo.Commit", "jindo.Evaluate", "buckler.Verify", "jindo.Verify"} {
+		assert.Contains(t, phases, name)
+	}
+
+	assert.Equal(t, 0, phases["buckler.Prove"].Depth)
+	assert.Equal(t, 1, phases["buckler.WitnessCommit"].Depth)
+	assert.Equal(t, 2, phases["jindo.Commit"].Depth)
+
+	assert.Positive(t, phases["jindo.Commit"].Counts.NTT)
+	assert.Positive(t, phases["jindo.Commit"].Counts.RingMul)
+	assert.Positive(t, phases["jindo.Commit"].Counts.GaussianSample)
+	assert.Positive(t, phases["jindo.Commit"].Counts.RNSReconstruct)
+	assert.Positive(t, phases["jindo.Verify"].Counts.RNSReconstruct)
+
+	assert.GreaterOrEqual(t, report.Total.NTT, phases["buckler.Prove"].Counts.NTT)
+	assert.GreaterOrEqual(t, phases["buckler.Prove"].Counts.NTT, phases["buckler.WitnessCommit"].Counts.NTT)
+}
+
+// MaliciousCircuit uses every constraint type of [buckler.Context],
+// so that the soundness of each of them can be tested.
+type MaliciousCircuit[E bignum.Uint[E]] struct {
+	NTT buckler.LinearChecker[E]
+
+	P buckler.PublicWitness[E]
+
+	X    buckler.Witness[E]
+	XNTT buckler.Witness[E]
+	Prod buckler.Witness[E]
+	Y    buckler.Witness[E]
+	Z    buckler.Witness[E]
+	S    buckler.Witness[E]
+	A    buckler.Witness[E]
+
+	YBig buckler.Witness[E]
+	ZBig buckler.Witness[E]
+	SBig buckler.Witness[E]
+	ABig buckler.Witness[E]
+}
+
+const (
+	malYBound = 7
+	malZBound = 1 << 12
+	malABound = 4
+
+	// malZBigBits is the bit length of the bound of ZBig, which does not fit in uint64.
+	malZBigBits = 70
+	// malABigBits is the bit length of the bound of ABig.
+	// The projection of ABig is decomposed with slack rank,
+	// so the bound must be small enough for the decomposition to fit in rank.
+	malABigBits = 5
+)
+
+var (
+	// malYBigBound is the bound of YBig.
+	// Each bit of the bound costs a witness, so it is kept small.
+	malYBigBound = big.NewInt(1000)
+	// malZBigBound is the bound of ZBig.
+	malZBigBound = new(big.Int).Lsh(big.NewInt(1), malZBigBits)
+	// malABigBound is the bound of ABig.
+	malABigBound = new(big.Int).Lsh(big.NewInt(1), malABigBits)
+	// malBigSum is the sum of SBig, which does not fit in uint64.
+	malBigSum = new(big.Int).Lsh(big.NewInt(1), 100)
+)
+
+// randSigned returns a random integer in [-2^(bits-1), 2^(bits-1)).
+func randSigned(bits int) *big.Int {
+	half := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+	x := new(big.Int).Rand(rand.New(rand.NewSource(rand.Int63())), new(big.Int).Lsh(half, 1))
+	return x.Sub(x, half)
+}
+
+func (c *MaliciousCircuit[E]) Define(ctx *buckler.Context[E]) {
+	ctx.AddLinearConstraint(c.XNTT, c.X, c.NTT)
+
+	// prod - p * xNTT = 0
+	var prodCircuit buckler.ArithmeticConstraint[E]
+	prodCircuit.AddTerm(nil, c.Prod)
+	prodCircuit.SubTerm(c.P, c.XNTT)
+	ctx.AddArithmeticConstraint(prodCircuit)
+
+	// sum_i s_i = 0
+	var sumCircuit buckler.ArithmeticConstraint[E]
+	sumCircuit.AddTerm(nil, c.S)
+	ctx.AddSumCheckConstraint(sumCircuit, 0)
+
+	ctx.AddInfNormConstraint(c.X, 1)
+	ctx.AddInfNormConstraint(c.Y, malYBound)
+	ctx.AddSqTwoNormConstraint(c.Z, malZBound)
+	ctx.AddApproxInfNormConstraint(c.A, malABound)
+
+	var sumBigCircuit buckler.ArithmeticConstraint[E]
+	sumBigCircuit.AddTerm(nil, c.SBig)
+	ctx.AddSumCheckConstraintBig(sumBigCircuit, malBigSum)
+
+	ctx.AddInfNormConstraintBig(c.YBig, malYBigBound)
+	ctx.AddSqNormConstraintBig(c.ZBig, malZBigBound)
+	ctx.AddApproxInfNormConstraintBig(c.ABig, malABigBound)
+}
+
+// ApproxBoundCircuit adds an approximate infinity-norm constraint on A with Bound.
+type ApproxBoundCircuit[E bignum.Uint[E]] struct {
+	A     buckler.Witness[E]
+	Bound *big.Int
+}
+
+func (c *ApproxBoundCircuit[E]) Define(ctx *buckler.Context[E]) {
+	ctx.AddApproxInfNormConstraintBig(c.A, c.Bound)
+}
+
+// newMaliciousCircuit returns an honest witness of [MaliciousCircuit].
+func newMaliciousCircuit[E bignum.Uint[E]](rank int) *MaliciousCircuit[E] {
+	polyEval := bigpoly.NewCyclotomicEvaluator[E](rank)
+
+	c := &MaliciousCircuit[E]{
+		P: polyEval.NewPoly(true).Coeffs,
+
+		X: polyEval.NewPoly(false).Coeffs,
+		Y: polyEval.NewPoly(false).Coeffs,
+		Z: polyEval.NewPoly(false).Coeffs,
+		S: polyEval.NewPoly(false).Coeffs,
+		A: polyEval.NewPoly(false).Coeffs,
+
+		YBig: polyEval.NewPoly(false).Coeffs,
+		ZBig: polyEval.NewPoly(false).Coeffs,
+		SBig: polyEval.NewPoly(false).Coeffs,
+		ABig: polyEval.NewPoly(false).Coeffs,
+	}
+
+	// The squared norm of ZBig is at most rank * 2^(malZBigBits-12) < malZBigBound for rank <= 2^11.
+	var sum int64
+	sumBig := new(big.Int).Set(malBigSum)
+	for i := range rank {
+		c.P[i].MustSetRandom()
+		c.X[i].SetInt64(rand.Int63()%3 - 1)
+		c.Y[i].SetInt64(rand.Int63()%(2*malYBound+1) - malYBound)
+		c.Z[i].SetInt64(rand.Int63()%3 - 1)
+		c.A[i].SetInt64(rand.Int63()%(2*malABound+1) - malABound)
+
+		c.YBig[i].SetInt64(rand.Int63()%(2*malYBigBound.Int64()+1) - malYBigBound.Int64())
+		c.ZBig[i].SetBigInt(randSigned(malZBigBits/2 - 5))
+		c.ABig[i].SetBigInt(randSigned(malABigBits + 1))
+
+		if i < rank-1 {
+			s := rand.Int63()%3 - 1
+			c.S[i].SetInt64(s)
+			sum += s
+
+			sBig := randSigned(malZBigBits)
+			c.SBig[i].SetBigInt(sBig)
+			sumBig.Sub(sumBig, sBig)
+		}
+	}
+	c.S[rank-1].SetInt64(-sum)
+	c.SBig[rank-1].SetBigInt(sumBig)
+
+	c.fillDerived(polyEval)
+	return c
+}
+
+// fillDerived computes XNTT and Prod from X and P.
+func (c *MaliciousCircuit[E]) fillDerived(polyEval *bigpoly.CyclotomicEvaluator[E]) {
+	xNTT := polyEval.NTT(&bigpoly.Poly[E]{Coeffs: c.X})
+	prod := polyEval.NewPoly(true)
+	polyEval.MulTo(prod, xNTT, &bigpoly.Poly[E]{Coeffs: c.P, IsNTT: true})
+
+	c.XNTT = xNTT.Coeffs
+	c.Prod = prod.Coeffs
+}
+
+// clone returns a deep copy of c.
+func (c *MaliciousCircuit[E]) clone() *MaliciousCircuit[E] {
+	cp := func(v []E) []E {
+		vOut := make([]E, len(v))
+		for i := range v {
+			vOut[i] = v[i].New().Set(v[i])
+		}
+		return vOut
+	}
+
+	return &MaliciousCircuit[E]{
+		P:    cp(c.P),
+		X:    cp(c.X),
+		XNTT: cp(c.XNTT),
+		Prod: cp(c.Prod),
+		Y:    cp(c.Y),
+		Z:    cp(c.Z),
+		S:    cp(c.S),
+		A:    cp(c.A),
+
+		YBig: cp(c.YBig),
+		ZBig: cp(c.ZBig),
+		SBig: cp(c.SBig),
+		ABig: cp(c.ABig),
+	}
+}
+
+// perturbPoly changes the coefficient of ps chosen by idx,
+// keeping it in the range of its modulus.
+func perturbPoly(ps []ring.Poly, idx uint64) {
+	p := ps[idx%uint64(len(ps))]
+	idx /= uint64(len(ps))
+
+	coeffs := p.Coeffs[idx%uint64(len(p.Coeffs))]
+	idx /= uint64(len(p.Coeffs))
+
+	j := idx % uint64(len(coeffs))
+	if coeffs[j] == 0 {
+		coeffs[j] = 1
+	} else {
+		coeffs[j]--
+	}
+}
+
+// perturbElem adds a nonzero delta to x.
+func perturbElem[E bignum.Uint[E]](x E, delta uint64) {
+	x.Add(x, x.New().SetUint64(delta|1))
+}
+
+// proofMutations are the ways a malicious prover can modify a proof.
+var proofMutations = []struct {
+	name   string
+	mutate func(pf *buckler.Proof[*zp220.Uint], idx uint64)
+}{
+	{"Witness", func(pf *buckler.Proof[*zp220.Uint], idx uint64) {
+		perturbPoly(pf.Witness[idx%uint64(len(pf.Witness))].Value, idx/uint64(len(pf.Witness)))
+	}},
+	{"Evals", func(pf *buckler.Proof[*zp220.Uint], idx uint64) {
+		perturbElem(pf.Evals[idx%uint64(len(pf.Evals))], idx)
+	}},
+	{"LinCheckMaskSum", func(pf *buckler.Proof[*zp220.Uint], idx uint64) {
+		perturbElem(pf.LinCheckMaskSum, idx)
+	}},
+	{"SumCheckMaskSum", func(pf *buckler.Proof[*zp220.Uint], idx uint64) {
+		perturbElem(pf.SumCheckMaskSum, idx)
+	}},
+	{"EvalProof.InCommit", func(pf *buckler.Proof[*zp220.Uint], idx uint64) {
+		perturbPoly(pf.EvalProof.InCommit, idx)
+	}},
+	{"EvalProof.Partial", func(pf *buckler.Proof[*zp220.Uint], idx uint64) {
+		perturbPoly(pf.EvalProof.Partial, idx)
+	}},
+	{"EvalProof.PartialMask", func(pf *buckler.Proof[*zp220.Uint], idx uint64) {
+		perturbPoly([]ring.Poly{pf.EvalProof.PartialMask}, idx)
+	}},
+	{"EvalProof.Encode", func(pf *buckler.Proof[*zp220.Uint], idx uint64) {
+		perturbPoly(pf.EvalProof.Encode, idx)
+	}},
+	{"EvalProof.MLWE", func(pf *buckler.Proof[*zp220.Uint], idx uint64) {
+		perturbPoly(pf.EvalProof.MLWE, idx)
+	}},
+}
+
+// witnessMutations are the ways a malicious prover can violate each constraint.
+// The derived witnesses are recomputed afterwards unless the mutation breaks them on purpose.
+var witnessMutations = []struct {
+	name   string
+	derive bool
+	mutate func(c *MaliciousCircuit[*zp220.Uint], i int, v int64)
+}{
+	// InfNorm with bound 1.
+	{"Ternary", true, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		c.X[i].SetInt64(2 + v%100)
+	}},
+	// InfNorm with bound > 1.
+	{"InfNorm", true, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		c.Y[i].SetInt64(malYBound + 1 + v%100)
+	}},
+	// Linear.
+	{"NTT", false, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		perturbElem(c.XNTT[i], uint64(v))
+	}},
+	// Arithmetic.
+	{"Arithmetic", false, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		perturbElem(c.Prod[i], uint64(v))
+	}},
+	// SumCheck.
+	{"SumCheck", true, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		perturbElem(c.S[i], uint64(v))
+	}},
+	// SqTwoNorm.
+	{"SqTwoNorm", true, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		c.Z[i].SetInt64(malZBound + v%malZBound)
+	}},
+	// SqTwoNorm, where the squared norm wraps around the modulus.
+	{"SqTwoNormWrap", true, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		q := new(zp220.Uint).SetInt64(-1).BigInt(new(big.Int))
+		z := new(big.Int).Lsh(big.NewInt(1), uint(q.BitLen()/2+1))
+		c.Z[i].SetBigInt(z.Add(z, big.NewInt(v)))
+	}},
+	// ApproxInfNorm.
+	{"ApproxInfNorm", true, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		c.A[i].SetInt64(int64(len(c.A)) * malABound * (2 + v%100))
+	}},
+	// InfNorm with a big bound.
+	{"InfNormBig", true, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		c.YBig[i].SetBigInt(new(big.Int).Add(malYBigBound, big.NewInt(1+v%100)))
+	}},
+	// SqNorm with a big bound.
+	{"SqNormBig", true, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		z := new(big.Int).Lsh(big.NewInt(1), malZBigBits/2)
+		c.ZBig[i].SetBigInt(z.Add(z, big.NewInt(1+v%(1<<20))))
+	}},
+	// SumCheck with a big sum.
+	{"SumCheckBig", true, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		perturbElem(c.SBig[i], uint64(v))
+	}},
+	// ApproxInfNorm with a big bound.
+	{"ApproxInfNormBig", true, func(c *MaliciousCircuit[*zp220.Uint], i int, v int64) {
+		a := new(big.Int).Mul(malABigBound, big.NewInt(int64(len(c.ABig))*(2+v%100)))
+		c.ABig[i].SetBigInt(a)
+	}},
+}
+
+func TestMaliciousProver(t *testing.T) {
+	crs := []byte("Buckler!")
+	N := 1 << 11
+
+	c := MaliciousCircuit[*zp220.Uint]{
+		NTT: buckler.NewNTTChecker[*zp220.Uint](N),
+	}
+
+	prv, vrf, err := buckler.Compile(N, &c, crs)
+	assert.NoError(t, err)
+
+	honest := newMaliciousCircuit[*zp220.Uint](N)
+	pf, err := prv.Prove(honest)
+	assert.NoError(t, err)
+	assert.True(t, vrf.Verify(honest, pf))
+
+	pfBytes, err := pf.MarshalBinary()
+	assert.NoError(t, err)
+
+	t.Run("ApproxInfNormBound", func(t *testing.T) {
+		assert.NotPanics(t, func() { buckler.Compile(N, &ApproxBoundCircuit[*zp220.Uint]{Bound: malABigBound}, crs) })
+		bound := new(big.Int).Lsh(malABigBound, 1)
+		assert.Panics(t, func() { buckler.Compile(N, &ApproxBoundCircuit[*zp220.Uint]{Bound: bound}, crs) })
+	})
+
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 16
+
+	t.Run("Proof", func(t *testing.T) {
+		for _, m := range proofMutations {
+			properties := gopter.NewProperties(parameters)
+			properties.Property(m.name+" mutation should be rejected", prop.ForAll(
+				func(idx uint64) bool {
+					pfMut := &buckler.Proof[*zp220.Uint]{}
+					if err := pfMut.UnmarshalBinary(pfBytes); err != nil {
+						return false
+					}
+					m.mutate(pfMut, idx)
+					return !vrf.Verify(honest, pfMut)
+				},
+				gen.UInt64(),
+			))
+			properties.TestingRun(t, gopter.ConsoleReporter(false))
+		}
+	})
+
+	t.Run("Witness", func(t *testing.T) {
+		polyEval := bigpoly.NewCyclotomicEvaluator[*zp220.Uint](N)
+
+		// Each case is a full proof, so -short runs a single case per mutation.
+		parameters := gopter.DefaultTestParameters()
+		parameters.MinSuccessfulTests = 4
+		if testing.Short() {
+			parameters.MinSuccessfulTests = 1
+		}
+
+		for _, m := range witnessMutations {
+			properties := gopter.NewProperties(parameters)
+			properties.Property(m.name+" violation should be rejected", prop.ForAll(
+				func(i int, v int64) bool {
+					w := honest.clone()
+					m.mutate(w, i, max(v, -v))
+					if m.derive {
+						w.fillDerived(polyEval)
+					}
+
+					pf, err := prv.Prove(w)
+					if err != nil {
+						return true
+					}
+					return !vrf.Verify(w, pf)
+				},
+				gen.IntRange(0, N-1),
+				gen.Int64Range(0, math.MaxInt64),
+			))
+			properties.TestingRun(t, gopter.ConsoleReporter(false))
+		}
+	})
+}
+
+// ksStatistic returns the two-sample Kolmogorov-Smirnov statistic of a and b,
+// which is the largest distance between their empirical distribution functions.
+func ksStatistic[E bignum.Uint[E]](a, b []E) float64 {
+	sorted := func(v []E) []*big.Int {
+		vBig := make([]*big.Int, len(v))
+		for i := range v {
+			vBig[i] = v[i].BigInt(new(big.Int))
+		}
+		slices.SortFunc(vBig, (*big.Int).Cmp)
+		return vBig
+	}
+	aBig, bBig := sorted(a), sorted(b)
+
+	var d float64
+	var i, j int
+	for i < len(aBig) && j < len(bBig) {
+		x := aBig[i]
+		if bBig[j].Cmp(x) < 0 {
+			x = bBig[j]
+		}
+		for i < len(aBig) && aBig[i].Cmp(x) == 0 {
+			i++
+		}
+		for j < len(bBig) && bBig[j].Cmp(x) == 0 {
+			j++
+		}
+		d = max(d, math.Abs(float64(i)/float64(len(aBig))-float64(j)/float64(len(bBig))))
+	}
+	return d
+}
+
+func TestSimulator(t *testing.T) {
+	crs := []byte("Buckler!")
+
+	t.Run("Accept", func(t *testing.T) {
+		N := 1 << 11
+
+		c := MaliciousCircuit[*zp220.Uint]{
+			NTT: buckler.NewNTTChecker[*zp220.Uint](N),
+		}
+
+		_, vrf, err := buckler.Compile(N, &c, crs)
+		assert.NoError(t, err)
+
+		sim := buckler.NewSimulator(vrf)
+		pub := newMaliciousCircuit[*zp220.Uint](N)
+
+		tr, err := sim.Simulate(pub)
+		assert.NoError(t, err)
+		assert.True(t, vrf.VerifyPIOPTranscript(pub, tr))
+
+		tr.Evals[0].Add(tr.Evals[0], new(zp220.Uint).SetUint64(1))
+		assert.False(t, vrf.VerifyPIOPTranscript(pub, tr))
+	})
+
+	t.Run("Constraints", func(t *testing.T) {
+		N := 1 << 10
+
+		for _, tc := range constraintTests(N, crs) {
+			t.Run(tc.name, func(t *testing.T) {
+				_, vrf, err := buckler.Compile(N, tc.circuit, crs)
+				assert.NoError(t, err)
+
+				_, pub := tc.valid()
+				tr, err := buckler.NewSimulator(vrf).Simulate(pub)
+				assert.NoError(t, err)
+				assert.True(t, vrf.VerifyPIOPTranscript(pub, tr))
+			})
+		}
+	})
+
+	t.Run("Distribution", func(t *testing.T) {
+		N := 1 << 6
+		samples := 32
+
+		c := PublicKeyCircuit[*zp220.Uint]{
+			NTT: buckler.NewNTTChecker[*zp220.Uint](N),
+		}
+
+		prv, vrf, err := buckler.Compile(N, &c, crs)
+		assert.NoError(t, err)
+
+		sim := buckler.NewSimulator(vrf)
+		pk := newPkCircuit[*zp220.Uint](N)
+
+		var realEvals, simEvals []*zp220.Uint
+		var prev []*zp220.Uint
+		for range samples {
+			pf, err := prv.Prove(pk)
+			assert.NoError(t, err)
+
+			trReal, err := vrf.PIOPTranscript(pf)
+			assert.NoError(t, err)
+			assert.True(t, vrf.VerifyPIOPTranscript(pk, trReal))
+
+			trSim, err := sim.Simulate(pk)
+			assert.NoError(t, err)
+			assert.True(t, vrf.VerifyPIOPTranscript(pk, trSim))
+
+			// The witnesses and the mask come first in Evals,
+			// followed by the quotients and remainders of the arithmetic and linear checks.
+			// The evaluations of the former must be uniformly random in both transcripts.
+			masked := len(trReal.Evals) - 4
+			realEvals = append(realEvals, trReal.Evals[:masked]...)
+			simEvals = append(simEvals, trSim.Evals[:masked]...)
+
+			// The same witness must not give the same evaluations twice.
+			if prev != nil {
+				for i := range masked {
+					assert.NotEqual(t, prev[i].Marshal(), trReal.Evals[i].Marshal())
+				}
+			}
+			prev = trReal.Evals
+		}
+
+		uniformEvals := make([]*zp220.Uint, len(realEvals))
+		for i := range uniformEvals {
+			uniformEvals[i] = new(zp220.Uint).New().MustSetRandom()
+		}
+
+		// The real evaluations must follow the same distribution as the simulated and the uniform ones.
+		// The critical value of the Kolmogorov-Smirnov test at significance 1e-6
+		// is sqrt(-ln(1e-6 / 2) / 2) * sqrt(2 / n) for two samples of size n.
+		crit := math.Sqrt(-math.Log(1e-6/2)/2) * math.Sqrt(2/float64(len(realEvals)))
+		assert.Less(t, ksStatistic(realEvals, simEvals), crit)
+		assert.Less(t, ksStatistic(realEvals, uniformEvals), crit)
+	})
 }
 
 func BenchmarkPublicKey(b *testing.B) {
@@ -220,3 +1566,47 @@ func BenchmarkPublicKey(b *testing.B) {
 		assert.True(b, ok)
 	})
 }
+
+// katPath is the path of the known-answer test vectors,
+// which are generated by buckler/internal/katgen.
+var katPath = filepath.Join("testdata", "kat.json")
+
+func TestKAT(t *testing.T) {
+	b, err := os.ReadFile(katPath)
+	assert.NoError(t, err)
+
+	var vecs []kat.Vector
+	assert.NoError(t, json.Unmarshal(b, &vecs))
+
+	for i, vec := range vecs {
+		t.Run(fmt.Sprintf("Vector=%v", i), func(t *testing.T) {
+			vrf, pk, pf, err := kat.Prove(vec)
+			assert.NoError(t, err)
+
+			if vec.Digest != "" {
+				digest, err := kat.Digest(pf)
+				assert.NoError(t, err)
+				assert.Equal(t, vec.Digest, digest)
+			}
+
+			assert.True(t, vrf.Verify(pk, pf))
+
+			pfBytes, err := pf.MarshalBinary()
+			assert.NoError(t, err)
+
+			for _, m := range vec.Mutations {
+				t.Run(fmt.Sprintf("%v/%v", m.Field, m.Op), func(t *testing.T) {
+					pkMut, err := vec.Circuit()
+					assert.NoError(t, err)
+					pfMut := &buckler.Proof[*zp220.Uint]{}
+					assert.NoError(t, pfMut.UnmarshalBinary(pfBytes))
+
+					assert.NoError(t, m.Apply(pkMut, pfMut))
+					assert.NotPanics(t, func() {
+						assert.False(t, vrf.Verify(pkMut, pfMut))
+					})
+				})
+			}
+		})
+	}
+}
@@ -98,7 +98,7 @@ func (w *walker[E]) prvWalk(prv *Prover[E], v reflect.Value, pw []PublicWitness[
 
 	case reflect.TypeOf(Witness[E]{}):
 		sw[w.wCnt] = v.Interface().(Witness[E])
-		if len(sw[w.wCnt]) != prv.ctx.rank {
+		if len(sw[w.wCnt]) != prv.ctx.rank && !(len(sw[w.wCnt]) == 0 && prv.ctx.isProverFilled(w.wCnt)) {
 			return errRankMismatch
 		}
 		w.wCnt++
@@ -177,8 +177,22 @@ func Compile[E bignum.Uint[E]](witnessRank int, c Circuit[E], crs []byte) (*Prov
 
 	jindoParams := jindo.NewParameters[E](ctx.commitRank(), ctx.batch())
 
+	crsCopy := make([]byte, len(crs))
+	copy(crsCopy, crs)
+
 	embRank := 1 << bits.Len(uint(max(ctx.arithCheckMaxRank, ctx.sumCheckMaxRank)-1))
 
+	// The encodings of the fixed public witnesses are shared by the prover and verifier,
+	// which only read them.
+	ecd := newEncoder[E](witnessRank, embRank)
+	polyEval := bigpoly.NewCyclicEvaluator[E](embRank)
+	fixedEcd := make(map[uint64]*bigpoly.Poly[E], len(ctx.fixedWitness))
+	fixedEcdNTT := make(map[uint64]*bigpoly.Poly[E], len(ctx.fixedWitness))
+	for id, v := range ctx.fixedWitness {
+		fixedEcd[id] = ecd.Encode(v)
+		fixedEcdNTT[id] = polyEval.NTT(fixedEcd[id])
+	}
+
 	prv := &Prover[E]{
 		JindoParams: jindoParams,
 
@@ -188,6 +202,16 @@ func Compile[E bignum.Uint[E]](witnessRank int, c Circuit[E], crs []byte) (*Prov
 
 		polyProver: jindo.NewProver[E](jindoParams, crs),
 
+		crs:             crsCopy,
+		manyPolyProvers: make(map[int]*jindo.Prover[E]),
+
+		fixedEcd:    fixedEcd,
+		fixedEcdNTT: fixedEcdNTT,
+		fixedComs:   make(map[int][]*jindo.Commitment),
+		fixedOpens:  make(map[int][]*jindo.Opening),
+
+		securityTarget: DefaultSecurityTarget,
+
 		ctx: ctx,
 	}
 
@@ -200,8 +224,19 @@ func Compile[E bignum.Uint[E]](witnessRank int, c Circuit[E], crs []byte) (*Prov
 
 		polyVerifier: jindo.NewVerifier[E](jindoParams, crs),
 
+		crs:               crsCopy,
+		manyPolyVerifiers: make(map[int]*jindo.Verifier[E]),
+
+		fixedEcd:  fixedEcd,
+		fixedComs: make(map[int][]*jindo.Commitment),
+
+		securityTarget: DefaultSecurityTarget,
+
 		ctx: ctx,
 	}
 
+	prv.fixedComs[1], prv.fixedOpens[1] = prv.commitFixed(prv.polyProver)
+	vrf.fixedComs[1] = vrf.commitFixed(vrf.polyVerifier)
+
 	return prv, vrf, nil
 }
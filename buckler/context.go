@@ -1,6 +1,7 @@
 package buckler
 
 import (
+	"crypto/sha3"
 	"math/big"
 	"reflect"
 
@@ -36,19 +37,60 @@ type Context[E bignum.Uint[E]] struct {
 	linCheckConstraints map[LinearChecker[E]][][2]uint64
 
 	infDcmpBound   map[uint64]*big.Int
+	infDcmpBase    map[uint64][]*big.Int
 	infDcmpWitness map[uint64][]Witness[E]
 
 	twoDcmpBound   map[uint64]*big.Int
-	twoDcmpBase    map[uint64]PublicWitness[E]
-	twoDcmpMask    map[uint64]PublicWitness[E]
 	twoDcmpWitness map[uint64]Witness[E]
 
+	// gadgetDcmpBase are the bases of the gadget-decomposed witnesses, indexed by their IDs.
+	gadgetDcmpBase map[uint64]*big.Int
+	// gadgetDcmpWitness are the digits of the gadget-decomposed witnesses, indexed by their IDs.
+	gadgetDcmpWitness map[uint64][]Witness[E]
+	// proverFilled are the IDs of the witnesses computed by the prover.
+	proverFilled map[uint64]struct{}
+
+	// bitDcmpWitness are the bits of the bit-decomposed witnesses, indexed by their IDs.
+	bitDcmpWitness map[uint64][]Witness[E]
+
+	// dcmpOrder are the infinity-norm, gadget and bit decompositions in the order they are declared.
+	// The prover fills them in this order, since the source of a decomposition
+	// may be the output of an earlier one.
+	dcmpOrder []dcmpEntry
+
+	// fixedWitness are the values of the fixed public witnesses, indexed by their IDs.
+	fixedWitness map[uint64][]E
+	// fixedIDs are the IDs of the fixed public witnesses,
+	// in the order of their commitments.
+	fixedIDs []uint64
+
+	// maskedWitness are the public witnesses restricted to the public ranges
+	// of the partially public witnesses, indexed by their IDs.
+	// The value is the IDs of the restricted public witness and the fixed selector,
+	// and the masked public witness is their product.
+	maskedWitness map[uint64][2]uint64
+
 	projChecker        LinearChecker[E]
 	projWitness        map[uint64]Witness[E]
 	projInfDcmpBound   map[uint64]*big.Int
 	projInfDcmpWitness map[uint64]Witness[E]
 }
 
+// dcmpKind is the kind of a decomposition computed by the prover.
+type dcmpKind int
+
+const (
+	dcmpInf dcmpKind = iota
+	dcmpGadget
+	dcmpBit
+)
+
+// dcmpEntry is a decomposition of the witness with ID id.
+type dcmpEntry struct {
+	kind dcmpKind
+	id   uint64
+}
+
 // newContext creates a new [Context].
 func newContext[E bignum.Uint[E]](rank int, walker *walker[E]) *Context[E] {
 	return &Context[E]{
@@ -62,19 +104,109 @@ func newContext[E bignum.Uint[E]](rank int, walker *walker[E]) *Context[E] {
 		linCheckConstraints: make(map[LinearChecker[E]][][2]uint64),
 
 		infDcmpBound:   make(map[uint64]*big.Int),
+		infDcmpBase:    make(map[uint64][]*big.Int),
 		infDcmpWitness: make(map[uint64][]Witness[E]),
 
 		twoDcmpBound:   make(map[uint64]*big.Int),
-		twoDcmpBase:    make(map[uint64]PublicWitness[E]),
-		twoDcmpMask:    make(map[uint64]PublicWitness[E]),
 		twoDcmpWitness: make(map[uint64]Witness[E]),
 
+		gadgetDcmpBase:    make(map[uint64]*big.Int),
+		gadgetDcmpWitness: make(map[uint64][]Witness[E]),
+		proverFilled:      make(map[uint64]struct{}),
+
+		bitDcmpWitness: make(map[uint64][]Witness[E]),
+
+		fixedWitness:  make(map[uint64][]E),
+		maskedWitness: make(map[uint64][2]uint64),
+
 		projWitness:        make(map[uint64]Witness[E]),
 		projInfDcmpBound:   make(map[uint64]*big.Int),
 		projInfDcmpWitness: make(map[uint64]Witness[E]),
 	}
 }
 
+// NewFixedWitness declares a fixed public witness with value v,
+// which is the same for all assignments of the circuit.
+// Its encoding is precomputed and committed without hiding when compiling.
+// The prover opens the commitment together with the witnesses,
+// so the verifier reads its evaluation from the proof
+// instead of encoding and evaluating it on every verification.
+// The result can be used as a public witness in constraints.
+// Panics if len(v) != rank.
+func (ctx *Context[E]) NewFixedWitness(v []E) PublicWitness[E] {
+	switch {
+	case len(v) != ctx.rank:
+		panic("len(v) != rank")
+	}
+
+	pw := idToWitness[E, PublicWitness[E]](ctx.pwCnt)
+	ctx.pwCnt++
+
+	vCopy := make([]E, len(v))
+	for i := range v {
+		vCopy[i] = v[i].New().Set(v[i])
+	}
+	ctx.fixedWitness[witnessToID(pw)] = vCopy
+	ctx.fixedIDs = append(ctx.fixedIDs, witnessToID(pw))
+
+	return pw
+}
+
+// AddPublicRangeConstraint marks the index ranges of w as public,
+// and adds a constraint that w[i] = pw[i] for all i in the ranges.
+// Each range is a half-open interval [start, end).
+// The rest of w remains secret, and the entries of pw outside the ranges are ignored.
+//
+// This is enforced by a single arithmetic constraint sel * w = sel * pw,
+// where sel is the fixed selector of the ranges.
+// Panics if any range is out of [0, rank) or empty.
+func (ctx *Context[E]) AddPublicRangeConstraint(w Witness[E], pw PublicWitness[E], ranges ...[2]int) {
+	var z E
+
+	selVec := make([]E, ctx.rank)
+	for i := range selVec {
+		selVec[i] = z.New()
+	}
+	for _, r := range ranges {
+		switch {
+		case r[0] < 0 || r[1] > ctx.rank:
+			panic("range out of bounds")
+		case r[0] >= r[1]:
+			panic("empty range")
+		}
+		for i := r[0]; i < r[1]; i++ {
+			selVec[i].SetInt64(1)
+		}
+	}
+	sel := ctx.NewFixedWitness(selVec)
+
+	pwMasked := idToWitness[E, PublicWitness[E]](ctx.pwCnt)
+	ctx.pwCnt++
+	ctx.maskedWitness[witnessToID(pwMasked)] = [2]uint64{witnessToID(pw), witnessToID(sel)}
+
+	var maskConstraint ArithmeticConstraint[E]
+	maskConstraint.AddTerm(sel, w)
+	maskConstraint.SubTerm(pwMasked)
+	ctx.AddArithmeticConstraint(maskConstraint)
+}
+
+// fillInternalPublicWitness sets the public witnesses declared in the context,
+// after the public witnesses of the circuit are read to pw.
+func (ctx *Context[E]) fillInternalPublicWitness(pw []PublicWitness[E]) {
+	var z E
+
+	for id, v := range ctx.fixedWitness {
+		pw[id] = v
+	}
+
+	for id, src := range ctx.maskedWitness {
+		pw[id] = make(PublicWitness[E], ctx.rank)
+		for i := range ctx.rank {
+			pw[id][i] = z.New().Mul(pw[src[0]][i], pw[src[1]][i])
+		}
+	}
+}
+
 // AddArithmeticConstraint adds an arithmetic constraint to the context.
 func (ctx *Context[E]) AddArithmeticConstraint(c ArithmeticConstraint[E]) {
 	ctx.arithConstraints = append(ctx.arithConstraints, c)
@@ -115,6 +247,12 @@ func (ctx *Context[E]) AddInfNormConstraint(w Witness[E], bound uint64) {
 
 // AddInfNormConstraintBig adds an infinity-norm constraint to the context.
 func (ctx *Context[E]) AddInfNormConstraintBig(w Witness[E], bound *big.Int) {
+	ctx.addInfNormConstraint(w, bound, decomposeBase)
+}
+
+// addInfNormConstraint adds an infinity-norm constraint to the context,
+// decomposing w in the ternary base returned by dcmpBaseOf(bound).
+func (ctx *Context[E]) addInfNormConstraint(w Witness[E], bound *big.Int, dcmpBaseOf func(*big.Int) []*big.Int) {
 	var z E
 
 	switch {
@@ -133,7 +271,7 @@ func (ctx *Context[E]) AddInfNormConstraintBig(w Witness[E], bound *big.Int) {
 		return
 	}
 
-	dcmpBase := decomposeBase(bound)
+	dcmpBase := dcmpBaseOf(bound)
 
 	id := witnessToID(w)
 	wDcmp := make([]Witness[E], 0, len(dcmpBase))
@@ -142,6 +280,8 @@ func (ctx *Context[E]) AddInfNormConstraintBig(w Witness[E], bound *big.Int) {
 	}
 	ctx.infDcmpWitness[id] = wDcmp
 	ctx.infDcmpBound[id] = bound
+	ctx.infDcmpBase[id] = dcmpBase
+	ctx.dcmpOrder = append(ctx.dcmpOrder, dcmpEntry{kind: dcmpInf, id: id})
 	ctx.wCnt += uint64(len(dcmpBase))
 
 	for i := range wDcmp {
@@ -160,6 +300,117 @@ func (ctx *Context[E]) AddInfNormConstraintBig(w Witness[E], bound *big.Int) {
 	ctx.AddArithmeticConstraint(dcmpConstraint)
 }
 
+// AddBinaryConstraint adds a constraint that every entry of w is 0 or 1 to the context.
+func (ctx *Context[E]) AddBinaryConstraint(w Witness[E]) {
+	var z E
+
+	var binConstraint ArithmeticConstraint[E]
+	binConstraint.AddTermWithConst(z.New().SetInt64(1), nil, w, w)
+	binConstraint.AddTermWithConst(z.New().SetInt64(-1), nil, w)
+	ctx.AddArithmeticConstraint(binConstraint)
+}
+
+// AddBitDecompositionConstraint adds a constraint that w = sum 2^i * bits[i]
+// for binary witnesses bits of length numBits to the context, and returns bits.
+// That is, each entry of w is in [0, 2^numBits) and bits is its bit decomposition.
+// The bits are computed by the prover, and can be used in other constraints.
+// Proving fails if an entry of w is not in [0, 2^numBits).
+//
+// Calling this again for the same w returns the same bits.
+// Panics if numBits is not in [1, log2(q) - 1) for the field modulus q,
+// since otherwise sum 2^i * bits[i] may wrap around q,
+// or if w is already decomposed with a different numBits.
+func (ctx *Context[E]) AddBitDecompositionConstraint(w Witness[E], numBits int) []Witness[E] {
+	var z E
+
+	switch {
+	case numBits < 1:
+		panic("numBits must be positive")
+	case numBits >= bignum.Modulus[E]().BitLen()-1:
+		panic("numBits too large for the field modulus")
+	}
+
+	if bits, ok := ctx.bitDcmpWitness[witnessToID(w)]; ok {
+		if len(bits) != numBits {
+			panic("witness already decomposed with a different numBits")
+		}
+		return bits
+	}
+
+	bits := make([]Witness[E], numBits)
+	for i := range bits {
+		bits[i] = idToWitness[E, Witness[E]](ctx.wCnt)
+		ctx.wCnt++
+		ctx.AddBinaryConstraint(bits[i])
+	}
+	ctx.bitDcmpWitness[witnessToID(w)] = bits
+	ctx.dcmpOrder = append(ctx.dcmpOrder, dcmpEntry{kind: dcmpBit, id: witnessToID(w)})
+
+	var dcmpConstraint ArithmeticConstraint[E]
+	dcmpConstraint.AddTermWithConst(z.New().SetInt64(1), nil, w)
+	pow := new(big.Int)
+	for i := range bits {
+		negPow := z.New().SetBigInt(pow.Lsh(big.NewInt(1), uint(i)))
+		dcmpConstraint.AddTermWithConst(negPow.Neg(negPow), nil, bits[i])
+	}
+	ctx.AddArithmeticConstraint(dcmpConstraint)
+
+	return bits
+}
+
+// AddGadgetDecompositionConstraint adds a constraint that digits is
+// the gadget decomposition of x in the given base to the context.
+// That is, x = sum base^i * digits[i] and each digit is bounded by base/2 in infinity-norm.
+//
+// The digits are the balanced digits computed by the prover,
+// so the prover can leave them empty when proving.
+// x must be small enough to be decomposed into len(digits) digits,
+// where x is regarded as the centered representative modulo the field modulus.
+// Otherwise, proving fails.
+// Panics if base < 2 or digits is empty.
+func (ctx *Context[E]) AddGadgetDecompositionConstraint(digits []Witness[E], x Witness[E], base *big.Int) {
+	var z E
+
+	switch {
+	case base.Cmp(big.NewInt(2)) < 0:
+		panic("base must be at least 2")
+	case len(digits) == 0:
+		panic("digits must not be empty")
+	}
+
+	id := witnessToID(x)
+	ctx.gadgetDcmpBase[id] = new(big.Int).Set(base)
+	ctx.gadgetDcmpWitness[id] = digits
+	ctx.dcmpOrder = append(ctx.dcmpOrder, dcmpEntry{kind: dcmpGadget, id: id})
+	for _, d := range digits {
+		ctx.proverFilled[witnessToID(d)] = struct{}{}
+	}
+
+	var dcmpConstraint ArithmeticConstraint[E]
+	dcmpConstraint.AddTermWithConst(z.New().SetInt64(1), nil, x)
+	pow := big.NewInt(1)
+	for i := range digits {
+		negPow := z.New().SetBigInt(pow)
+		dcmpConstraint.AddTermWithConst(negPow.Neg(negPow), nil, digits[i])
+		pow.Mul(pow, base)
+	}
+	ctx.AddArithmeticConstraint(dcmpConstraint)
+
+	// The balanced digits reach base/2 itself when base is even,
+	// so the digits are decomposed to cover the bound inclusively.
+	bound := new(big.Int).Rsh(base, 1)
+	for i := range digits {
+		ctx.addInfNormConstraint(digits[i], bound, infNormBase)
+	}
+}
+
+// isProverFilled returns true if the witness is computed by the prover,
+// so that it can be left empty when proving.
+func (ctx *Context[E]) isProverFilled(id uint64) bool {
+	_, ok := ctx.proverFilled[id]
+	return ok
+}
+
 // AddSqTwoNormConstraint adds a squared two-norm constraint to the context.
 // Note that this only proves the constraint over modulo witness modulus.
 func (ctx *Context[E]) AddSqTwoNormConstraint(w Witness[E], bound uint64) {
@@ -175,13 +426,20 @@ func (ctx *Context[E]) AddSqNormConstraintBig(w Witness[E], bound *big.Int) {
 	wDcmp := idToWitness[E, Witness[E]](ctx.wCnt)
 	ctx.wCnt += 1
 
-	pwBase := idToWitness[E, PublicWitness[E]](ctx.pwCnt)
-	pwMask := idToWitness[E, PublicWitness[E]](ctx.pwCnt + 1)
-	ctx.pwCnt += 2
+	base := decomposeBase(bound)
+	baseVec := make([]E, ctx.rank)
+	maskVec := make([]E, ctx.rank)
+	for i := range ctx.rank {
+		baseVec[i], maskVec[i] = z.New(), z.New()
+		if i < len(base) {
+			baseVec[i].SetBigInt(base[i])
+			maskVec[i].SetInt64(1)
+		}
+	}
+	pwBase := ctx.NewFixedWitness(baseVec)
+	pwMask := ctx.NewFixedWitness(maskVec)
 
 	ctx.twoDcmpBound[id] = bound
-	ctx.twoDcmpBase[id] = pwBase
-	ctx.twoDcmpMask[id] = pwMask
 	ctx.twoDcmpWitness[id] = wDcmp
 
 	var binConstraint ArithmeticConstraint[E]
@@ -195,6 +453,35 @@ func (ctx *Context[E]) AddSqNormConstraintBig(w Witness[E], bound *big.Int) {
 	ctx.AddSumCheckConstraint(dcmpConstraint, 0)
 }
 
+// AddHammingWeightConstraint adds a constraint that w is ternary
+// with exactly weight nonzero entries to the context.
+// The number of nonzero entries is the sum of w^2, which is checked by a sumcheck.
+func (ctx *Context[E]) AddHammingWeightConstraint(w Witness[E], weight uint64) {
+	var z E
+
+	ctx.AddInfNormConstraint(w, 1)
+
+	var weightConstraint ArithmeticConstraint[E]
+	weightConstraint.AddTermWithConst(z.New().SetInt64(1), nil, w, w)
+	ctx.AddSumCheckConstraint(weightConstraint, weight)
+}
+
+// AddSignedHammingWeightConstraint adds a constraint that w is ternary
+// with exactly numPos entries equal to 1 and numNeg entries equal to -1 to the context.
+// In addition to [Context.AddHammingWeightConstraint],
+// the sum of w is checked to be numPos - numNeg.
+func (ctx *Context[E]) AddSignedHammingWeightConstraint(w Witness[E], numPos, numNeg uint64) {
+	var z E
+
+	ctx.AddHammingWeightConstraint(w, numPos+numNeg)
+
+	var signConstraint ArithmeticConstraint[E]
+	signConstraint.AddTermWithConst(z.New().SetInt64(1), nil, w)
+	sum := new(big.Int).SetUint64(numPos)
+	sum.Sub(sum, new(big.Int).SetUint64(numNeg))
+	ctx.AddSumCheckConstraintBig(signConstraint, sum)
+}
+
 // AddApproxInfNormConstraint adds a approximate inf-norm constraint to the context.
 // The slack is around rank.
 func (ctx *Context[E]) AddApproxInfNormConstraint(w Witness[E], bound uint64) {
@@ -203,7 +490,14 @@ func (ctx *Context[E]) AddApproxInfNormConstraint(w Witness[E], bound uint64) {
 
 // AddApproxInfNormConstraint adds a approximate inf-norm constraint to the context.
 // The slack is around rank.
+// Panics if the decomposition of the projection with slack does not fit in rank.
 func (ctx *Context[E]) AddApproxInfNormConstraintBig(w Witness[E], bound *big.Int) {
+	slackBound := new(big.Int).SetUint64(uint64(ctx.rank))
+	slackBound.Mul(slackBound, bound)
+	if projRows*len(decomposeBase(slackBound)) > ctx.rank {
+		panic("bound too large for the rank")
+	}
+
 	if ctx.projChecker == nil {
 		ctx.projChecker = newProjChecker[E](ctx.rank)
 	}
@@ -217,8 +511,6 @@ func (ctx *Context[E]) AddApproxInfNormConstraintBig(w Witness[E], bound *big.In
 	wProjDcmp := idToWitness[E, Witness[E]](ctx.wCnt)
 	ctx.wCnt++
 
-	slackBound := new(big.Int).SetUint64(uint64(ctx.rank))
-	slackBound.Mul(slackBound, bound)
 	ctx.projInfDcmpBound[witnessToID(wProj)] = slackBound
 	ctx.projInfDcmpWitness[witnessToID(wProj)] = wProjDcmp
 	ctx.AddLinearConstraint(wProj, wProjDcmp, newProjRecomposeChecker[E](slackBound))
@@ -227,9 +519,64 @@ func (ctx *Context[E]) AddApproxInfNormConstraintBig(w Witness[E], bound *big.In
 	ctx.wSecond = append(ctx.wSecond, wProj, wProjDcmp)
 }
 
+// setProjection sets the random projection matrix from the challenge projConst.
+func (ctx *Context[E]) setProjection(projConst []byte) {
+	xofProj := sha3.NewSHAKE128()
+	xofProj.Write(projConst)
+
+	chk := ctx.projChecker.(*projChecker[E])
+	var projBuf [32]byte
+	for j := 0; j < ctx.rank; j++ {
+		xofProj.Read(projBuf[:])
+		for i := range projRows {
+			chk.proj[i][j] = (projBuf[i/8]>>(i%8))&1 == 0
+		}
+	}
+}
+
+// sumCheckOffset returns the sums of the sumcheck constraints divided by rank,
+// batched by the powers of batchConst for n instances in the same order as evalCircuit.
+// The batched constraints minus the offset sum to zero over the domain
+// if and only if each constraint sums to its claimed sum, with high probability.
+func (ctx *Context[E]) sumCheckOffset(batchConst E, n int) E {
+	var z E
+
+	rankInv := z.New().SetUint64(uint64(ctx.rank))
+	rankInv.Inverse(rankInv)
+
+	offset, sum := z.New(), z.New()
+	for range n {
+		for i := range ctx.sumCheckSums {
+			sum.SetBigInt(ctx.sumCheckSums[i])
+			sum.Mul(sum, rankInv)
+			offset.Mul(offset, batchConst)
+			offset.Add(offset, sum)
+		}
+	}
+	offset.Mul(offset, batchConst)
+
+	return offset
+}
+
+// isSecondRound returns a slice indicating whether
+// each witness is committed in the second round.
+func (ctx *Context[E]) isSecondRound() []bool {
+	isSecond := make([]bool, ctx.wCnt)
+	for _, w := range ctx.wSecond {
+		isSecond[witnessToID(w)] = true
+	}
+	return isSecond
+}
+
 // batch returns the number of polynomials to commit.
 func (ctx *Context[E]) batch() int {
-	batch := int(ctx.wCnt)
+	return ctx.batchMany(1)
+}
+
+// batchMany returns the number of polynomials to commit
+// when proving n instances at once.
+func (ctx *Context[E]) batchMany(n int) int {
+	batch := n*int(ctx.wCnt) + len(ctx.fixedIDs)
 
 	if len(ctx.arithConstraints) > 0 {
 		batch += 1
@@ -250,6 +597,9 @@ func (ctx *Context[E]) commitRank() int {
 	if ctx.wCnt > 0 {
 		rank = ctx.rank + 1
 	}
+	if len(ctx.fixedIDs) > 0 {
+		rank = max(rank, ctx.rank)
+	}
 
 	for i := range ctx.arithConstraints {
 		quoRank := ctx.arithConstraints[i].maxRank(ctx.rank) - ctx.rank
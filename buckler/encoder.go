@@ -1,6 +1,8 @@
 package buckler
 
 import (
+	"io"
+
 	"github.com/sp301415/ringo-snark/math/bignum"
 	"github.com/sp301415/ringo-snark/math/bigpoly"
 )
@@ -9,6 +11,10 @@ import (
 type Encoder[E bignum.Uint[E]] struct {
 	ntt       *bigpoly.CyclicTransformer[E]
 	embedRank int
+
+	// rand is the source of randomness for RandEncodeTo.
+	// If nil, crypto/rand is used.
+	rand io.Reader
 }
 
 // newEncoder creates a new [Encoder].
@@ -49,6 +55,6 @@ func (e *Encoder[E]) RandEncode(v []E) *bigpoly.Poly[E] {
 // v should have length rank.
 func (e *Encoder[E]) RandEncodeTo(pOut *bigpoly.Poly[E], v []E) {
 	e.EncodeTo(pOut, v)
-	pOut.Coeffs[e.ntt.Rank()].MustSetRandom()
+	bignum.MustSetRandomFrom(pOut.Coeffs[e.ntt.Rank()], e.rand)
 	pOut.Coeffs[0].Sub(pOut.Coeffs[0], pOut.Coeffs[e.ntt.Rank()])
 }
@@ -1,12 +1,19 @@
 package buckler
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
 	"github.com/sp301415/ringo-snark/jindo"
 	"github.com/sp301415/ringo-snark/math/bignum"
 )
 
 // Proof is the proof for the circuit.
 type Proof[E bignum.Uint[E]] struct {
+	// Witness are the commitments of the proof.
+	// The commitments of the fixed public witnesses are not included,
+	// as the verifier computes them when compiling.
 	Witness []*jindo.Commitment
 
 	LinCheckMaskSum E
@@ -15,3 +22,131 @@ type Proof[E bignum.Uint[E]] struct {
 	Evals     []E
 	EvalProof *jindo.Proof
 }
+
+// PIOPChallenges are the challenges of the PIOP.
+type PIOPChallenges[E bignum.Uint[E]] struct {
+	// Projection is the seed of the random projection.
+	Projection []byte
+
+	ArithBatch    E
+	LinCheckBatch E
+	LinCheck      E
+	SumCheckBatch E
+	EvalPoint     E
+}
+
+// PIOPTranscript is the part of a [Proof] checked by the PIOP,
+// together with the challenges it is checked against.
+type PIOPTranscript[E bignum.Uint[E]] struct {
+	Challenges PIOPChallenges[E]
+
+	LinCheckMaskSum E
+	SumCheckMaskSum E
+
+	Evals []E
+}
+
+// MarshalBinary encodes pf into bytes.
+func (pf *Proof[E]) MarshalBinary() ([]byte, error) {
+	b := binary.BigEndian.AppendUint64(nil, uint64(len(pf.Witness)))
+	for _, com := range pf.Witness {
+		comBytes, err := com.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = appendBytes(b, comBytes)
+	}
+
+	for _, sum := range []E{pf.LinCheckMaskSum, pf.SumCheckMaskSum} {
+		if isNil(sum) {
+			b = append(b, 0)
+		} else {
+			b = append(b, 1)
+			b = appendBytes(b, sum.Marshal())
+		}
+	}
+
+	b = binary.BigEndian.AppendUint64(b, uint64(len(pf.Evals)))
+	for _, e := range pf.Evals {
+		b = appendBytes(b, e.Marshal())
+	}
+
+	pfBytes, err := pf.EvalProof.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return appendBytes(b, pfBytes), nil
+}
+
+// UnmarshalBinary decodes pf from bytes written by [Proof.MarshalBinary].
+func (pf *Proof[E]) UnmarshalBinary(b []byte) error {
+	var z E
+	r := bytes.NewReader(b)
+
+	comCnt, err := readUint64(r)
+	if err != nil {
+		return err
+	}
+	if comCnt > uint64(r.Len()) {
+		return errors.New("buckler: invalid length")
+	}
+	pf.Witness = make([]*jindo.Commitment, comCnt)
+	for i := range pf.Witness {
+		comBytes, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		pf.Witness[i] = &jindo.Commitment{}
+		if err := pf.Witness[i].UnmarshalBinary(comBytes); err != nil {
+			return err
+		}
+	}
+
+	for _, sum := range []*E{&pf.LinCheckMaskSum, &pf.SumCheckMaskSum} {
+		flag, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		var zero E
+		*sum = zero
+		if flag == 1 {
+			sumBytes, err := readBytes(r)
+			if err != nil {
+				return err
+			}
+			*sum = z.New()
+			(*sum).Unmarshal(sumBytes)
+		}
+	}
+
+	evalCnt, err := readUint64(r)
+	if err != nil {
+		return err
+	}
+	if evalCnt > uint64(r.Len()) {
+		return errors.New("buckler: invalid length")
+	}
+	pf.Evals = make([]E, evalCnt)
+	for i := range pf.Evals {
+		evalBytes, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		pf.Evals[i] = z.New()
+		pf.Evals[i].Unmarshal(evalBytes)
+	}
+
+	pfBytes, err := readBytes(r)
+	if err != nil {
+		return err
+	}
+	pf.EvalProof = &jindo.Proof{}
+	if err := pf.EvalProof.UnmarshalBinary(pfBytes); err != nil {
+		return err
+	}
+
+	if r.Len() != 0 {
+		return errors.New("buckler: trailing bytes")
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+// Package kat defines the known-answer test vectors of Buckler,
+// which prove [PublicKeyCircuit] over zp220.
+//
+// The vectors are generated by buckler/internal/katgen and checked by TestKAT.
+// A vector stores the witness, but not the proof, which is reproduced from its seed.
+//
+// This package is test-only code: it is shared by TestKAT and katgen,
+// and [PublicKeyCircuit] is a test fixture, not a supported circuit.
+package kat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"github.com/sp301415/ringo-snark/buckler"
+	"github.com/sp301415/ringo-snark/buckler/internal/zp220"
+	"github.com/sp301415/ringo-snark/math/bignum"
+	"github.com/sp301415/ringo-snark/math/bigpoly"
+	"github.com/sp301415/ringo-snark/math/csprng"
+	"github.com/tuneinsight/lattigo/v6/ring"
+)
+
+// PublicKeyCircuit proves that PkNTT is an RLWE public key
+// whose secret key and noise are ternary.
+type PublicKeyCircuit[E bignum.Uint[E]] struct {
+	NTT buckler.LinearChecker[E]
+
+	Sk    buckler.Witness[E]
+	SkNTT buckler.Witness[E]
+
+	PkNTT [2]buckler.PublicWitness[E]
+
+	Noise    buckler.Witness[E]
+	NoiseNTT buckler.Witness[E]
+}
+
+func (c *PublicKeyCircuit[E]) Define(ctx *buckler.Context[E]) {
+	var z E
+
+	ctx.AddLinearConstraint(c.SkNTT, c.Sk, c.NTT)
+	ctx.AddLinearConstraint(c.NoiseNTT, c.Noise, c.NTT)
+
+	// pk[1] - pk[0] * sk - noise = 0
+	var pkCircuit buckler.ArithmeticConstraint[E]
+	pkCircuit.AddTermWithConst(z.New().SetInt64(1), c.PkNTT[1])
+	pkCircuit.AddTermWithConst(z.New().SetInt64(-1), c.PkNTT[0], c.SkNTT)
+	pkCircuit.AddTermWithConst(z.New().SetInt64(-1), nil, c.NoiseNTT)
+	ctx.AddArithmeticConstraint(pkCircuit)
+
+	ctx.AddInfNormConstraint(c.Sk, 1)
+	ctx.AddInfNormConstraint(c.Noise, 1)
+}
+
+// NewPublicKeyCircuit returns the witness of [PublicKeyCircuit]
+// with the secret key sk, the noise noise and the first public key pk0 in NTT form.
+func NewPublicKeyCircuit[E bignum.Uint[E]](rank int, sk, noise []int64, pk0 []E) *PublicKeyCircuit[E] {
+	polyEval := bigpoly.NewCyclotomicEvaluator[E](rank)
+
+	skPoly := polyEval.NewPoly(false)
+	noisePoly := polyEval.NewPoly(false)
+	for i := range rank {
+		skPoly.Coeffs[i].SetInt64(sk[i])
+		noisePoly.Coeffs[i].SetInt64(noise[i])
+	}
+	skNTT := polyEval.NTT(skPoly)
+	noiseNTT := polyEval.NTT(noisePoly)
+
+	pkNTT := [2]*bigpoly.Poly[E]{polyEval.NewPoly(true), polyEval.NewPoly(true)}
+	for i := range rank {
+		pkNTT[0].Coeffs[i].Set(pk0[i])
+	}
+	polyEval.MulTo(pkNTT[1], pkNTT[0], skNTT)
+	polyEval.AddTo(pkNTT[1], pkNTT[1], noiseNTT)
+
+	return &PublicKeyCircuit[E]{
+		Sk:    skPoly.Coeffs,
+		SkNTT: skNTT.Coeffs,
+
+		PkNTT: [2]buckler.PublicWitness[E]{
+			pkNTT[0].Coeffs,
+			pkNTT[1].Coeffs,
+		},
+
+		Noise:    noisePoly.Coeffs,
+		NoiseNTT: noiseNTT.Coeffs,
+	}
+}
+
+// Vector is a known-answer test vector of Buckler.
+// All byte strings are hex encoded, and field elements are encoded by Marshal.
+type Vector struct {
+	N int `json:"n"`
+
+	CRS  string `json:"crs"`
+	Seed string `json:"seed"`
+
+	Sk    []int64  `json:"sk"`
+	Noise []int64  `json:"noise"`
+	Pk0   []string `json:"pk0"`
+
+	// Digest is the SHA-256 digest of the proof, or empty if it is not pinned.
+	Digest string `json:"digest,omitempty"`
+	// Mutations are the changes of the statement and the proof
+	// which must be rejected by the verifier.
+	Mutations []Mutation `json:"mutations,omitempty"`
+}
+
+// Ops of a [Mutation].
+const (
+	// OpPerturb changes the Index-th entry by one.
+	OpPerturb = "perturb"
+	// OpDrop removes the last entry.
+	OpDrop = "drop"
+	// OpDup appends a copy of the last entry.
+	OpDup = "dup"
+	// OpLevel removes the last level of the Index-th entry.
+	OpLevel = "level"
+	// OpNil sets the field to nil.
+	OpNil = "nil"
+)
+
+// Mutation is a field-level change of a valid statement and proof.
+//
+// Field is "PkNTT1", which is the second public key of the statement,
+// or a field of [buckler.Proof], where "EvalProof.<Field>" is a field of the PCS proof.
+// The polynomials of "Witness" are changed in its first commitment.
+type Mutation struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Index int    `json:"index,omitempty"`
+}
+
+// Compile compiles [PublicKeyCircuit] with the rank and the CRS of vec.
+func (vec Vector) Compile() (*buckler.Prover[*zp220.Uint], *buckler.Verifier[*zp220.Uint], error) {
+	crs, err := hex.DecodeString(vec.CRS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := PublicKeyCircuit[*zp220.Uint]{
+		NTT: buckler.NewNTTChecker[*zp220.Uint](vec.N),
+	}
+	return buckler.Compile(vec.N, &c, crs)
+}
+
+// Circuit returns the witness of vec.
+func (vec Vector) Circuit() (*PublicKeyCircuit[*zp220.Uint], error) {
+	pk0 := make([]*zp220.Uint, len(vec.Pk0))
+	for i := range pk0 {
+		b, err := hex.DecodeString(vec.Pk0[i])
+		if err != nil {
+			return nil, err
+		}
+		pk0[i] = new(zp220.Uint)
+		pk0[i].Unmarshal(b)
+	}
+	return NewPublicKeyCircuit(vec.N, vec.Sk, vec.Noise, pk0), nil
+}
+
+// Prove proves the witness of vec with the prover seeded by its seed.
+// It returns the verifier, the witness and the proof.
+func Prove(vec Vector) (*buckler.Verifier[*zp220.Uint], *PublicKeyCircuit[*zp220.Uint], *buckler.Proof[*zp220.Uint], error) {
+	prv, vrf, err := vec.Compile()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	seed, err := hex.DecodeString(vec.Seed)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pk, err := vec.Circuit()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	prv.SetSeed(seed)
+	pf, err := prv.Prove(pk)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return vrf, pk, pf, nil
+}
+
+// Digest returns the SHA-256 digest of pf, as pinned by [Vector.Digest].
+func Digest(pf *buckler.Proof[*zp220.Uint]) (string, error) {
+	b, err := pf.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// Generate returns the known-answer test vectors.
+func Generate() ([]Vector, error) {
+	seed := []byte("Buckler KAT")
+	vec := Vector{
+		N: 1 << 6,
+
+		CRS:  hex.EncodeToString([]byte("Buckler!")),
+		Seed: hex.EncodeToString(seed),
+	}
+
+	// The witness is derived from the seed, so that the vectors are reproducible.
+	u := csprng.NewUniformSamplerWithSeed(seed)
+	vec.Sk = make([]int64, vec.N)
+	vec.Noise = make([]int64, vec.N)
+	vec.Pk0 = make([]string, vec.N)
+	for i := range vec.N {
+		vec.Sk[i] = int64(u.SampleN(3)) - 1
+		vec.Noise[i] = int64(u.SampleN(3)) - 1
+		vec.Pk0[i] = hex.EncodeToString(bignum.MustSetRandomFrom(new(zp220.Uint), u).Marshal())
+	}
+
+	_, _, pf, err := Prove(vec)
+	if err != nil {
+		return nil, err
+	}
+	if vec.Digest, err = Digest(pf); err != nil {
+		return nil, err
+	}
+	vec.Mutations = []Mutation{
+		{Field: "PkNTT1", Op: OpPerturb},
+		{Field: "Witness", Op: OpPerturb},
+		{Field: "Witness", Op: OpDrop},
+		{Field: "Witness", Op: OpDup},
+		{Field: "LinCheckMaskSum", Op: OpPerturb},
+		{Field: "LinCheckMaskSum", Op: OpNil},
+		{Field: "Evals", Op: OpPerturb},
+		{Field: "Evals", Op: OpDrop},
+		{Field: "Evals", Op: OpDup},
+		{Field: "EvalProof", Op: OpNil},
+		{Field: "EvalProof.InCommit", Op: OpDrop},
+		{Field: "EvalProof.Partial", Op: OpPerturb},
+		{Field: "EvalProof.Partial", Op: OpLevel},
+		{Field: "EvalProof.Encode", Op: OpDup},
+		{Field: "EvalProof.MLWE", Op: OpDrop},
+	}
+
+	return []Vector{vec}, nil
+}
+
+// Apply applies m to pk and pf in place.
+func (m Mutation) Apply(pk *PublicKeyCircuit[*zp220.Uint], pf *buckler.Proof[*zp220.Uint]) error {
+	switch m.Field {
+	case "PkNTT1":
+		if m.Op != OpPerturb || m.Index >= len(pk.PkNTT[1]) {
+			return m.invalid()
+		}
+		pk.PkNTT[1][m.Index] = perturb(pk.PkNTT[1][m.Index])
+		return nil
+	case "Witness":
+		switch m.Op {
+		case OpDrop:
+			pf.Witness = pf.Witness[:len(pf.Witness)-1]
+			return nil
+		case OpDup:
+			pf.Witness = append(pf.Witness, pf.Witness[len(pf.Witness)-1])
+			return nil
+		}
+		return m.applyPolys(&pf.Witness[0].Value)
+	case "LinCheckMaskSum":
+		switch m.Op {
+		case OpPerturb:
+			pf.LinCheckMaskSum = perturb(pf.LinCheckMaskSum)
+			return nil
+		case OpNil:
+			pf.LinCheckMaskSum = nil
+			return nil
+		}
+	case "Evals":
+		switch m.Op {
+		case OpPerturb:
+			if m.Index >= len(pf.Evals) {
+				return m.invalid()
+			}
+			pf.Evals[m.Index] = perturb(pf.Evals[m.Index])
+			return nil
+		case OpDrop:
+			pf.Evals = pf.Evals[:len(pf.Evals)-1]
+			return nil
+		case OpDup:
+			pf.Evals = append(pf.Evals, pf.Evals[len(pf.Evals)-1])
+			return nil
+		}
+	case "EvalProof":
+		if m.Op == OpNil {
+			pf.EvalProof = nil
+			return nil
+		}
+	case "EvalProof.InCommit":
+		return m.applyPolys(&pf.EvalProof.InCommit)
+	case "EvalProof.Partial":
+		return m.applyPolys(&pf.EvalProof.Partial)
+	case "EvalProof.Encode":
+		return m.applyPolys(&pf.EvalProof.Encode)
+	case "EvalProof.MLWE":
+		return m.applyPolys(&pf.EvalProof.MLWE)
+	}
+	return m.invalid()
+}
+
+// applyPolys applies m to ps.
+func (m Mutation) applyPolys(ps *[]ring.Poly) error {
+	switch m.Op {
+	case OpDrop:
+		*ps = (*ps)[:len(*ps)-1]
+		return nil
+	case OpDup:
+		*ps = append(*ps, *(*ps)[len(*ps)-1].CopyNew())
+		return nil
+	}
+
+	if m.Index >= len(*ps) {
+		return m.invalid()
+	}
+	p := &(*ps)[m.Index]
+	switch m.Op {
+	case OpPerturb:
+		// Decrementing a nonzero coefficient keeps it below the modulus.
+		if p.Coeffs[0][0] > 0 {
+			p.Coeffs[0][0]--
+		} else {
+			p.Coeffs[0][0]++
+		}
+		return nil
+	case OpLevel:
+		p.Coeffs = slices.Clone(p.Coeffs[:len(p.Coeffs)-1])
+		return nil
+	}
+	return m.invalid()
+}
+
+// invalid returns the error of an unsupported mutation.
+func (m Mutation) invalid() error {
+	return fmt.Errorf("kat: unsupported mutation %+v", m)
+}
+
+// perturb returns x + 1.
+func perturb(x *zp220.Uint) *zp220.Uint {
+	return new(zp220.Uint).Add(x, new(zp220.Uint).SetUint64(1))
+}
@@ -0,0 +1,37 @@
+// Command katgen writes the known-answer test vectors of Buckler.
+//
+// Run it from the root of the module as
+//
+//	go run ./buckler/internal/katgen
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sp301415/ringo-snark/buckler/internal/kat"
+)
+
+var outPtr = flag.String("o", "buckler/testdata/kat.json", "The output path of the vectors.")
+
+func main() {
+	flag.Parse()
+
+	vecs, err := kat.Generate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	b, err := json.MarshalIndent(vecs, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*outPtr, append(b, '\n'), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -90,13 +90,16 @@ func modInverse(x, m uint64) uint64 {
 	return u % m
 }
 
+// projRows is the number of rows of the random projection.
+const projRows = 128
+
 // projChecker computes the random projection on coefficients.
 type projChecker[E bignum.Uint[E]] struct {
 	proj [][]bool
 }
 
 func newProjChecker[E bignum.Uint[E]](rank int) LinearChecker[E] {
-	proj := make([][]bool, 128)
+	proj := make([][]bool, projRows)
 	for i := range proj {
 		proj[i] = make([]bool, rank)
 	}
@@ -0,0 +1,113 @@
+package buckler
+
+import (
+	"time"
+
+	"github.com/sp301415/ringo-snark/profile"
+)
+
+// Phase is a phase of the proving procedure.
+type Phase int
+
+const (
+	// PhaseWitnessCommit computes the internal witnesses and commits the witnesses.
+	PhaseWitnessCommit Phase = iota
+	// PhaseProjection computes the random projection and commits the projected witnesses.
+	PhaseProjection
+	// PhaseMaskCommit commits the masks for lincheck and sumcheck.
+	PhaseMaskCommit
+	// PhaseArithCheck computes and commits the quotient of the arithmetic constraints.
+	PhaseArithCheck
+	// PhaseLinCheck computes and commits the quotient and remainder of the linear constraints.
+	PhaseLinCheck
+	// PhaseSumCheck computes and commits the quotient and remainder of the sumcheck constraints.
+	PhaseSumCheck
+	// PhaseEvaluation evaluates the committed polynomials and generates the PCS proof.
+	PhaseEvaluation
+)
+
+// String returns the name of the phase.
+func (ph Phase) String() string {
+	switch ph {
+	case PhaseWitnessCommit:
+		return "WitnessCommit"
+	case PhaseProjection:
+		return "Projection"
+	case PhaseMaskCommit:
+		return "MaskCommit"
+	case PhaseArithCheck:
+		return "ArithCheck"
+	case PhaseLinCheck:
+		return "LinCheck"
+	case PhaseSumCheck:
+		return "SumCheck"
+	case PhaseEvaluation:
+		return "Evaluation"
+	}
+	return "Unknown"
+}
+
+// PhaseEvent is emitted to the [Observer] when a phase ends.
+type PhaseEvent struct {
+	// Phase is the phase that ended.
+	Phase Phase
+	// Start is the time when the phase started.
+	Start time.Time
+	// Elapsed is the duration of the phase.
+	Elapsed time.Duration
+}
+
+// Observer receives the events during proving.
+// The methods are called synchronously from the proving goroutine.
+type Observer interface {
+	// ObservePhase is called when a phase ends.
+	ObservePhase(e PhaseEvent)
+}
+
+// ObserverFunc is an adapter to use an ordinary function as an [Observer].
+type ObserverFunc func(e PhaseEvent)
+
+// ObservePhase calls f(e).
+func (f ObserverFunc) ObservePhase(e PhaseEvent) {
+	f(e)
+}
+
+// phaseTracker tracks the current phase and reports it to the observer and the profiler.
+type phaseTracker struct {
+	observer Observer
+	prof     *profile.Profiler
+
+	phase  Phase
+	start  time.Time
+	region *profile.Region
+}
+
+// begin starts a new phase.
+func (t *phaseTracker) begin(ph Phase) {
+	t.phase = ph
+	t.start = time.Now()
+	t.region = t.prof.Begin("buckler." + ph.String())
+}
+
+// close ends the current phase without reporting it to the observer.
+// It is used to close the profiler region when proving is aborted.
+func (t *phaseTracker) close() {
+	t.region.End()
+	t.region = nil
+}
+
+// end ends the current phase, and reports it to the observer and the profiler if exists.
+func (t *phaseTracker) end() {
+	t.region.End()
+	t.region = nil
+
+	if t.observer == nil {
+		return
+	}
+
+	t.observer.ObservePhase(PhaseEvent{
+		Phase:   t.phase,
+		Start:   t.start,
+		Elapsed: time.Since(t.start),
+	})
+}
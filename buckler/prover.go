@@ -1,17 +1,19 @@
 package buckler
 
 import (
-	"bytes"
-	"crypto/sha256"
-	"crypto/sha3"
+	"context"
 	"fmt"
+	"io"
 	"math/big"
 	"reflect"
+	"sync"
 
-	fiatshamir "github.com/consensys/gnark-crypto/fiat-shamir"
 	"github.com/sp301415/ringo-snark/jindo"
 	"github.com/sp301415/ringo-snark/math/bignum"
 	"github.com/sp301415/ringo-snark/math/bigpoly"
+	"github.com/sp301415/ringo-snark/math/csprng"
+	"github.com/sp301415/ringo-snark/profile"
+	"github.com/sp301415/ringo-snark/transcript"
 )
 
 // Prover proves the given circuit.
@@ -24,6 +26,36 @@ type Prover[E bignum.Uint[E]] struct {
 
 	polyProver *jindo.Prover[E]
 
+	// crs is the common reference string.
+	crs []byte
+	// manyPolyProvers are the PCS provers for proving multiple instances, indexed by the number of instances.
+	manyPolyProvers map[int]*jindo.Prover[E]
+	// manyMu protects manyPolyProvers, fixedComs and fixedOpens.
+	manyMu sync.Mutex
+
+	// fixedEcd and fixedEcdNTT are the precomputed encodings of the fixed public witnesses,
+	// indexed by their IDs.
+	fixedEcd    map[uint64]*bigpoly.Poly[E]
+	fixedEcdNTT map[uint64]*bigpoly.Poly[E]
+	// fixedComs and fixedOpens are the commitments and openings of the fixed public witnesses,
+	// indexed by the number of instances.
+	fixedComs  map[int][]*jindo.Commitment
+	fixedOpens map[int][]*jindo.Opening
+
+	// observer receives the phase events, if not nil.
+	observer Observer
+	// prof records the measurements, if not nil.
+	prof *profile.Profiler
+
+	// seed is the seed set by SetSeed, if not nil.
+	seed []byte
+	// rand is the source of randomness for the masks.
+	// If nil, crypto/rand is used.
+	rand io.Reader
+
+	// securityTarget is the target security level used in SecurityReport.
+	securityTarget float64
+
 	ctx *Context[E]
 }
 
@@ -38,174 +70,267 @@ type witnessData[E bignum.Uint[E]] struct {
 	wEcdNTT  []*bigpoly.Poly[E]
 }
 
-// Prove generates a proof for the given circuit and witnesses.
-func (p *Prover[E]) Prove(c Circuit[E]) (*Proof[E], error) {
-	if p.ctx.circType != reflect.TypeOf(c).Elem() {
-		return nil, fmt.Errorf("circuit type mismatch")
+// SetObserver sets the observer which receives the phase events during proving.
+// If o is nil, no events are reported.
+func (p *Prover[E]) SetObserver(o Observer) {
+	p.observer = o
+}
+
+// SetProfiler sets the profiler which records the measurements during proving,
+// including the PCS operations.
+// If prof is nil, profiling is disabled.
+func (p *Prover[E]) SetProfiler(prof *profile.Profiler) {
+	p.manyMu.Lock()
+	defer p.manyMu.Unlock()
+
+	p.prof = prof
+	p.polyProver.SetProfiler(prof)
+	for _, polyProver := range p.manyPolyProvers {
+		polyProver.SetProfiler(prof)
 	}
+}
 
-	var z E
+// SetSeed makes the prover deterministic by drawing all of its randomness from seed,
+// including the randomness of the PCS,
+// so that the same sequence of calls with the same inputs gives bit-identical proofs.
+// This is intended for test vectors and conformance testing.
+// If seed is nil, the prover draws its randomness from crypto/rand again.
+//
+// The proofs are zero-knowledge only if seed is secret and never reused for different inputs.
+//
+// All calls draw from one stream, so a proof depends on the calls made before it.
+// A seeded prover must not be shared between goroutines:
+// concurrent calls interleave their draws, and the proofs are not reproducible.
+func (p *Prover[E]) SetSeed(seed []byte) {
+	p.manyMu.Lock()
+	defer p.manyMu.Unlock()
+
+	p.seed = nil
+	p.rand = nil
+	p.ecd.rand = nil
+	if seed != nil {
+		p.seed = append([]byte(nil), seed...)
+		p.rand = csprng.NewUniformSamplerWithSeed(csprng.DeriveSeed(seed, "buckler.Prover"))
+		p.ecd.rand = csprng.NewUniformSamplerWithSeed(csprng.DeriveSeed(seed, "buckler.Encoder"))
+	}
+
+	p.polyProver.SetSeed(p.polySeed(1))
+	for n, polyProver := range p.manyPolyProvers {
+		polyProver.SetSeed(p.polySeed(n))
+	}
+}
 
-	wData := witnessData[E]{
-		pw: make([]PublicWitness[E], p.ctx.pwCnt),
-		w:  make([]Witness[E], p.ctx.wCnt),
+// polySeed returns the seed of the PCS prover for n instances,
+// or nil if the prover is not seeded.
+func (p *Prover[E]) polySeed(n int) []byte {
+	if p.seed == nil {
+		return nil
 	}
+	return csprng.DeriveSeed(p.seed, fmt.Sprintf("buckler.PCS.%d", n))
+}
 
-	wk := &walker[E]{}
-	if err := wk.prvWalk(p, reflect.ValueOf(c), wData.pw, wData.w); err != nil {
-		return nil, err
+// Prove generates a proof for the given circuit and witnesses.
+func (p *Prover[E]) Prove(c Circuit[E]) (*Proof[E], error) {
+	return p.ProveContext(context.Background(), c)
+}
+
+// ProveContext generates a proof for the given circuit and witnesses.
+// It checks ctx for cancellation between rounds and while committing,
+// and returns ctx.Err() if ctx is done before the proof is generated.
+func (p *Prover[E]) ProveContext(ctx context.Context, c Circuit[E]) (*Proof[E], error) {
+	return p.prove(ctx, p.polyProver, []Circuit[E]{c}, transcript.NewSHA256())
+}
+
+// ProveWithTranscript generates a proof for the given circuit and witnesses,
+// drawing the challenges from oracle instead of the default SHA-256 transcript.
+// The PCS continues the same transcript after the evaluation point is drawn.
+// For example, the proof can be generated interactively using [transcript.Channel],
+// or embedded in the transcript of an outer protocol.
+// The proof can be verified using [Verifier.VerifyWithTranscript] with the matching transcript.
+func (p *Prover[E]) ProveWithTranscript(ctx context.Context, c Circuit[E], oracle transcript.Transcript) (*Proof[E], error) {
+	return p.prove(ctx, p.polyProver, []Circuit[E]{c}, oracle)
+}
+
+// ProveMany generates a batched proof for multiple instances of the circuit.
+// The witnesses of all instances are committed in a single PCS batch,
+// and all constraints are checked in a single set of rounds.
+// This saves the per-proof overhead of the rounds and the PCS evaluation proof,
+// but the proof still contains the commitments and evaluations of every instance,
+// so its size is linear in the number of instances.
+// It does not aggregate the instances into a proof of sublinear size,
+// which would need the PCS to open many instances from a single commitment.
+// The proof can be verified using [Verifier.VerifyMany].
+func (p *Prover[E]) ProveMany(c []Circuit[E]) (*Proof[E], error) {
+	return p.ProveManyContext(context.Background(), c)
+}
+
+// ProveManyContext is the context-aware version of [Prover.ProveMany].
+func (p *Prover[E]) ProveManyContext(ctx context.Context, c []Circuit[E]) (*Proof[E], error) {
+	if len(c) == 0 {
+		return nil, fmt.Errorf("no circuit to prove")
 	}
 
-	for i := wk.pwCnt; i < p.ctx.pwCnt; i++ {
-		wData.pw[i] = make(PublicWitness[E], p.ctx.rank)
-		for j := range p.ctx.rank {
-			wData.pw[i][j] = wData.pw[i][j].New()
-		}
+	if len(c) == 1 {
+		return p.ProveContext(ctx, c[0])
 	}
-	for i := wk.wCnt; i < p.ctx.wCnt; i++ {
-		wData.w[i] = make(Witness[E], p.ctx.rank)
-		for j := range p.ctx.rank {
-			wData.w[i][j] = wData.w[i][j].New()
+
+	p.manyMu.Lock()
+	polyProver, ok := p.manyPolyProvers[len(c)]
+	if !ok {
+		params := jindo.NewParameters[E](p.ctx.commitRank(), p.ctx.batchMany(len(c)))
+		polyProver = jindo.NewProver[E](params, p.crs)
+		polyProver.SetProfiler(p.prof)
+		if p.seed != nil {
+			polyProver.SetSeed(p.polySeed(len(c)))
 		}
+		p.manyPolyProvers[len(c)] = polyProver
+		p.fixedComs[len(c)], p.fixedOpens[len(c)] = p.commitFixed(polyProver)
 	}
+	p.manyMu.Unlock()
 
-	mod := z.New().SetInt64(-1).BigInt(new(big.Int))
-	mod.Add(mod, big.NewInt(1))
+	return p.prove(ctx, polyProver, c, transcript.NewSHA256())
+}
 
-	bigCoeff := new(big.Int)
+// commitFixed commits the encodings of the fixed public witnesses using polyProver.
+func (p *Prover[E]) commitFixed(polyProver *jindo.Prover[E]) ([]*jindo.Commitment, []*jindo.Opening) {
+	coms := make([]*jindo.Commitment, len(p.ctx.fixedIDs))
+	opens := make([]*jindo.Opening, len(p.ctx.fixedIDs))
+	for j, id := range p.ctx.fixedIDs {
+		coms[j], opens[j] = polyProver.CommitPublic(p.fixedEcd[id].Coeffs[:p.ctx.rank])
+	}
+	return coms, opens
+}
 
-	for id, wDcmps := range p.ctx.infDcmpWitness {
-		base := decomposeBase(p.ctx.infDcmpBound[id])
-		for i := range p.ctx.rank {
-			wData.w[id][i].BigInt(bigCoeff)
-			dcmp := decomposeBig(bigCoeff, base, mod)
-			for j, wDcmp := range wDcmps {
-				wData.w[witnessToID(wDcmp)][i].SetInt64(dcmp[j])
-			}
-		}
+// prove generates a proof for the given instances using polyProver,
+// drawing the challenges from oracle.
+func (p *Prover[E]) prove(ctx context.Context, polyProver *jindo.Prover[E], c []Circuit[E], oracle transcript.Transcript) (*Proof[E], error) {
+	var z E
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	sqNm, mul := new(big.Int), new(big.Int)
-	for id, bound := range p.ctx.twoDcmpBound {
-		base := decomposeBase(bound)
+	defer p.prof.Begin("buckler.Prove").End()
 
-		pwBaseID := witnessToID(p.ctx.twoDcmpBase[id])
-		pwMaskID := witnessToID(p.ctx.twoDcmpMask[id])
-		for i := range base {
-			wData.pw[pwBaseID][i].SetBigInt(base[i])
-			wData.pw[pwMaskID][i].SetInt64(1)
-		}
+	tr := phaseTracker{observer: p.observer, prof: p.prof}
+	defer tr.close()
 
-		for i := range p.ctx.rank {
-			wData.w[id][i].BigInt(bigCoeff)
-			mul.Mul(bigCoeff, bigCoeff)
-			sqNm.Add(sqNm, mul)
+	tr.begin(PhaseWitnessCommit)
+	// The common reference string is bound first,
+	// since the PCS continues this transcript without binding it again.
+	if err := oracle.Append("projConst", p.crs); err != nil {
+		return nil, err
+	}
+
+	p.manyMu.Lock()
+	fixedComs, fixedOpens := p.fixedComs[len(c)], p.fixedOpens[len(c)]
+	p.manyMu.Unlock()
+	for j := range fixedComs {
+		if err := appendCommitment(oracle, "projConst", fixedComs[j]); err != nil {
+			return nil, err
 		}
-		sqNm.Mod(sqNm, mod)
+	}
 
-		dcmp := decomposeBig(sqNm, base, mod)
-		wDcmpID := witnessToID(p.ctx.twoDcmpWitness[id])
-		for i := range dcmp {
-			wData.w[wDcmpID][i].SetInt64(dcmp[i])
+	wData := make([]witnessData[E], len(c))
+	for k := range c {
+		if err := p.fillWitness(c[k], &wData[k]); err != nil {
+			return nil, err
 		}
 	}
 
-	chalNames := []string{
-		"projConst",
-		"arithBatchConst",
-		"linCheckBatchConst",
-		"linCheckConst",
-		"sumCheckBatchConst",
-		"evalPoint",
-	}
-	oracle := fiatshamir.NewTranscript(sha256.New(), chalNames...)
-	var oracleBuf bytes.Buffer
-
-	wData.pwEcd = make([]*bigpoly.Poly[E], p.ctx.pwCnt)
-	wData.pwEcdNTT = make([]*bigpoly.Poly[E], p.ctx.pwCnt)
-	for i := range wData.pw {
-		wData.pwEcd[i] = p.ecd.Encode(wData.pw[i])
-		wData.pwEcdNTT[i] = p.polyEval.NTT(wData.pwEcd[i])
-	}
-
-	wData.wEcd = make([]*bigpoly.Poly[E], p.ctx.wCnt)
-	wData.wEcdNTT = make([]*bigpoly.Poly[E], p.ctx.wCnt)
-	coms := make([]*jindo.Commitment, p.ctx.batch())
-	opens := make([]*jindo.Opening, p.ctx.batch())
-	comPolys := make([][]E, p.ctx.batch())
-	for i := range wData.w {
-		isFirstRound := true
-		for j := range p.ctx.wSecond {
-			if witnessToID(p.ctx.wSecond[j]) == uint64(i) {
-				isFirstRound = false
-				break
+	for k := range wData {
+		wData[k].pwEcd = make([]*bigpoly.Poly[E], p.ctx.pwCnt)
+		wData[k].pwEcdNTT = make([]*bigpoly.Poly[E], p.ctx.pwCnt)
+		for i := range wData[k].pw {
+			if ecd, ok := p.fixedEcd[uint64(i)]; ok {
+				wData[k].pwEcd[i] = ecd
+				wData[k].pwEcdNTT[i] = p.fixedEcdNTT[uint64(i)]
+				continue
 			}
+			wData[k].pwEcd[i] = p.ecd.Encode(wData[k].pw[i])
+			wData[k].pwEcdNTT[i] = p.polyEval.NTT(wData[k].pwEcd[i])
+			p.prof.Count(profile.OpNTT, 1)
 		}
+		wData[k].wEcd = make([]*bigpoly.Poly[E], p.ctx.wCnt)
+		wData[k].wEcdNTT = make([]*bigpoly.Poly[E], p.ctx.wCnt)
+	}
 
-		if !isFirstRound {
-			continue
-		}
+	batch := polyProver.Parameters().Batch()
+	coms := make([]*jindo.Commitment, batch)
+	opens := make([]*jindo.Opening, batch)
+	comPolys := make([][]E, batch)
 
-		wData.wEcd[i] = p.ecd.RandEncode(wData.w[i])
-		wData.wEcdNTT[i] = p.polyEval.NTT(wData.wEcd[i])
+	// The fixed public witnesses come last in the batch.
+	fixedIdx := batch - len(fixedComs)
+	for j, id := range p.ctx.fixedIDs {
+		comPolys[fixedIdx+j] = p.fixedEcd[id].Coeffs[:p.ctx.rank]
+		coms[fixedIdx+j], opens[fixedIdx+j] = fixedComs[j], fixedOpens[j]
+	}
 
-		comPolys[i] = wData.wEcd[i].Coeffs[:p.ctx.rank+1]
-		coms[i], opens[i] = p.polyProver.Commit(comPolys[i])
+	var err error
+	isSecondRound := p.ctx.isSecondRound()
+	for k := range wData {
+		for i := range wData[k].w {
+			if isSecondRound[i] {
+				continue
+			}
+
+			idx := k*int(p.ctx.wCnt) + i
+			wData[k].wEcd[i] = p.ecd.RandEncode(wData[k].w[i])
+			wData[k].wEcdNTT[i] = p.polyEval.NTT(wData[k].wEcd[i])
+			p.prof.Count(profile.OpNTT, 1)
+
+			comPolys[idx] = wData[k].wEcd[i].Coeffs[:p.ctx.rank+1]
+			coms[idx], opens[idx], err = polyProver.CommitContext(ctx, comPolys[idx])
+			if err != nil {
+				return nil, err
+			}
 
-		coms[i].WriteRawTo(&oracleBuf)
-		oracle.Bind("projConst", oracleBuf.Bytes())
-		oracleBuf.Reset()
+			if err := appendCommitment(oracle, "projConst", coms[idx]); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	projConstBytes, err := oracle.ComputeChallenge("projConst")
+	tr.end()
+
+	tr.begin(PhaseProjection)
+	projConstBytes, err := oracle.Challenge("projConst", challengeSize)
 	if err != nil {
 		return nil, err
 	}
 
-	xofProj := sha3.NewSHAKE128()
-	xofProj.Write(projConstBytes)
-
 	if p.ctx.projChecker != nil {
-		chk := p.ctx.projChecker.(*projChecker[E])
-		var projBuf [32]byte
-		for j := 0; j < p.ctx.rank; j++ {
-			xofProj.Read(projBuf[:])
-			for i := range 128 {
-				chk.proj[i][j] = (projBuf[i/8]>>(i%8))&1 == 0
-			}
+		p.ctx.setProjection(projConstBytes)
+		for k := range wData {
+			p.fillProjWitness(&wData[k])
 		}
+	}
 
-		for id, wProj := range p.ctx.projWitness {
-			chk.TransformTo(wData.w[witnessToID(wProj)], wData.w[id])
-		}
+	for k := range wData {
+		for _, w := range p.ctx.wSecond {
+			i := witnessToID(w)
+			idx := k*int(p.ctx.wCnt) + int(i)
+			wData[k].wEcd[i] = p.ecd.RandEncode(wData[k].w[i])
+			wData[k].wEcdNTT[i] = p.polyEval.NTT(wData[k].wEcd[i])
+			p.prof.Count(profile.OpNTT, 1)
 
-		for id, wDcmp := range p.ctx.projInfDcmpWitness {
-			base := decomposeBase(p.ctx.projInfDcmpBound[id])
-			wDcmpID := witnessToID(wDcmp)
-			for i := range 128 {
-				wData.w[id][i].BigInt(bigCoeff)
-				dcmp := decomposeBig(bigCoeff, base, mod)
-				for j := range base {
-					wData.w[wDcmpID][i*len(base)+j].SetInt64(dcmp[j])
-				}
+			comPolys[idx] = wData[k].wEcd[i].Coeffs[:p.ctx.rank+1]
+			coms[idx], opens[idx], err = polyProver.CommitContext(ctx, comPolys[idx])
+			if err != nil {
+				return nil, err
+			}
+
+			if err := appendCommitment(oracle, "arithBatchConst", coms[idx]); err != nil {
+				return nil, err
 			}
 		}
 	}
 
-	for _, w := range p.ctx.wSecond {
-		i := witnessToID(w)
-		wData.wEcd[i] = p.ecd.RandEncode(wData.w[i])
-		wData.wEcdNTT[i] = p.polyEval.NTT(wData.wEcd[i])
+	tr.end()
 
-		comPolys[i] = wData.wEcd[i].Coeffs[:p.ctx.rank+1]
-		coms[i], opens[i] = p.polyProver.Commit(comPolys[i])
-
-		coms[i].WriteRawTo(&oracleBuf)
-		oracle.Bind("arithBatchConst", oracleBuf.Bytes())
-		oracleBuf.Reset()
-	}
-
-	roundComIdx := p.ctx.wCnt
+	tr.begin(PhaseMaskCommit)
+	roundComIdx := len(c) * int(p.ctx.wCnt)
 
 	var linCheckMask *bigpoly.Poly[E]
 	var linCheckMaskSum E
@@ -213,12 +338,17 @@ func (p *Prover[E]) Prove(c Circuit[E]) (*Proof[E], error) {
 		linCheckMask, linCheckMaskSum = p.sumCheckMask(2 * p.ctx.rank)
 
 		comPolys[roundComIdx] = linCheckMask.Coeffs[:2*p.ctx.rank]
-		coms[roundComIdx], opens[roundComIdx] = p.polyProver.Commit(comPolys[roundComIdx])
+		coms[roundComIdx], opens[roundComIdx], err = polyProver.CommitContext(ctx, comPolys[roundComIdx])
+		if err != nil {
+			return nil, err
+		}
 
-		coms[roundComIdx].WriteRawTo(&oracleBuf)
-		oracle.Bind("arithBatchConst", oracleBuf.Bytes())
-		oracle.Bind("arithBatchConst", linCheckMaskSum.Marshal())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "arithBatchConst", coms[roundComIdx]); err != nil {
+			return nil, err
+		}
+		if err := oracle.Append("arithBatchConst", linCheckMaskSum.Marshal()); err != nil {
+			return nil, err
+		}
 
 		roundComIdx++
 	}
@@ -229,120 +359,172 @@ func (p *Prover[E]) Prove(c Circuit[E]) (*Proof[E], error) {
 		sumCheckMask, sumCheckMaskSum = p.sumCheckMask(p.ctx.sumCheckMaxRank)
 
 		comPolys[roundComIdx] = sumCheckMask.Coeffs[:p.ctx.sumCheckMaxRank]
-		coms[roundComIdx], opens[roundComIdx] = p.polyProver.Commit(comPolys[roundComIdx])
+		coms[roundComIdx], opens[roundComIdx], err = polyProver.CommitContext(ctx, comPolys[roundComIdx])
+		if err != nil {
+			return nil, err
+		}
 
-		coms[roundComIdx].WriteRawTo(&oracleBuf)
-		oracle.Bind("arithBatchConst", oracleBuf.Bytes())
-		oracle.Bind("arithBatchConst", sumCheckMaskSum.Marshal())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "arithBatchConst", coms[roundComIdx]); err != nil {
+			return nil, err
+		}
+		if err := oracle.Append("arithBatchConst", sumCheckMaskSum.Marshal()); err != nil {
+			return nil, err
+		}
 
 		roundComIdx++
 	}
 
-	arithBatchConstBytes, err := oracle.ComputeChallenge("arithBatchConst")
+	tr.end()
+
+	arithBatchConstBytes, err := oracle.Challenge("arithBatchConst", challengeSize)
 	if err != nil {
 		return nil, err
 	}
 
 	if p.ctx.HasArithmeticCheck() {
+		tr.begin(PhaseArithCheck)
+
 		batchConst := z.New().SetBytes(arithBatchConstBytes)
 
 		quo := p.arithCheck(batchConst, wData)
 
 		comPolys[roundComIdx] = quo
-		coms[roundComIdx], opens[roundComIdx] = p.polyProver.Commit(quo)
+		coms[roundComIdx], opens[roundComIdx], err = polyProver.CommitContext(ctx, quo)
+		if err != nil {
+			return nil, err
+		}
 
-		coms[roundComIdx].WriteRawTo(&oracleBuf)
-		oracle.Bind("evalPoint", oracleBuf.Bytes())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "evalPoint", coms[roundComIdx]); err != nil {
+			return nil, err
+		}
 
 		roundComIdx++
+		tr.end()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	linCheckBatchConstBytes, err := oracle.ComputeChallenge("linCheckBatchConst")
+	linCheckBatchConstBytes, err := oracle.Challenge("linCheckBatchConst", challengeSize)
 	if err != nil {
 		return nil, err
 	}
 
-	linCheckConstBytes, err := oracle.ComputeChallenge("linCheckConst")
+	linCheckConstBytes, err := oracle.Challenge("linCheckConst", challengeSize)
 	if err != nil {
 		return nil, err
 	}
 
 	if p.ctx.HasLinearCheck() {
+		tr.begin(PhaseLinCheck)
 		batchConst := z.New().SetBytes(linCheckBatchConstBytes)
 		linCheckConst := z.New().SetBytes(linCheckConstBytes)
 
-		quo, remLo, remHi := p.linCheck(batchConst, linCheckConst, linCheckMask, wData)
+		quo, remLo, remHi := p.linCheck(batchConst, linCheckConst, linCheckMask, polyProver.Parameters(), wData)
 
 		comPolys[roundComIdx] = quo
-		coms[roundComIdx], opens[roundComIdx] = p.polyProver.Commit(quo)
+		coms[roundComIdx], opens[roundComIdx], err = polyProver.CommitContext(ctx, quo)
+		if err != nil {
+			return nil, err
+		}
 
-		coms[roundComIdx].WriteRawTo(&oracleBuf)
-		oracle.Bind("evalPoint", oracleBuf.Bytes())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "evalPoint", coms[roundComIdx]); err != nil {
+			return nil, err
+		}
 
 		comPolys[roundComIdx+1] = remLo
-		coms[roundComIdx+1], opens[roundComIdx+1] = p.polyProver.Commit(remLo)
+		coms[roundComIdx+1], opens[roundComIdx+1], err = polyProver.CommitContext(ctx, remLo)
+		if err != nil {
+			return nil, err
+		}
 
-		coms[roundComIdx+1].WriteRawTo(&oracleBuf)
-		oracle.Bind("evalPoint", oracleBuf.Bytes())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "evalPoint", coms[roundComIdx+1]); err != nil {
+			return nil, err
+		}
 
 		comPolys[roundComIdx+2] = remHi
-		coms[roundComIdx+2], opens[roundComIdx+2] = p.polyProver.Commit(remHi)
+		coms[roundComIdx+2], opens[roundComIdx+2], err = polyProver.CommitContext(ctx, remHi)
+		if err != nil {
+			return nil, err
+		}
 
-		coms[roundComIdx+2].WriteRawTo(&oracleBuf)
-		oracle.Bind("evalPoint", oracleBuf.Bytes())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "evalPoint", coms[roundComIdx+2]); err != nil {
+			return nil, err
+		}
 
 		roundComIdx += 3
+		tr.end()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	sumCheckBatchConstBytes, err := oracle.ComputeChallenge("sumCheckBatchConst")
+	sumCheckBatchConstBytes, err := oracle.Challenge("sumCheckBatchConst", challengeSize)
 	if err != nil {
 		return nil, err
 	}
 
 	if p.ctx.HasSumCheck() {
+		tr.begin(PhaseSumCheck)
 		batchConst := z.New().SetBytes(sumCheckBatchConstBytes)
 
-		quo, remLo, remHi := p.sumCheck(batchConst, sumCheckMask, wData)
+		quo, remLo, remHi := p.sumCheck(batchConst, sumCheckMask, polyProver.Parameters(), wData)
 
 		comPolys[roundComIdx] = quo
-		coms[roundComIdx], opens[roundComIdx] = p.polyProver.Commit(quo)
+		coms[roundComIdx], opens[roundComIdx], err = polyProver.CommitContext(ctx, quo)
+		if err != nil {
+			return nil, err
+		}
 
-		coms[roundComIdx].WriteRawTo(&oracleBuf)
-		oracle.Bind("evalPoint", oracleBuf.Bytes())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "evalPoint", coms[roundComIdx]); err != nil {
+			return nil, err
+		}
 
 		comPolys[roundComIdx+1] = remLo
-		coms[roundComIdx+1], opens[roundComIdx+1] = p.polyProver.Commit(remLo)
+		coms[roundComIdx+1], opens[roundComIdx+1], err = polyProver.CommitContext(ctx, remLo)
+		if err != nil {
+			return nil, err
+		}
 
-		coms[roundComIdx+1].WriteRawTo(&oracleBuf)
-		oracle.Bind("evalPoint", oracleBuf.Bytes())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "evalPoint", coms[roundComIdx+1]); err != nil {
+			return nil, err
+		}
 
 		comPolys[roundComIdx+2] = remHi
-		coms[roundComIdx+2], opens[roundComIdx+2] = p.polyProver.Commit(remHi)
+		coms[roundComIdx+2], opens[roundComIdx+2], err = polyProver.CommitContext(ctx, remHi)
+		if err != nil {
+			return nil, err
+		}
 
-		coms[roundComIdx+2].WriteRawTo(&oracleBuf)
-		oracle.Bind("evalPoint", oracleBuf.Bytes())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "evalPoint", coms[roundComIdx+2]); err != nil {
+			return nil, err
+		}
 
 		roundComIdx += 3
+		tr.end()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	evalPointBytes, err := oracle.ComputeChallenge("evalPoint")
+	tr.begin(PhaseEvaluation)
+	evalPointBytes, err := oracle.Challenge("evalPoint", challengeSize)
 	if err != nil {
 		return nil, err
 	}
 	evalPoint := z.New().SetBytes(evalPointBytes)
 
-	evals, evalProof := p.polyProver.Evaluate(evalPoint, comPolys, coms, opens)
+	evals, evalProof, err := polyProver.ContinueEvaluate(evalPoint, comPolys, coms, opens, oracle)
+	if err != nil {
+		return nil, err
+	}
+	tr.end()
 
 	return &Proof[E]{
-		Witness: coms,
+		Witness: coms[:fixedIdx],
 
 		LinCheckMaskSum: linCheckMaskSum,
 		SumCheckMaskSum: sumCheckMaskSum,
@@ -352,28 +534,187 @@ func (p *Prover[E]) Prove(c Circuit[E]) (*Proof[E], error) {
 	}, nil
 }
 
-func (p *Prover[E]) evalCircuit(batchConst E, constraints []ArithmeticConstraint[E], wData witnessData[E]) *bigpoly.Poly[E] {
+// fillWitness reads the witnesses from the circuit,
+// and computes the internal witnesses of the first round to wData.
+func (p *Prover[E]) fillWitness(c Circuit[E], wData *witnessData[E]) error {
+	if p.ctx.circType != reflect.TypeOf(c).Elem() {
+		return fmt.Errorf("circuit type mismatch")
+	}
+
+	wData.pw = make([]PublicWitness[E], p.ctx.pwCnt)
+	wData.w = make([]Witness[E], p.ctx.wCnt)
+
+	wk := &walker[E]{}
+	if err := wk.prvWalk(p, reflect.ValueOf(c), wData.pw, wData.w); err != nil {
+		return err
+	}
+
+	p.ctx.fillInternalPublicWitness(wData.pw)
+	for i := wk.wCnt; i < p.ctx.wCnt; i++ {
+		wData.w[i] = make(Witness[E], p.ctx.rank)
+		for j := range p.ctx.rank {
+			wData.w[i][j] = wData.w[i][j].New()
+		}
+	}
+
+	mod := bignum.Modulus[E]()
+
+	bigCoeff := new(big.Int)
+
+	// The decompositions are filled in the order they are declared,
+	// so that a decomposition of the output of another one reads its final value.
+	for _, dcmp := range p.ctx.dcmpOrder {
+		var err error
+		switch dcmp.kind {
+		case dcmpInf:
+			p.fillInfDcmpWitness(wData, dcmp.id)
+		case dcmpGadget:
+			err = p.fillGadgetDcmpWitness(wData, dcmp.id)
+		case dcmpBit:
+			err = p.fillBitDcmpWitness(wData, dcmp.id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	sqNm, mul := new(big.Int), new(big.Int)
+	for id, bound := range p.ctx.twoDcmpBound {
+		base := decomposeBase(bound)
+
+		// Each two-norm constraint decomposes the squared norm of its own witness.
+		sqNm.SetUint64(0)
+		for i := range p.ctx.rank {
+			wData.w[id][i].BigInt(bigCoeff)
+			mul.Mul(bigCoeff, bigCoeff)
+			sqNm.Add(sqNm, mul)
+		}
+		sqNm.Mod(sqNm, mod)
+
+		dcmp := decomposeBig(sqNm, base, mod)
+		wDcmpID := witnessToID(p.ctx.twoDcmpWitness[id])
+		for i := range dcmp {
+			wData.w[wDcmpID][i].SetInt64(dcmp[i])
+		}
+	}
+
+	return nil
+}
+
+// fillInfDcmpWitness computes the infinity-norm decomposition of the witness with ID id to wData.
+func (p *Prover[E]) fillInfDcmpWitness(wData *witnessData[E], id uint64) {
+	mod := bignum.Modulus[E]()
+	base := p.ctx.infDcmpBase[id]
+	wDcmps := p.ctx.infDcmpWitness[id]
+
+	bigCoeff := new(big.Int)
+	for i := range p.ctx.rank {
+		wData.w[id][i].BigInt(bigCoeff)
+		dcmp := decomposeBig(bigCoeff, base, mod)
+		for j, wDcmp := range wDcmps {
+			wData.w[witnessToID(wDcmp)][i].SetInt64(dcmp[j])
+		}
+	}
+}
+
+// fillGadgetDcmpWitness computes the gadget digits of the witness with ID id to wData.
+func (p *Prover[E]) fillGadgetDcmpWitness(wData *witnessData[E], id uint64) error {
+	var z E
+
+	mod := bignum.Modulus[E]()
+	digits := p.ctx.gadgetDcmpWitness[id]
+	for _, d := range digits {
+		wData.w[witnessToID(d)] = make(Witness[E], p.ctx.rank)
+	}
+
+	bigCoeff := new(big.Int)
+	for i := range p.ctx.rank {
+		wData.w[id][i].BigInt(bigCoeff)
+		dcmp, ok := decomposeBalanced(bigCoeff, p.ctx.gadgetDcmpBase[id], len(digits), mod)
+		if !ok {
+			return fmt.Errorf("gadget decomposition: entry %v does not fit in %v digits", i, len(digits))
+		}
+		for j, d := range digits {
+			wData.w[witnessToID(d)][i] = z.New().SetBigInt(dcmp[j])
+		}
+	}
+	return nil
+}
+
+// fillBitDcmpWitness computes the bits of the witness with ID id to wData.
+func (p *Prover[E]) fillBitDcmpWitness(wData *witnessData[E], id uint64) error {
+	bits := p.ctx.bitDcmpWitness[id]
+
+	bigCoeff := new(big.Int)
+	for i := range p.ctx.rank {
+		wData.w[id][i].BigInt(bigCoeff)
+		if bigCoeff.BitLen() > len(bits) {
+			return fmt.Errorf("bit decomposition: entry %v is not in [0, 2^%v)", i, len(bits))
+		}
+		for j := range bits {
+			wData.w[witnessToID(bits[j])][i].SetUint64(uint64(bigCoeff.Bit(j)))
+		}
+	}
+	return nil
+}
+
+// fillProjWitness computes the projection witnesses to wData.
+// The projection must be set before calling this method.
+func (p *Prover[E]) fillProjWitness(wData *witnessData[E]) {
+	mod := bignum.Modulus[E]()
+
+	bigCoeff := new(big.Int)
+
+	chk := p.ctx.projChecker.(*projChecker[E])
+	for id, wProj := range p.ctx.projWitness {
+		chk.TransformTo(wData.w[witnessToID(wProj)], wData.w[id])
+	}
+
+	for id, wDcmp := range p.ctx.projInfDcmpWitness {
+		base := decomposeBase(p.ctx.projInfDcmpBound[id])
+		wDcmpID := witnessToID(wDcmp)
+		for i := range projRows {
+			wData.w[id][i].BigInt(bigCoeff)
+			dcmp := decomposeBig(bigCoeff, base, mod)
+			for j := range base {
+				wData.w[wDcmpID][i*len(base)+j].SetInt64(dcmp[j])
+			}
+		}
+	}
+}
+
+// evalCircuit evaluates the constraints for all instances,
+// batched by the powers of batchConst.
+// Each constraint of each instance gets a distinct power,
+// so the result vanishes on the domain only if every constraint does,
+// except with probability at most the number of constraints over |E|.
+func (p *Prover[E]) evalCircuit(batchConst E, constraints []ArithmeticConstraint[E], wData []witnessData[E]) *bigpoly.Poly[E] {
 	pOut := p.polyEval.NewPoly(true)
 
 	eval := p.polyEval.NewPoly(true)
 	term := p.polyEval.NewPoly(true)
-	for _, c := range constraints {
-		eval.Clear()
-		for i := range c.witness {
-			for j := range p.polyEval.Rank() {
-				term.Coeffs[j].Set(c.coeffs[i])
-			}
-			if c.hasCoeffPublicWitness[i] {
-				p.polyEval.MulTo(term, term, wData.pwEcdNTT[c.coeffsPublicWitness[i]])
-			}
-			for j := range c.witness[i] {
-				p.polyEval.MulTo(term, term, wData.wEcdNTT[c.witness[i][j]])
+	for k := range wData {
+		for _, c := range constraints {
+			eval.Clear()
+			for i := range c.witness {
+				for j := range p.polyEval.Rank() {
+					term.Coeffs[j].Set(c.coeffs[i])
+				}
+				if c.hasCoeffPublicWitness[i] {
+					p.polyEval.MulTo(term, term, wData[k].pwEcdNTT[c.coeffsPublicWitness[i]])
+					p.prof.Count(profile.OpRingMul, 1)
+				}
+				for j := range c.witness[i] {
+					p.polyEval.MulTo(term, term, wData[k].wEcdNTT[c.witness[i][j]])
+					p.prof.Count(profile.OpRingMul, 1)
+				}
+				p.polyEval.AddTo(eval, eval, term)
 			}
-			p.polyEval.AddTo(eval, eval, term)
+			p.polyEval.ScalarMulTo(pOut, pOut, batchConst)
+			p.polyEval.AddTo(pOut, pOut, eval)
 		}
-		p.polyEval.ScalarMulTo(eval, eval, batchConst)
-		p.polyEval.AddTo(pOut, pOut, eval)
 	}
+	p.polyEval.ScalarMulTo(pOut, pOut, batchConst)
 
 	return pOut
 }
@@ -383,27 +724,28 @@ func (p *Prover[E]) sumCheckMask(maskRank int) (*bigpoly.Poly[E], E) {
 
 	mask := p.polyEval.NewPoly(false)
 	for i := range p.ctx.rank {
-		mask.Coeffs[i].MustSetRandom()
+		bignum.MustSetRandomFrom(mask.Coeffs[i], p.rand)
 	}
 
 	maskSum := z.New().Set(mask.Coeffs[0])
 
 	for i := p.ctx.rank; i < maskRank; i++ {
-		mask.Coeffs[i].MustSetRandom()
+		bignum.MustSetRandomFrom(mask.Coeffs[i], p.rand)
 		mask.Coeffs[i-p.ctx.rank].Sub(mask.Coeffs[i-p.ctx.rank], mask.Coeffs[i])
 	}
 
 	return mask, maskSum
 }
 
-func (p *Prover[E]) arithCheck(batchConst E, wData witnessData[E]) (quo []E) {
+func (p *Prover[E]) arithCheck(batchConst E, wData []witnessData[E]) (quo []E) {
 	eval := p.evalCircuit(batchConst, p.ctx.arithConstraints, wData)
 	p.polyEval.InvNTTTo(eval, eval)
+	p.prof.Count(profile.OpNTT, 1)
 	quoPoly, _ := p.polyEval.QuoRemByVanishing(eval, p.ctx.rank)
 	return quoPoly.Coeffs[:p.ctx.arithCheckMaxRank-p.ctx.rank]
 }
 
-func (p *Prover[E]) linCheck(batchConst, linCheckConst E, linCheckMask *bigpoly.Poly[E], wData witnessData[E]) (quo, remLo, remHi []E) {
+func (p *Prover[E]) linCheck(batchConst, linCheckConst E, linCheckMask *bigpoly.Poly[E], params jindo.Parameters, wData []witnessData[E]) (quo, remLo, remHi []E) {
 	var z E
 
 	linCheckVec := make([]E, p.ctx.rank)
@@ -419,6 +761,7 @@ func (p *Prover[E]) linCheck(batchConst, linCheckConst E, linCheckMask *bigpoly.
 
 	linCheckEcd := p.ecd.Encode(linCheckVec)
 	p.polyEval.NTTTo(linCheckEcd, linCheckEcd)
+	p.prof.Count(profile.OpNTT, 1)
 
 	linCheckEcdTr := p.polyEval.NewPoly(false)
 
@@ -428,16 +771,21 @@ func (p *Prover[E]) linCheck(batchConst, linCheckConst E, linCheckMask *bigpoly.
 		tr.TransposeTo(linCheckVecTr, linCheckVec)
 		p.ecd.EncodeTo(linCheckEcdTr, linCheckVecTr)
 		p.polyEval.NTTTo(linCheckEcdTr, linCheckEcdTr)
-		for _, wIDs := range p.ctx.linCheckConstraints[tr] {
-			wOut, wIn := wData.wEcdNTT[wIDs[0]], wData.wEcdNTT[wIDs[1]]
-			p.polyEval.MulTo(term, linCheckEcdTr, wIn)
-			p.polyEval.MulSubTo(term, linCheckEcd, wOut)
-			p.polyEval.ScalarMulTo(eval, eval, batchConst)
-			p.polyEval.AddTo(eval, eval, term)
+		p.prof.Count(profile.OpNTT, 1)
+		for k := range wData {
+			for _, wIDs := range p.ctx.linCheckConstraints[tr] {
+				wOut, wIn := wData[k].wEcdNTT[wIDs[0]], wData[k].wEcdNTT[wIDs[1]]
+				p.polyEval.MulTo(term, linCheckEcdTr, wIn)
+				p.polyEval.MulSubTo(term, linCheckEcd, wOut)
+				p.prof.Count(profile.OpRingMul, 2)
+				p.polyEval.ScalarMulTo(eval, eval, batchConst)
+				p.polyEval.AddTo(eval, eval, term)
+			}
 		}
 	}
 	p.polyEval.ScalarMulTo(eval, eval, batchConst)
 	p.polyEval.InvNTTTo(eval, eval)
+	p.prof.Count(profile.OpNTT, 1)
 	p.polyEval.AddTo(eval, eval, linCheckMask)
 
 	quoPoly, remPoly := p.polyEval.QuoRemByVanishing(eval, p.ctx.rank)
@@ -447,24 +795,25 @@ func (p *Prover[E]) linCheck(batchConst, linCheckConst E, linCheckMask *bigpoly.
 		remLo[i] = z.New().Set(remPoly.Coeffs[i+1])
 	}
 
-	remHi = make([]E, p.JindoParams.Rank())
-	for i := 0; i < p.JindoParams.Rank()-(p.ctx.rank-1); i++ {
+	remHi = make([]E, params.Rank())
+	for i := 0; i < params.Rank()-(p.ctx.rank-1); i++ {
 		remHi[i] = z.New()
 	}
-	for i, ii := 0, p.JindoParams.Rank()-(p.ctx.rank-1); i < p.ctx.rank-1; i, ii = i+1, ii+1 {
+	for i, ii := 0, params.Rank()-(p.ctx.rank-1); i < p.ctx.rank-1; i, ii = i+1, ii+1 {
 		remHi[ii] = z.New().Set(remLo[i])
 	}
 
 	return quoPoly.Coeffs[:p.ctx.rank], remLo, remHi
 }
 
-func (p *Prover[E]) sumCheck(batchConst E, sumCheckMask *bigpoly.Poly[E], wData witnessData[E]) (quo, remLo, remHi []E) {
+func (p *Prover[E]) sumCheck(batchConst E, sumCheckMask *bigpoly.Poly[E], params jindo.Parameters, wData []witnessData[E]) (quo, remLo, remHi []E) {
 	var z E
 
 	eval := p.evalCircuit(batchConst, p.ctx.sumCheckConstraints, wData)
-	p.polyEval.ScalarMulTo(eval, eval, batchConst)
 	p.polyEval.InvNTTTo(eval, eval)
+	p.prof.Count(profile.OpNTT, 1)
 	p.polyEval.AddTo(eval, eval, sumCheckMask)
+	eval.Coeffs[0].Sub(eval.Coeffs[0], p.ctx.sumCheckOffset(batchConst, len(wData)))
 
 	quoPoly, remPoly := p.polyEval.QuoRemByVanishing(eval, p.ctx.rank)
 
@@ -473,11 +822,11 @@ func (p *Prover[E]) sumCheck(batchConst E, sumCheckMask *bigpoly.Poly[E], wData
 		remLo[i] = z.New().Set(remPoly.Coeffs[i+1])
 	}
 
-	remHi = make([]E, p.JindoParams.Rank())
-	for i := 0; i < p.JindoParams.Rank()-(p.ctx.rank-1); i++ {
+	remHi = make([]E, params.Rank())
+	for i := 0; i < params.Rank()-(p.ctx.rank-1); i++ {
 		remHi[i] = z.New()
 	}
-	for i, ii := 0, p.JindoParams.Rank()-(p.ctx.rank-1); i < p.ctx.rank-1; i, ii = i+1, ii+1 {
+	for i, ii := 0, params.Rank()-(p.ctx.rank-1); i < p.ctx.rank-1; i, ii = i+1, ii+1 {
 		remHi[ii] = z.New().Set(remLo[i])
 	}
 
@@ -0,0 +1,202 @@
+package buckler
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/sp301415/ringo-snark/jindo"
+	"github.com/sp301415/ringo-snark/jindo/security"
+	"github.com/sp301415/ringo-snark/math/bignum"
+)
+
+// DefaultSecurityTarget is the default target security level in bits.
+const DefaultSecurityTarget = 128
+
+// SecurityComponent is a single term of the security estimate.
+type SecurityComponent struct {
+	// Name is the name of the component.
+	Name string
+	// Bits is -log2 of the error of the component.
+	// It is +Inf if the component does not apply.
+	Bits float64
+}
+
+// SecurityReport is a breakdown of the security of a compiled circuit.
+type SecurityReport struct {
+	// Soundness are the components of the soundness error.
+	Soundness []SecurityComponent
+	// ZeroKnowledge are the components of the zero-knowledge error.
+	ZeroKnowledge []SecurityComponent
+
+	// SoundnessBits is the total soundness in bits.
+	SoundnessBits float64
+	// ZeroKnowledgeBits is the total zero-knowledge in bits.
+	ZeroKnowledgeBits float64
+
+	// TargetBits is the target security level in bits.
+	TargetBits float64
+	// BelowTarget is true if either SoundnessBits or ZeroKnowledgeBits is below TargetBits.
+	BelowTarget bool
+}
+
+// log2 returns log2(x).
+func log2(x *big.Int) float64 {
+	if x.Sign() <= 0 {
+		return math.Inf(-1)
+	}
+
+	shift := max(x.BitLen()-53, 0)
+	mant, _ := new(big.Float).SetInt(new(big.Int).Rsh(x, uint(shift))).Float64()
+	return math.Log2(mant) + float64(shift)
+}
+
+// totalBits returns -log2 of the sum of the errors of the components.
+func totalBits(cs []SecurityComponent) float64 {
+	err := 0.0
+	for _, c := range cs {
+		err += math.Exp2(-c.Bits)
+	}
+	return -math.Log2(err)
+}
+
+// schwartzZippel returns the component for a polynomial identity of degree deg
+// tested at a uniformly random point of E.
+func schwartzZippel[E bignum.Uint[E]](name string, deg int) SecurityComponent {
+	if deg <= 0 {
+		return SecurityComponent{Name: name, Bits: math.Inf(1)}
+	}
+	return SecurityComponent{Name: name, Bits: log2(bignum.Modulus[E]()) - math.Log2(float64(deg))}
+}
+
+// piopSoundness returns the soundness components of the PIOP.
+// The errors are bounded by the Schwartz-Zippel lemma over the challenge space E.
+func (ctx *Context[E]) piopSoundness() []SecurityComponent {
+	var cs []SecurityComponent
+
+	if ctx.HasArithmeticCheck() {
+		cs = append(cs,
+			schwartzZippel[E]("ArithBatch", len(ctx.arithConstraints)),
+			schwartzZippel[E]("ArithEval", ctx.arithCheckMaxRank),
+		)
+	}
+
+	if ctx.HasLinearCheck() {
+		linCheckCnt := 0
+		for _, c := range ctx.linCheckConstraints {
+			linCheckCnt += len(c)
+		}
+		cs = append(cs,
+			schwartzZippel[E]("LinCheckVector", ctx.rank-1),
+			schwartzZippel[E]("LinCheckBatch", linCheckCnt),
+			schwartzZippel[E]("LinCheckEval", 2*ctx.rank),
+		)
+	}
+
+	if ctx.HasSumCheck() {
+		cs = append(cs,
+			schwartzZippel[E]("SumCheckBatch", len(ctx.sumCheckConstraints)),
+			schwartzZippel[E]("SumCheckEval", ctx.sumCheckMaxRank+ctx.rank),
+		)
+	}
+
+	if len(ctx.projWitness) > 0 {
+		cs = append(cs, ctx.projSoundness())
+	}
+
+	return cs
+}
+
+// projSoundness returns the soundness component of the random projection.
+//
+// If w has an entry w_j with 2B < |w_j| <= q/2 for the slack bound B,
+// each row of the projection lands in [-B, B] with probability at most 1/2,
+// since flipping the j-th entry of the row shifts the result by w_j.
+// If 4B >= q, no such entry exists and the projection is not sound.
+func (ctx *Context[E]) projSoundness() SecurityComponent {
+	q := bignum.Modulus[E]()
+	for _, bound := range ctx.projInfDcmpBound {
+		if new(big.Int).Lsh(bound, 2).Cmp(q) >= 0 {
+			return SecurityComponent{Name: "Projection", Bits: 0}
+		}
+	}
+	return SecurityComponent{Name: "Projection", Bits: projRows}
+}
+
+// soundnessBits returns the soundness of the PIOP in bits.
+func (ctx *Context[E]) soundnessBits() float64 {
+	return totalBits(ctx.piopSoundness())
+}
+
+// securityReport returns the security report with the given PCS parameters.
+func (ctx *Context[E]) securityReport(params jindo.Parameters, target float64) SecurityReport {
+	pcs := security.EstimateParameters(params, security.CoreSVPClassical)
+
+	soundness := ctx.piopSoundness()
+	soundness = append(soundness,
+		SecurityComponent{Name: "PCSChallenge", Bits: params.LogChallengeSpace()},
+		SecurityComponent{Name: "PCSBinding", Bits: pcs.BindingBits},
+	)
+
+	zk := []SecurityComponent{
+		// The witnesses are perfectly hidden unless
+		// the evaluation point falls in the evaluation domain.
+		schwartzZippel[E]("EvalPointOutsideDomain", ctx.rank),
+		{Name: "PCSHiding", Bits: pcs.HidingBits},
+	}
+
+	r := SecurityReport{
+		Soundness:     soundness,
+		ZeroKnowledge: zk,
+
+		SoundnessBits:     totalBits(soundness),
+		ZeroKnowledgeBits: totalBits(zk),
+
+		TargetBits: target,
+	}
+	r.BelowTarget = r.SoundnessBits < target || r.ZeroKnowledgeBits < target
+
+	return r
+}
+
+// SoundnessBits returns an estimate of the soundness of the PIOP in bits,
+// i.e. -log2 of the probability that a cheating prover passes all checks.
+// The soundness of the polynomial commitment scheme is not accounted for.
+//
+// Since all challenges are sampled from E, the estimate is at most log2|E|.
+// Therefore E must be large enough for the target security.
+func (p *Prover[E]) SoundnessBits() float64 {
+	return p.ctx.soundnessBits()
+}
+
+// SoundnessBits returns an estimate of the soundness of the PIOP in bits.
+// See [Prover.SoundnessBits] for details.
+func (v *Verifier[E]) SoundnessBits() float64 {
+	return v.ctx.soundnessBits()
+}
+
+// SetSecurityTarget sets the target security level in bits used by [Prover.SecurityReport].
+// The default is [DefaultSecurityTarget].
+func (p *Prover[E]) SetSecurityTarget(bits float64) {
+	p.securityTarget = bits
+}
+
+// SetSecurityTarget sets the target security level in bits used by [Verifier.SecurityReport].
+// The default is [DefaultSecurityTarget].
+func (v *Verifier[E]) SetSecurityTarget(bits float64) {
+	v.securityTarget = bits
+}
+
+// SecurityReport returns a breakdown of the soundness and zero-knowledge
+// of the compiled circuit per component, including the polynomial commitment scheme.
+// The bits of the PCS are estimated by [security.EstimateParameters]
+// in the classical core-SVP model.
+func (p *Prover[E]) SecurityReport() SecurityReport {
+	return p.ctx.securityReport(p.JindoParams, p.securityTarget)
+}
+
+// SecurityReport returns a breakdown of the soundness and zero-knowledge
+// of the compiled circuit per component.
+// See [Prover.SecurityReport] for details.
+func (v *Verifier[E]) SecurityReport() SecurityReport {
+	return v.ctx.securityReport(v.JindoParams, v.securityTarget)
+}
@@ -0,0 +1,137 @@
+package buckler
+
+import (
+	"github.com/sp301415/ringo-snark/math/bignum"
+	"github.com/sp301415/ringo-snark/math/csprng"
+)
+
+// Simulator simulates the PIOP transcripts of a circuit from the public witnesses only.
+// It programs the oracle: the challenges are sampled first,
+// and the evaluations are chosen to pass the checks of the verifier.
+//
+// The evaluations of the witnesses and masks in an honest proof are uniformly random,
+// as they are masked by [Encoder.RandEncode] and the sumcheck masks.
+// Hence the simulated transcripts are distributed identically to the honest ones,
+// which makes the PIOP zero-knowledge.
+// The PCS part of the proof is not simulated.
+type Simulator[E bignum.Uint[E]] struct {
+	vrf *Verifier[E]
+
+	uniformSampler *csprng.UniformSampler
+}
+
+// NewSimulator creates a new [Simulator] for the circuit of vrf.
+func NewSimulator[E bignum.Uint[E]](vrf *Verifier[E]) *Simulator[E] {
+	return &Simulator[E]{
+		vrf: vrf,
+
+		uniformSampler: csprng.NewUniformSampler(),
+	}
+}
+
+// SetSeed makes the simulator deterministic by drawing all of its randomness from seed.
+// If seed is nil, the simulator draws its randomness from crypto/rand again.
+func (s *Simulator[E]) SetSeed(seed []byte) {
+	if seed == nil {
+		s.uniformSampler = csprng.NewUniformSampler()
+		return
+	}
+	s.uniformSampler = csprng.NewUniformSamplerWithSeed(csprng.DeriveSeed(seed, "buckler.Simulator"))
+}
+
+// random returns a uniformly random element.
+func (s *Simulator[E]) random() E {
+	var z E
+	return bignum.MustSetRandomFrom(z.New(), s.uniformSampler)
+}
+
+// Simulate returns a simulated PIOP transcript for the given public assignment,
+// which is accepted by [Verifier.VerifyPIOPTranscript].
+func (s *Simulator[E]) Simulate(c Circuit[E]) (*PIOPTranscript[E], error) {
+	var z E
+	v := s.vrf
+
+	tr := &PIOPTranscript[E]{
+		Challenges: PIOPChallenges[E]{
+			Projection: make([]byte, 32),
+
+			ArithBatch:    s.random(),
+			LinCheckBatch: s.random(),
+			LinCheck:      s.random(),
+			SumCheckBatch: s.random(),
+			EvalPoint:     s.random(),
+		},
+		Evals: make([]E, v.ctx.batch()),
+	}
+	s.uniformSampler.Read(tr.Challenges.Projection)
+
+	for i := range tr.Evals {
+		tr.Evals[i] = z.New()
+	}
+
+	roundComIdx := int(v.ctx.wCnt)
+	for i := range roundComIdx {
+		tr.Evals[i] = s.random()
+	}
+	if v.ctx.HasLinearCheck() {
+		tr.Evals[roundComIdx] = s.random()
+		tr.LinCheckMaskSum = s.random()
+		roundComIdx++
+	}
+	if v.ctx.HasSumCheck() {
+		tr.Evals[roundComIdx] = s.random()
+		tr.SumCheckMaskSum = s.random()
+		roundComIdx++
+	}
+
+	// The fixed public witnesses are public, so their evaluations are not simulated.
+	fixedIdx := len(tr.Evals) - len(v.ctx.fixedIDs)
+	for j, id := range v.ctx.fixedIDs {
+		tr.Evals[fixedIdx+j] = v.fixedEcd[id].Evaluate(tr.Challenges.EvalPoint)
+	}
+
+	if v.ctx.projChecker != nil {
+		v.ctx.setProjection(tr.Challenges.Projection)
+	}
+
+	ev, err := v.readEvals([]Circuit[E]{c}, tr)
+	if err != nil {
+		return nil, err
+	}
+
+	evalPoint := tr.Challenges.EvalPoint
+	vanishInv := z.New().Inverse(ev.vanish)
+	params := v.polyVerifier.Parameters()
+
+	if v.ctx.HasArithmeticCheck() {
+		eval := v.evalCircuit(tr.Challenges.ArithBatch, v.ctx.arithConstraints, ev.w, ev.pw)
+		tr.Evals[roundComIdx].Mul(eval, vanishInv)
+		roundComIdx++
+	}
+
+	// solveRound sets the evaluations of the quotient and remainders at roundComIdx,
+	// so that they match eval.
+	solveRound := func(eval, maskSum E) {
+		remLoEval := s.random()
+		tr.Evals[roundComIdx+1] = remLoEval
+		tr.Evals[roundComIdx+2] = remShiftEval(params, v.ctx.rank, evalPoint, remLoEval)
+
+		quoEval := z.New().Sub(eval, roundEval(evalPoint, ev.vanish, maskSum, z.New(), remLoEval))
+		tr.Evals[roundComIdx].Mul(quoEval, vanishInv)
+		roundComIdx += 3
+	}
+
+	if v.ctx.HasLinearCheck() {
+		eval := v.linCheckEval(tr.Challenges.LinCheckBatch, tr.Challenges.LinCheck, ev.linCheckMask, evalPoint, ev.w)
+		solveRound(eval, tr.LinCheckMaskSum)
+	}
+
+	if v.ctx.HasSumCheck() {
+		eval := v.evalCircuit(tr.Challenges.SumCheckBatch, v.ctx.sumCheckConstraints, ev.w, ev.pw)
+		eval.Add(eval, ev.sumCheckMask)
+		eval.Sub(eval, v.ctx.sumCheckOffset(tr.Challenges.SumCheckBatch, len(ev.w)))
+		solveRound(eval, tr.SumCheckMaskSum)
+	}
+
+	return tr, nil
+}
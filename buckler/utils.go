@@ -1,9 +1,19 @@
 package buckler
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"math/big"
+	"reflect"
+
+	"github.com/sp301415/ringo-snark/jindo"
+	"github.com/sp301415/ringo-snark/transcript"
 )
 
+// challengeSize is the size of the challenges in bytes.
+const challengeSize = 32
+
 func decomposeBase(x *big.Int) []*big.Int {
 	one := big.NewInt(1)
 
@@ -31,6 +41,21 @@ func decomposeBase(x *big.Int) []*big.Int {
 	return base
 }
 
+// infNormBase returns the base of the ternary decomposition of the values bounded by bound,
+// whose sum is exactly bound.
+// Unlike [decomposeBase], this includes the bound itself when it is a power of two.
+func infNormBase(bound *big.Int) []*big.Int {
+	base := decomposeBase(bound)
+	sum := new(big.Int)
+	for i := range base {
+		sum.Add(sum, base[i])
+	}
+	if sum.Cmp(bound) < 0 {
+		base = append(base, new(big.Int).Sub(bound, sum))
+	}
+	return base
+}
+
 func decomposeBig(x *big.Int, base []*big.Int, q *big.Int) []int64 {
 	xSigned := new(big.Int).Set(x)
 	qHalf := new(big.Int).Rsh(q, 1)
@@ -54,3 +79,69 @@ func decomposeBig(x *big.Int, base []*big.Int, q *big.Int) []int64 {
 	}
 	return dcmpOut
 }
+
+// decomposeBalanced decomposes x into n balanced digits in base,
+// where x is regarded as the centered representative modulo q.
+// Each digit is in [-base/2, base/2].
+// It returns false if x does not fit in n digits.
+func decomposeBalanced(x *big.Int, base *big.Int, n int, q *big.Int) ([]*big.Int, bool) {
+	xSigned := new(big.Int).Set(x)
+	qHalf := new(big.Int).Rsh(q, 1)
+	if xSigned.Cmp(qHalf) > 0 {
+		xSigned.Sub(xSigned, q)
+	}
+
+	// A digit d in [0, base) is balanced if 2d < base.
+	dcmpDouble := new(big.Int)
+	dcmpOut := make([]*big.Int, n)
+	for i := range dcmpOut {
+		dcmpOut[i] = new(big.Int).Mod(xSigned, base)
+		if dcmpDouble.Lsh(dcmpOut[i], 1).Cmp(base) >= 0 {
+			dcmpOut[i].Sub(dcmpOut[i], base)
+		}
+		xSigned.Sub(xSigned, dcmpOut[i])
+		xSigned.Quo(xSigned, base)
+	}
+	return dcmpOut, xSigned.Sign() == 0
+}
+
+// appendBytes appends p to b, prefixed by its length.
+func appendBytes(b, p []byte) []byte {
+	b = binary.BigEndian.AppendUint64(b, uint64(len(p)))
+	return append(b, p...)
+}
+
+// readUint64 reads a uint64 written by binary.BigEndian.AppendUint64 from r.
+func readUint64(r *bytes.Reader) (uint64, error) {
+	var n uint64
+	err := binary.Read(r, binary.BigEndian, &n)
+	return n, err
+}
+
+// readBytes reads a byte slice written by appendBytes from r.
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := readUint64(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, errors.New("buckler: invalid length")
+	}
+
+	p := make([]byte, n)
+	r.Read(p)
+	return p, nil
+}
+
+// isNil returns true if x is a nil pointer.
+func isNil(x any) bool {
+	v := reflect.ValueOf(x)
+	return !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil())
+}
+
+// appendCommitment binds com to the challenge named label in oracle.
+func appendCommitment(oracle transcript.Transcript, label string, com *jindo.Commitment) error {
+	var buf bytes.Buffer
+	com.WriteRawTo(&buf)
+	return oracle.Append(label, buf.Bytes())
+}
@@ -1,15 +1,16 @@
 package buckler
 
 import (
-	"bytes"
-	"crypto/sha256"
-	"crypto/sha3"
+	"fmt"
 	"reflect"
+	"slices"
+	"sync"
 
-	fiatshamir "github.com/consensys/gnark-crypto/fiat-shamir"
 	"github.com/sp301415/ringo-snark/jindo"
 	"github.com/sp301415/ringo-snark/math/bignum"
 	"github.com/sp301415/ringo-snark/math/bigpoly"
+	"github.com/sp301415/ringo-snark/profile"
+	"github.com/sp301415/ringo-snark/transcript"
 )
 
 // Verifier verifies the given circuit.
@@ -22,192 +23,461 @@ type Verifier[E bignum.Uint[E]] struct {
 
 	polyVerifier *jindo.Verifier[E]
 
+	// crs is the common reference string.
+	crs []byte
+	// manyPolyVerifiers are the PCS verifiers for verifying multiple instances, indexed by the number of instances.
+	manyPolyVerifiers map[int]*jindo.Verifier[E]
+	// manyMu protects manyPolyVerifiers and fixedComs.
+	manyMu sync.Mutex
+
+	// fixedEcd are the precomputed encodings of the fixed public witnesses, indexed by their IDs.
+	fixedEcd map[uint64]*bigpoly.Poly[E]
+	// fixedComs are the commitments of the fixed public witnesses,
+	// indexed by the number of instances.
+	fixedComs map[int][]*jindo.Commitment
+
+	// prof records the measurements, if not nil.
+	prof *profile.Profiler
+
+	// securityTarget is the target security level used in SecurityReport.
+	securityTarget float64
+
 	ctx *Context[E]
 }
 
+// SetProfiler sets the profiler which records the measurements during verification,
+// including the PCS operations.
+// If prof is nil, profiling is disabled.
+func (v *Verifier[E]) SetProfiler(prof *profile.Profiler) {
+	v.manyMu.Lock()
+	defer v.manyMu.Unlock()
+
+	v.prof = prof
+	v.polyVerifier.SetProfiler(prof)
+	for _, polyVerifier := range v.manyPolyVerifiers {
+		polyVerifier.SetProfiler(prof)
+	}
+}
+
 // Verify verifies the proof for the given public assignment.
 func (v *Verifier[E]) Verify(c Circuit[E], pf *Proof[E]) bool {
-	if v.ctx.circType != reflect.TypeOf(c).Elem() {
+	return v.VerifyWithTranscript(c, pf, transcript.NewSHA256())
+}
+
+// VerifyWithTranscript verifies the proof generated by [Prover.ProveWithTranscript]
+// for the given public assignment, drawing all challenges from oracle.
+// For an interactive proof, oracle is the transcript returned by [transcript.Challenger.Replay].
+func (v *Verifier[E]) VerifyWithTranscript(c Circuit[E], pf *Proof[E], oracle transcript.Transcript) bool {
+	defer v.prof.Begin("buckler.Verify").End()
+
+	evalPoint, ok := v.verifyPIOP(v.polyVerifier, []Circuit[E]{c}, pf, oracle)
+	if !ok {
 		return false
 	}
 
-	var z E
+	return v.polyVerifier.ContinueVerify(evalPoint, v.commitments(1, pf), pf.Evals, pf.EvalProof, oracle)
+}
 
-	pw := make([]PublicWitness[E], v.ctx.pwCnt)
-	wk := &walker[E]{}
-	if err := wk.vrfWalk(v, reflect.ValueOf(c), pw); err != nil {
+// VerifyMany verifies the proof generated by [Prover.ProveMany]
+// for the given public assignments.
+// Like the proof size, the verification cost is linear in len(c).
+func (v *Verifier[E]) VerifyMany(c []Circuit[E], pf *Proof[E]) bool {
+	if len(c) == 0 {
 		return false
 	}
 
-	for i := wk.pwCnt; i < v.ctx.pwCnt; i++ {
-		pw[i] = make(PublicWitness[E], v.ctx.rank)
-		for j := range v.ctx.rank {
-			pw[i][j] = pw[i][j].New()
-		}
+	if len(c) == 1 {
+		return v.Verify(c[0], pf)
+	}
+
+	v.manyMu.Lock()
+	polyVerifier, ok := v.manyPolyVerifiers[len(c)]
+	if !ok {
+		params := jindo.NewParameters[E](v.ctx.commitRank(), v.ctx.batchMany(len(c)))
+		polyVerifier = jindo.NewVerifier[E](params, v.crs)
+		polyVerifier.SetProfiler(v.prof)
+		v.manyPolyVerifiers[len(c)] = polyVerifier
+		v.fixedComs[len(c)] = v.commitFixed(polyVerifier)
 	}
+	v.manyMu.Unlock()
 
-	for id, bound := range v.ctx.twoDcmpBound {
-		base := decomposeBase(bound)
+	oracle := transcript.NewSHA256()
+	evalPoint, ok := v.verifyPIOP(polyVerifier, c, pf, oracle)
+	if !ok {
+		return false
+	}
 
-		pwBaseID := witnessToID(v.ctx.twoDcmpBase[id])
-		pwMaskID := witnessToID(v.ctx.twoDcmpMask[id])
-		for i := range base {
-			pw[pwBaseID][i].SetBigInt(base[i])
-			pw[pwMaskID][i].SetInt64(1)
+	return polyVerifier.ContinueVerify(evalPoint, v.commitments(len(c), pf), pf.Evals, pf.EvalProof, oracle)
+}
+
+// BatchVerify verifies the proofs for the given public assignments.
+// The PCS checks of the proofs passing the PIOP checks are done by [jindo.Verifier.ContinueBatchVerify],
+// which combines the checks of the proofs with random linear combinations where the norm analysis allows.
+// It returns true only if all proofs are valid, and the verification result of each proof,
+// which is found by the PCS verifier from the combined checks without verifying each proof again.
+func (v *Verifier[E]) BatchVerify(c []Circuit[E], pf []*Proof[E]) (bool, []bool) {
+	if len(c) != len(pf) {
+		return false, make([]bool, len(pf))
+	}
+
+	isValid := make([]bool, len(pf))
+	idx := make([]int, 0, len(pf))
+	evalPoints := make([]E, 0, len(pf))
+	coms := make([][]*jindo.Commitment, 0, len(pf))
+	evals := make([][]E, 0, len(pf))
+	evalPfs := make([]*jindo.Proof, 0, len(pf))
+	oracles := make([]transcript.Transcript, 0, len(pf))
+	for i := range pf {
+		oracle := transcript.NewSHA256()
+		evalPoint, ok := v.verifyPIOP(v.polyVerifier, []Circuit[E]{c[i]}, pf[i], oracle)
+		if !ok {
+			continue
 		}
+
+		idx = append(idx, i)
+		evalPoints = append(evalPoints, evalPoint)
+		coms = append(coms, v.commitments(1, pf[i]))
+		evals = append(evals, pf[i].Evals)
+		evalPfs = append(evalPfs, pf[i].EvalProof)
+		oracles = append(oracles, oracle)
 	}
 
-	chalNames := []string{
-		"projConst",
-		"arithBatchConst",
-		"linCheckBatchConst",
-		"linCheckConst",
-		"sumCheckBatchConst",
-		"evalPoint",
+	_, isValidPCS := v.polyVerifier.ContinueBatchVerify(evalPoints, coms, evals, evalPfs, oracles)
+	for k, i := range idx {
+		isValid[i] = isValidPCS[k]
 	}
-	oracle := fiatshamir.NewTranscript(sha256.New(), chalNames...)
-	var oracleBuf bytes.Buffer
 
-	pwEcd := make([]*bigpoly.Poly[E], v.ctx.pwCnt)
-	for i := range pw {
-		pwEcd[i] = v.ecd.Encode(pw[i])
+	return !slices.Contains(isValid, false), isValid
+}
+
+// readPublicWitness reads the public witnesses from the circuit,
+// including the internal public witnesses.
+func (v *Verifier[E]) readPublicWitness(c Circuit[E]) ([]PublicWitness[E], error) {
+	if v.ctx.circType != reflect.TypeOf(c).Elem() {
+		return nil, fmt.Errorf("circuit type mismatch")
 	}
 
-	for i := range v.ctx.wCnt {
-		isFirstRound := true
-		for j := range v.ctx.wSecond {
-			if witnessToID(v.ctx.wSecond[j]) == uint64(i) {
-				isFirstRound = false
-				break
-			}
-		}
+	pw := make([]PublicWitness[E], v.ctx.pwCnt)
+	wk := &walker[E]{}
+	if err := wk.vrfWalk(v, reflect.ValueOf(c), pw); err != nil {
+		return nil, err
+	}
 
-		if !isFirstRound {
-			continue
-		}
+	v.ctx.fillInternalPublicWitness(pw)
+
+	return pw, nil
+}
+
+// commitFixed commits the encodings of the fixed public witnesses using polyVerifier.
+func (v *Verifier[E]) commitFixed(polyVerifier *jindo.Verifier[E]) []*jindo.Commitment {
+	coms := make([]*jindo.Commitment, len(v.ctx.fixedIDs))
+	for j, id := range v.ctx.fixedIDs {
+		coms[j] = polyVerifier.CommitPublic(v.fixedEcd[id].Coeffs[:v.ctx.rank])
+	}
+	return coms
+}
+
+// fixedCommitments returns the commitments of the fixed public witnesses for n instances.
+func (v *Verifier[E]) fixedCommitments(n int) []*jindo.Commitment {
+	v.manyMu.Lock()
+	defer v.manyMu.Unlock()
+	return v.fixedComs[n]
+}
+
+// commitments returns all commitments evaluated by the PCS for the proof of n instances,
+// which are the commitments in pf followed by the commitments of the fixed public witnesses.
+func (v *Verifier[E]) commitments(n int, pf *Proof[E]) []*jindo.Commitment {
+	return slices.Concat(pf.Witness, v.fixedCommitments(n))
+}
 
-		pf.Witness[i].WriteRawTo(&oracleBuf)
-		oracle.Bind("projConst", oracleBuf.Bytes())
-		oracleBuf.Reset()
+// verifyPIOP verifies the PIOP part of the proof for the given instances,
+// drawing the challenges from oracle,
+// and returns the evaluation point for the PCS check.
+func (v *Verifier[E]) verifyPIOP(polyVerifier *jindo.Verifier[E], c []Circuit[E], pf *Proof[E], oracle transcript.Transcript) (E, bool) {
+	var z E
+
+	if !v.isValidProof(polyVerifier.Parameters().Batch(), pf) {
+		return z, false
 	}
 
-	projConstBytes, err := oracle.ComputeChallenge("projConst")
+	chal, err := v.readChallenges(oracle, len(c), pf)
 	if err != nil {
+		return z, false
+	}
+
+	tr := &PIOPTranscript[E]{
+		Challenges: chal,
+
+		LinCheckMaskSum: pf.LinCheckMaskSum,
+		SumCheckMaskSum: pf.SumCheckMaskSum,
+
+		Evals: pf.Evals,
+	}
+	if !v.checkPIOP(polyVerifier.Parameters(), c, tr) {
+		return z, false
+	}
+
+	return chal.EvalPoint, true
+}
+
+// isValidProof returns true if pf has the shape of a proof with PCS batch size batch.
+// The shapes of the commitments and the PCS proof are checked by the PCS verifier.
+func (v *Verifier[E]) isValidProof(batch int, pf *Proof[E]) bool {
+	switch {
+	case pf == nil || pf.EvalProof == nil:
+		return false
+	case len(pf.Witness) != batch-len(v.ctx.fixedIDs) || len(pf.Evals) != batch:
+		return false
+	case v.ctx.HasLinearCheck() && isNil(pf.LinCheckMaskSum):
+		return false
+	case v.ctx.HasSumCheck() && isNil(pf.SumCheckMaskSum):
+		return false
+	case slices.Contains(pf.Witness, nil) || slices.ContainsFunc(pf.Evals, func(e E) bool { return isNil(e) }):
 		return false
 	}
+	return true
+}
 
-	xofProj := sha3.NewSHAKE128()
-	xofProj.Write(projConstBytes)
+// readChallenges reads the challenges of the PIOP for n instances from oracle.
+func (v *Verifier[E]) readChallenges(oracle transcript.Transcript, n int, pf *Proof[E]) (PIOPChallenges[E], error) {
+	var z E
+	var chal PIOPChallenges[E]
 
-	if v.ctx.projChecker != nil {
-		chk := v.ctx.projChecker.(*projChecker[E])
-		var projBuf [32]byte
-		for j := 0; j < v.ctx.rank; j++ {
-			xofProj.Read(projBuf[:])
-			for i := range 128 {
-				chk.proj[i][j] = (projBuf[i/8]>>(i%8))&1 == 0
+	if err := oracle.Append("projConst", v.crs); err != nil {
+		return chal, err
+	}
+	for _, com := range v.fixedCommitments(n) {
+		if err := appendCommitment(oracle, "projConst", com); err != nil {
+			return chal, err
+		}
+	}
+
+	isSecondRound := v.ctx.isSecondRound()
+	for k := range n {
+		for i := range int(v.ctx.wCnt) {
+			if isSecondRound[i] {
+				continue
+			}
+
+			if err := appendCommitment(oracle, "projConst", pf.Witness[k*int(v.ctx.wCnt)+i]); err != nil {
+				return chal, err
 			}
 		}
 	}
 
-	for _, w := range v.ctx.wSecond {
-		i := witnessToID(w)
-		pf.Witness[i].WriteRawTo(&oracleBuf)
-		oracle.Bind("arithBatchConst", oracleBuf.Bytes())
-		oracleBuf.Reset()
+	projConstBytes, err := oracle.Challenge("projConst", challengeSize)
+	if err != nil {
+		return chal, err
+	}
+	chal.Projection = projConstBytes
+
+	for k := range n {
+		for _, w := range v.ctx.wSecond {
+			i := witnessToID(w)
+			if err := appendCommitment(oracle, "arithBatchConst", pf.Witness[k*int(v.ctx.wCnt)+int(i)]); err != nil {
+				return chal, err
+			}
+		}
 	}
 
-	roundComIdx := v.ctx.wCnt
+	roundComIdx := n * int(v.ctx.wCnt)
 
-	var linCheckMaskEval E
 	if v.ctx.HasLinearCheck() {
-		linCheckMaskEval = pf.Evals[roundComIdx]
-		pf.Witness[roundComIdx].WriteRawTo(&oracleBuf)
-		oracle.Bind("arithBatchConst", oracleBuf.Bytes())
-		oracle.Bind("arithBatchConst", pf.LinCheckMaskSum.Marshal())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "arithBatchConst", pf.Witness[roundComIdx]); err != nil {
+			return chal, err
+		}
+		if err := oracle.Append("arithBatchConst", pf.LinCheckMaskSum.Marshal()); err != nil {
+			return chal, err
+		}
 		roundComIdx++
 	}
 
-	var sumCheckMaskEval E
 	if v.ctx.HasSumCheck() {
-		sumCheckMaskEval = pf.Evals[roundComIdx]
-		pf.Witness[roundComIdx].WriteRawTo(&oracleBuf)
-		oracle.Bind("arithBatchConst", oracleBuf.Bytes())
-		oracle.Bind("arithBatchConst", pf.SumCheckMaskSum.Marshal())
-		oracleBuf.Reset()
+		if err := appendCommitment(oracle, "arithBatchConst", pf.Witness[roundComIdx]); err != nil {
+			return chal, err
+		}
+		if err := oracle.Append("arithBatchConst", pf.SumCheckMaskSum.Marshal()); err != nil {
+			return chal, err
+		}
 		roundComIdx++
 	}
 
-	arithBatchConstBytes, err := oracle.ComputeChallenge("arithBatchConst")
-	if err != nil {
-		return false
+	// The commitments of each round are bound to the evaluation point
+	// right after the challenge of the round, in the same order as the prover.
+	var arithCnt, linCheckCnt, sumCheckCnt int
+	if v.ctx.HasArithmeticCheck() {
+		arithCnt = 1
 	}
-
-	linCheckBatchConstBytes, err := oracle.ComputeChallenge("linCheckBatchConst")
-	if err != nil {
-		return false
+	if v.ctx.HasLinearCheck() {
+		linCheckCnt = 3
+	}
+	if v.ctx.HasSumCheck() {
+		sumCheckCnt = 3
 	}
 
-	linCheckConstBytes, err := oracle.ComputeChallenge("linCheckConst")
-	if err != nil {
-		return false
+	for _, ch := range []struct {
+		name string
+		out  *E
+		cnt  int
+	}{
+		{"arithBatchConst", &chal.ArithBatch, arithCnt},
+		{"linCheckBatchConst", &chal.LinCheckBatch, 0},
+		{"linCheckConst", &chal.LinCheck, linCheckCnt},
+		{"sumCheckBatchConst", &chal.SumCheckBatch, sumCheckCnt},
+	} {
+		chalBytes, err := oracle.Challenge(ch.name, challengeSize)
+		if err != nil {
+			return chal, err
+		}
+		*ch.out = z.New().SetBytes(chalBytes)
+
+		for i := roundComIdx; i < roundComIdx+ch.cnt; i++ {
+			if err := appendCommitment(oracle, "evalPoint", pf.Witness[i]); err != nil {
+				return chal, err
+			}
+		}
+		roundComIdx += ch.cnt
 	}
 
-	sumCheckBatchConstBytes, err := oracle.ComputeChallenge("sumCheckBatchConst")
+	evalPointBytes, err := oracle.Challenge("evalPoint", challengeSize)
 	if err != nil {
-		return false
+		return chal, err
 	}
+	chal.EvalPoint = z.New().SetBytes(evalPointBytes)
+
+	return chal, nil
+}
 
-	for i := int(roundComIdx); i < len(pf.Witness); i++ {
-		pf.Witness[i].WriteRawTo(&oracleBuf)
-		oracle.Bind("evalPoint", oracleBuf.Bytes())
-		oracleBuf.Reset()
+// PIOPTranscript returns the PIOP transcript of pf,
+// with the challenges derived from the oracle.
+func (v *Verifier[E]) PIOPTranscript(pf *Proof[E]) (*PIOPTranscript[E], error) {
+	if !v.isValidProof(v.ctx.batch(), pf) {
+		return nil, fmt.Errorf("proof size mismatch")
 	}
 
-	evalPointBytes, err := oracle.ComputeChallenge("evalPoint")
+	chal, err := v.readChallenges(transcript.NewSHA256(), 1, pf)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	evalPoint := z.New().SetBytes(evalPointBytes)
 
-	if !v.polyVerifier.Verify(evalPoint, pf.Witness, pf.Evals, pf.EvalProof) {
+	return &PIOPTranscript[E]{
+		Challenges: chal,
+
+		LinCheckMaskSum: pf.LinCheckMaskSum,
+		SumCheckMaskSum: pf.SumCheckMaskSum,
+
+		Evals: pf.Evals,
+	}, nil
+}
+
+// VerifyPIOPTranscript verifies the PIOP transcript for the given public assignment,
+// against the challenges in tr instead of the ones derived from the oracle.
+// This does not check the PCS part of the proof.
+func (v *Verifier[E]) VerifyPIOPTranscript(c Circuit[E], tr *PIOPTranscript[E]) bool {
+	if len(tr.Evals) != v.ctx.batch() {
 		return false
 	}
+	return v.checkPIOP(v.polyVerifier.Parameters(), []Circuit[E]{c}, tr)
+}
+
+// piopEvals holds the evaluations of the polynomials checked by the PIOP.
+type piopEvals[E bignum.Uint[E]] struct {
+	pw [][]E
+	w  [][]E
+
+	linCheckMask E
+	sumCheckMask E
+
+	vanish E
+}
 
-	vanishEval := bignum.Exp(evalPoint, uint64(v.ctx.rank))
-	vanishEval.Sub(vanishEval, z.New().SetUint64(1))
+// readEvals reads the evaluations of the polynomials at evalPoint from tr,
+// and computes the evaluations of the public witnesses of c.
+// The projection must be set before calling this method.
+func (v *Verifier[E]) readEvals(c []Circuit[E], tr *PIOPTranscript[E]) (piopEvals[E], error) {
+	var z E
+	evalPoint := tr.Challenges.EvalPoint
+
+	// The public witnesses are evaluated directly from their values,
+	// using the Lagrange basis at evalPoint shared by all of them.
+	// The basis is computed only if there is a public witness which is not fixed.
+	var basis []E
+
+	var ev piopEvals[E]
+	ev.pw = make([][]E, len(c))
+	ev.w = make([][]E, len(c))
+	for k := range c {
+		pw, err := v.readPublicWitness(c[k])
+		if err != nil {
+			return ev, err
+		}
+
+		ev.pw[k] = make([]E, v.ctx.pwCnt)
+		for i := range pw {
+			if _, ok := v.ctx.fixedWitness[uint64(i)]; ok {
+				continue
+			}
+			if basis == nil {
+				basis = v.ecd.ntt.LagrangeBasisAt(evalPoint)
+			}
+			ev.pw[k][i] = bigpoly.InnerProduct(pw[i], basis)
+		}
+		// The evaluations of the fixed public witnesses are opened by the PCS,
+		// which come last in the batch.
+		fixedIdx := len(tr.Evals) - len(v.ctx.fixedIDs)
+		for j, id := range v.ctx.fixedIDs {
+			ev.pw[k][id] = tr.Evals[fixedIdx+j]
+		}
+		ev.w[k] = tr.Evals[k*int(v.ctx.wCnt) : (k+1)*int(v.ctx.wCnt)]
+	}
 
-	pwEvals := make([]E, v.ctx.pwCnt)
-	for i := range pwEvals {
-		pwEvals[i] = pwEcd[i].Evaluate(evalPoint)
+	roundComIdx := len(c) * int(v.ctx.wCnt)
+	if v.ctx.HasLinearCheck() {
+		ev.linCheckMask = tr.Evals[roundComIdx]
+		roundComIdx++
+	}
+	if v.ctx.HasSumCheck() {
+		ev.sumCheckMask = tr.Evals[roundComIdx]
 	}
 
-	if v.ctx.HasArithmeticCheck() {
-		batchConst := z.New().SetBytes(arithBatchConstBytes)
+	ev.vanish = bignum.Exp(evalPoint, uint64(v.ctx.rank))
+	ev.vanish.Sub(ev.vanish, z.New().SetUint64(1))
+
+	return ev, nil
+}
+
+// checkPIOP checks the PIOP transcript for the given instances.
+func (v *Verifier[E]) checkPIOP(params jindo.Parameters, c []Circuit[E], tr *PIOPTranscript[E]) bool {
+	if v.ctx.projChecker != nil {
+		v.ctx.setProjection(tr.Challenges.Projection)
+	}
 
-		if !v.arithCheck(batchConst, vanishEval, pf.Evals[roundComIdx], pf.Evals, pwEvals) {
+	ev, err := v.readEvals(c, tr)
+	if err != nil {
+		return false
+	}
+
+	evalPoint := tr.Challenges.EvalPoint
+	roundComIdx := v.piopRoundIdx(len(c))
+
+	if v.ctx.HasArithmeticCheck() {
+		if !v.arithCheck(tr.Challenges.ArithBatch, ev.vanish, tr.Evals[roundComIdx], ev.w, ev.pw) {
 			return false
 		}
 		roundComIdx++
 	}
 
 	if v.ctx.HasLinearCheck() {
-		batchConst := z.New().SetBytes(linCheckBatchConstBytes)
-		linCheckConst := z.New().SetBytes(linCheckConstBytes)
-
-		quoEval, remLoEval, remHiEval := pf.Evals[roundComIdx], pf.Evals[roundComIdx+1], pf.Evals[roundComIdx+2]
-		if !v.linCheck(batchConst, linCheckConst, linCheckMaskEval, evalPoint, vanishEval, pf.LinCheckMaskSum, quoEval, remLoEval, remHiEval, pf.Evals) {
+		quoEval, remLoEval, remHiEval := tr.Evals[roundComIdx], tr.Evals[roundComIdx+1], tr.Evals[roundComIdx+2]
+		if !v.linCheck(tr.Challenges.LinCheckBatch, tr.Challenges.LinCheck, ev.linCheckMask, evalPoint, ev.vanish, tr.LinCheckMaskSum, quoEval, remLoEval, remHiEval, params, ev.w) {
 			return false
 		}
 		roundComIdx += 3
 	}
 
 	if v.ctx.HasSumCheck() {
-		batchConst := z.New().SetBytes(sumCheckBatchConstBytes)
-
-		quoEval, remLoEval, remHiEval := pf.Evals[roundComIdx], pf.Evals[roundComIdx+1], pf.Evals[roundComIdx+2]
-		if !v.sumCheck(batchConst, sumCheckMaskEval, evalPoint, vanishEval, pf.SumCheckMaskSum, quoEval, remLoEval, remHiEval, pf.Evals, pwEvals) {
+		quoEval, remLoEval, remHiEval := tr.Evals[roundComIdx], tr.Evals[roundComIdx+1], tr.Evals[roundComIdx+2]
+		if !v.sumCheck(tr.Challenges.SumCheckBatch, ev.sumCheckMask, evalPoint, ev.vanish, tr.SumCheckMaskSum, quoEval, remLoEval, remHiEval, params, ev.w, ev.pw) {
 			return false
 		}
 		roundComIdx += 3
@@ -216,100 +486,127 @@ func (v *Verifier[E]) Verify(c Circuit[E], pf *Proof[E]) bool {
 	return true
 }
 
-func (v *Verifier[E]) evalCircuit(batchConst E, constraints []ArithmeticConstraint[E], evals []E, pwEvals []E) E {
+// piopRoundIdx returns the index of the first round polynomial for n instances,
+// which comes after the witnesses and the masks.
+func (v *Verifier[E]) piopRoundIdx(n int) int {
+	idx := n * int(v.ctx.wCnt)
+	if v.ctx.HasLinearCheck() {
+		idx++
+	}
+	if v.ctx.HasSumCheck() {
+		idx++
+	}
+	return idx
+}
+
+// evalCircuit evaluates the constraints for all instances,
+// batched by the powers of batchConst, in the same order as Prover.evalCircuit.
+func (v *Verifier[E]) evalCircuit(batchConst E, constraints []ArithmeticConstraint[E], evals [][]E, pwEvals [][]E) E {
 	var z E
 
 	out, eval, term := z.New(), z.New(), z.New()
-	for _, c := range constraints {
-		eval.SetUint64(0)
-		for i := range c.witness {
-			term.Set(c.coeffs[i])
-			if c.hasCoeffPublicWitness[i] {
-				term.Mul(term, pwEvals[c.coeffsPublicWitness[i]])
-			}
-			for j := range c.witness[i] {
-				term.Mul(term, evals[c.witness[i][j]])
+	for k := range evals {
+		for _, c := range constraints {
+			eval.SetUint64(0)
+			for i := range c.witness {
+				term.Set(c.coeffs[i])
+				if c.hasCoeffPublicWitness[i] {
+					term.Mul(term, pwEvals[k][c.coeffsPublicWitness[i]])
+				}
+				for j := range c.witness[i] {
+					term.Mul(term, evals[k][c.witness[i][j]])
+				}
+				eval.Add(eval, term)
 			}
-			eval.Add(eval, term)
+			out.Mul(out, batchConst)
+			out.Add(out, eval)
 		}
-		eval.Mul(eval, batchConst)
-		out.Add(out, eval)
 	}
+	out.Mul(out, batchConst)
 
 	return out
 }
 
-func (v *Verifier[E]) arithCheck(batchConst, vanishEval, quoEval E, evals []E, pwEvals []E) bool {
+func (v *Verifier[E]) arithCheck(batchConst, vanishEval, quoEval E, evals [][]E, pwEvals [][]E) bool {
 	var z E
 	eval := v.evalCircuit(batchConst, v.ctx.arithConstraints, evals, pwEvals)
 	test := z.New().Mul(quoEval, vanishEval)
 	return eval.Cmp(test) == 0
 }
 
-func (v *Verifier[E]) linCheck(batchConst, linCheckConst, linCheckMaskEval, evalPoint, vanishEval, linCheckMaskSum, quoEval, remLoEval, remHiEval E, evals []E) bool {
-	var z E
-
-	remLoShiftEval := bignum.Exp(evalPoint, uint64(v.JindoParams.Rank()-(v.ctx.rank-1)))
-	remLoShiftEval.Mul(remLoShiftEval, remLoEval)
-	if remHiEval.Cmp(remLoShiftEval) != 0 {
+func (v *Verifier[E]) linCheck(batchConst, linCheckConst, linCheckMaskEval, evalPoint, vanishEval, linCheckMaskSum, quoEval, remLoEval, remHiEval E, params jindo.Parameters, evals [][]E) bool {
+	if remHiEval.Cmp(remShiftEval(params, v.ctx.rank, evalPoint, remLoEval)) != 0 {
 		return false
 	}
 
+	eval := v.linCheckEval(batchConst, linCheckConst, linCheckMaskEval, evalPoint, evals)
+	return eval.Cmp(roundEval(evalPoint, vanishEval, linCheckMaskSum, quoEval, remLoEval)) == 0
+}
+
+// linCheckEval evaluates the batched linear constraints plus the mask at evalPoint.
+func (v *Verifier[E]) linCheckEval(batchConst, linCheckConst, linCheckMaskEval, evalPoint E, evals [][]E) E {
+	var z E
+
 	linCheckVec := make([]E, v.ctx.rank)
 	linCheckVec[0] = z.New().SetUint64(1)
 	for i := 1; i < v.ctx.rank; i++ {
 		linCheckVec[i] = z.New().Mul(linCheckVec[i-1], linCheckConst)
 	}
-	linCheckEval := v.ecd.Encode(linCheckVec).Evaluate(evalPoint)
+	basis := v.ecd.ntt.LagrangeBasisAt(evalPoint)
+	linCheckEval := bigpoly.InnerProduct(linCheckVec, basis)
 
 	linCheckVecTr := make([]E, v.ctx.rank)
 	for i := range linCheckVec {
 		linCheckVecTr[i] = z.New()
 	}
-	linCheckEcdTr := v.polyEval.NewPoly(false)
 
 	eval, term, termMul := z.New(), z.New(), z.New()
 	for _, tr := range v.ctx.linCheckers {
 		tr.TransposeTo(linCheckVecTr, linCheckVec)
-		v.ecd.EncodeTo(linCheckEcdTr, linCheckVecTr)
-		linCheckTrEval := linCheckEcdTr.Evaluate(evalPoint)
-		for _, wIDs := range v.ctx.linCheckConstraints[tr] {
-			wOut, wIn := evals[wIDs[0]], evals[wIDs[1]]
-			term.Mul(linCheckTrEval, wIn)
-			termMul.Mul(linCheckEval, wOut)
-			term.Sub(term, termMul)
-			eval.Mul(eval, batchConst)
-			eval.Add(eval, term)
+		linCheckTrEval := bigpoly.InnerProduct(linCheckVecTr, basis)
+		for k := range evals {
+			for _, wIDs := range v.ctx.linCheckConstraints[tr] {
+				wOut, wIn := evals[k][wIDs[0]], evals[k][wIDs[1]]
+				term.Mul(linCheckTrEval, wIn)
+				termMul.Mul(linCheckEval, wOut)
+				term.Sub(term, termMul)
+				eval.Mul(eval, batchConst)
+				eval.Add(eval, term)
+			}
 		}
 	}
 	eval.Mul(eval, batchConst)
 	eval.Add(eval, linCheckMaskEval)
 
-	test := z.New().Mul(quoEval, vanishEval)
-	rem := z.New().Mul(remLoEval, evalPoint)
-	test.Add(test, rem)
-	test.Add(test, linCheckMaskSum)
-
-	return eval.Cmp(test) == 0
+	return eval
 }
 
-func (v *Verifier[E]) sumCheck(batchConst, sumCheckMaskEval, evalPoint, vanishEval, sumCheckMaskSum, quoEval, remLoEval, remHiEval E, evals []E, pwEvals []E) bool {
-	var z E
-
-	remLoShiftEval := bignum.Exp(evalPoint, uint64(v.JindoParams.Rank()-(v.ctx.rank-1)))
-	remLoShiftEval.Mul(remLoShiftEval, remLoEval)
-	if remHiEval.Cmp(remLoShiftEval) != 0 {
+func (v *Verifier[E]) sumCheck(batchConst, sumCheckMaskEval, evalPoint, vanishEval, sumCheckMaskSum, quoEval, remLoEval, remHiEval E, params jindo.Parameters, evals [][]E, pwEvals [][]E) bool {
+	if remHiEval.Cmp(remShiftEval(params, v.ctx.rank, evalPoint, remLoEval)) != 0 {
 		return false
 	}
 
 	eval := v.evalCircuit(batchConst, v.ctx.sumCheckConstraints, evals, pwEvals)
-	eval.Mul(eval, batchConst)
 	eval.Add(eval, sumCheckMaskEval)
+	eval.Sub(eval, v.ctx.sumCheckOffset(batchConst, len(evals)))
+
+	return eval.Cmp(roundEval(evalPoint, vanishEval, sumCheckMaskSum, quoEval, remLoEval)) == 0
+}
 
+// remShiftEval returns the evaluation of the shifted remainder,
+// which is X^(params.Rank() - (rank - 1)) * remLo.
+func remShiftEval[E bignum.Uint[E]](params jindo.Parameters, rank int, evalPoint, remLoEval E) E {
+	shiftEval := bignum.Exp(evalPoint, uint64(params.Rank()-(rank-1)))
+	return shiftEval.Mul(shiftEval, remLoEval)
+}
+
+// roundEval returns quo * Z + X * remLo + maskSum evaluated at evalPoint,
+// which is compared with the evaluation of the checked polynomial.
+func roundEval[E bignum.Uint[E]](evalPoint, vanishEval, maskSum, quoEval, remLoEval E) E {
+	var z E
 	test := z.New().Mul(quoEval, vanishEval)
 	rem := z.New().Mul(remLoEval, evalPoint)
 	test.Add(test, rem)
-	test.Add(test, sumCheckMaskSum)
-
-	return eval.Cmp(test) == 0
+	test.Add(test, maskSum)
+	return test
 }
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/sp301415/ringo-snark/jindo"
+	"github.com/sp301415/ringo-snark/jindo/security"
+)
+
+var (
+	basePtr    = flag.Uint64("b", 0, "The \"base\" of modulus in base 10.")
+	expPtr     = flag.Int("k", 0, "The \"exponent\" of the modulus.")
+	rankPtr    = flag.String("n", "1024", "The number of coefficients to commit. Can be a single number or range (of the form \"<start>-<end>\"), which is iterated by doubling.")
+	batchPtr   = flag.String("t", "1", "The number of polynomials to commit. Can be a single number or range (of the form \"<start>-<end>\"), which is iterated by doubling.")
+	pointsPtr  = flag.Int("p", 1, "The number of points at which a commitment can be opened.")
+	secPtr     = flag.Int("s", 128, "The target security level in bits. One of 128, 192 or 256.")
+	tailCutPtr = flag.Float64("c", 5, "The tail cut bound for Gaussian distribution.")
+	formatPtr  = flag.String("f", "table", "The output format. One of table, json or csv.")
+)
+
+// row is a single row of the output.
+type row struct {
+	Rank   int `json:"rank"`
+	Batch  int `json:"batch"`
+	Points int `json:"points"`
+
+	Rows  int `json:"rows"`
+	Cols  int `json:"cols"`
+	Slots int `json:"slots"`
+
+	InMSISRank  int `json:"inMSISRank"`
+	OutMSISRank int `json:"outMSISRank"`
+	MLWERank    int `json:"mlweRank"`
+
+	LogInCutOff uint64 `json:"logInCutOff"`
+	OutCutOff   uint64 `json:"outCutOff"`
+
+	CommitmentSizeKB float64 `json:"commitmentSizeKB"`
+	ProofSizeKB      float64 `json:"proofSizeKB"`
+
+	BindingBits float64 `json:"bindingBits"`
+	HidingBits  float64 `json:"hidingBits"`
+}
+
+// header is the header of the output.
+var header = []string{
+	"Rank", "Batch", "Points",
+	"Rows", "Cols", "Slots",
+	"InMSISRank", "OutMSISRank", "MLWERank",
+	"LogInCutOff", "OutCutOff",
+	"CommitmentSize(KB)", "ProofSize(KB)",
+	"Binding(bits)", "Hiding(bits)",
+}
+
+// record returns the row as a list of strings.
+func (r row) record() []string {
+	return []string{
+		strconv.Itoa(r.Rank), strconv.Itoa(r.Batch), strconv.Itoa(r.Points),
+		strconv.Itoa(r.Rows), strconv.Itoa(r.Cols), strconv.Itoa(r.Slots),
+		strconv.Itoa(r.InMSISRank), strconv.Itoa(r.OutMSISRank), strconv.Itoa(r.MLWERank),
+		strconv.FormatUint(r.LogInCutOff, 10), strconv.FormatUint(r.OutCutOff, 10),
+		strconv.FormatFloat(r.CommitmentSizeKB, 'f', 2, 64), strconv.FormatFloat(r.ProofSizeKB, 'f', 2, 64),
+		strconv.FormatFloat(r.BindingBits, 'f', 1, 64), strconv.FormatFloat(r.HidingBits, 'f', 1, 64),
+	}
+}
+
+// parseRange parses a single number or a range of the form "<start>-<end>".
+func parseRange(s string) (start, end int, err error) {
+	if ok, _ := regexp.MatchString(`^\d+-\d+$`, s); ok {
+		fmt.Sscanf(s, "%d-%d", &start, &end)
+	} else if ok, _ := regexp.MatchString(`^\d+$`, s); ok {
+		fmt.Sscanf(s, "%d", &start)
+		end = start
+	} else {
+		return 0, 0, fmt.Errorf("cannot parse %q", s)
+	}
+
+	if start < 1 || start > end {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+
+	return start, end, nil
+}
+
+func main() {
+	flag.Parse()
+
+	b := *basePtr
+	k := *expPtr
+	if b == 0 || k == 0 {
+		fmt.Println("either -b or -k is not set")
+		os.Exit(1)
+	}
+
+	p := new(big.Int).Exp(new(big.Int).SetUint64(b), big.NewInt(int64(k)), nil)
+	p.Add(p, big.NewInt(1))
+	if !p.ProbablyPrime(64) {
+		fmt.Println("modulus not prime")
+		os.Exit(1)
+	}
+
+	rankStart, rankEnd, err := parseRange(*rankPtr)
+	if err != nil {
+		fmt.Println("cannot parse rank:", err)
+		os.Exit(1)
+	}
+
+	batchStart, batchEnd, err := parseRange(*batchPtr)
+	if err != nil {
+		fmt.Println("cannot parse batch:", err)
+		os.Exit(1)
+	}
+
+	var rows []row
+	for n := rankStart; n <= rankEnd; n *= 2 {
+		for t := batchStart; t <= batchEnd; t *= 2 {
+			params, err := jindo.NewParametersFromModulus(b, k, jindo.ParametersLiteral{
+				TargetN:       n,
+				Batch:         t,
+				Points:        *pointsPtr,
+				SecurityLevel: *secPtr,
+				TailCut:       *tailCutPtr,
+			})
+			if err != nil {
+				fmt.Printf("cannot generate parameters for rank %v, batch %v: %v\n", n, t, err)
+				os.Exit(1)
+			}
+
+			est := security.EstimateParameters(params, security.CoreSVPClassical)
+
+			rows = append(rows, row{
+				Rank:   params.Rank(),
+				Batch:  params.Batch(),
+				Points: params.Points(),
+
+				Rows:  params.Rows(),
+				Cols:  params.Cols(),
+				Slots: params.Slots(),
+
+				InMSISRank:  params.InMSISRank(),
+				OutMSISRank: params.OutMSISRank(),
+				MLWERank:    params.MLWERank(),
+
+				LogInCutOff: params.LogInCutOff(),
+				OutCutOff:   params.OutCutOff(),
+
+				CommitmentSizeKB: params.CommitmentSize() / 8 / 1024,
+				ProofSizeKB:      params.ProofSize() / 8 / 1024,
+
+				BindingBits: est.BindingBits,
+				HidingBits:  est.HidingBits,
+			})
+		}
+	}
+
+	switch *formatPtr {
+	case "table":
+		fmt.Printf("[*] Using %v-bit modulus: %v = %v^%v + 1\n", p.BitLen(), p, b, k)
+		fmt.Printf("[*] Security is estimated in core-SVP (classical)\n")
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		for _, h := range header {
+			fmt.Fprintf(w, "%v\t", h)
+		}
+		fmt.Fprintln(w)
+		for _, r := range rows {
+			for _, c := range r.record() {
+				fmt.Fprintf(w, "%v\t", c)
+			}
+			fmt.Fprintln(w)
+		}
+		w.Flush()
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			panic(err)
+		}
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write(header)
+		for _, r := range rows {
+			w.Write(r.record())
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			panic(err)
+		}
+	default:
+		fmt.Println("unknown format:", *formatPtr)
+		os.Exit(1)
+	}
+}
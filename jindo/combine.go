@@ -0,0 +1,131 @@
+package jindo
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/sp301415/ringo-snark/math/bignum"
+	"github.com/tuneinsight/lattigo/v6/ring"
+)
+
+// checkCombination checks that coeffs are valid small challenges for n elements.
+func checkCombination(params Parameters, coeffs [][]int64, n int) {
+	switch {
+	case len(coeffs) != n:
+		panic("len(coeffs) does not match the number of elements")
+	case n == 0:
+		panic("nothing to combine")
+	}
+
+	bound := int64(params.ChallengeBound() / 2)
+	for i := range coeffs {
+		switch {
+		case len(coeffs[i]) > params.ecd.exp:
+			panic(fmt.Sprintf("len(coeffs[%v]) > params.Exp()", i))
+		}
+		for _, c := range coeffs[i] {
+			if c < -bound || c > bound {
+				panic(fmt.Sprintf("coeffs[%v] exceeds params.ChallengeBound() / 2", i))
+			}
+		}
+	}
+}
+
+// CombinationScalar returns the scalar in E corresponding to the small challenge c,
+// which is sum_j c[j] base^j mod p.
+// If coms are combined by [CombineCommitments] with coefficients c_i,
+// the result is a commitment of sum_i CombinationScalar(c_i) * v_i.
+func CombinationScalar[E bignum.Uint[E]](params Parameters, c []int64) E {
+	base := new(big.Int).SetUint64(params.ecd.base)
+	pow := big.NewInt(1)
+	sum := new(big.Int)
+	for _, cj := range c {
+		sum.Add(sum, new(big.Int).Mul(big.NewInt(cj), pow))
+		pow.Mul(pow, base)
+	}
+
+	var z E
+	return z.New().SetBigInt(sum)
+}
+
+// CombinationNormGrowth returns the factor by which the norm grows
+// when combining with coefficients coeffs, which is sum_i ||c_i||_1.
+//
+// For a small challenge c and any a in Z[X]/(X^N + 1), ||c * a||_2 <= ||c||_1 * ||a||_2.
+// Therefore each component of the combined [Opening],
+// and the rounding error of the combined [Commitment],
+// has norm at most CombinationNormGrowth(coeffs) times the largest norm among the inputs.
+func CombinationNormGrowth(coeffs [][]int64) float64 {
+	var growth float64
+	for i := range coeffs {
+		for _, c := range coeffs[i] {
+			growth += float64(max(c, -c))
+		}
+	}
+	return growth
+}
+
+// combinationChallenges returns the small challenges of coeffs in ringQ.
+func combinationChallenges(params Parameters, ringQ *ring.Ring, coeffs [][]int64) []ring.Poly {
+	chals := make([]ring.Poly, len(coeffs))
+	for i := range coeffs {
+		chals[i] = ringQ.NewPoly()
+		smallChallengeTo(params, ringQ, chals[i], coeffs[i])
+	}
+	return chals
+}
+
+// CombineCommitments returns the commitment sum_i c_i * coms[i],
+// where c_i is the small challenge sum_j coeffs[i][j] X^(j*slots).
+// Each coeffs[i] must have at most params.Exp() entries,
+// whose absolute values are at most params.ChallengeBound() / 2.
+//
+// The result is a commitment of sum_i CombinationScalar(coeffs[i]) * v_i,
+// whose opening is given by [CombineOpenings] with the same coefficients.
+// See [CombinationNormGrowth] for the norm of the result;
+// an evaluation proof of the result is accepted only while the grown norms fit in the bounds of params.
+func CombineCommitments(params Parameters, coeffs [][]int64, coms []*Commitment) *Commitment {
+	checkCombination(params, coeffs, len(coms))
+
+	chals := combinationChallenges(params, params.ringQOut, coeffs)
+
+	comOut := NewCommitment(params)
+	for i := range coms {
+		for j := range comOut.Value {
+			params.ringQOut.MulCoeffsMontgomeryThenAdd(coms[i].Value[j], chals[i], comOut.Value[j])
+		}
+	}
+	return comOut
+}
+
+// CombineOpenings returns the opening of [CombineCommitments] with the same coefficients.
+func CombineOpenings(params Parameters, coeffs [][]int64, opens []*Opening) *Opening {
+	checkCombination(params, coeffs, len(opens))
+
+	openOut := NewOpening(params)
+	combineOpeningsTo(params, openOut,
+		combinationChallenges(params, params.ringQ, coeffs),
+		combinationChallenges(params, params.ringQOut, coeffs),
+		opens)
+	return openOut
+}
+
+// combineOpeningsTo adds sum_i chals[i] * opens[i] to openOut,
+// where chals and chalsOut are the same challenges in ringQ and ringQOut.
+func combineOpeningsTo(params Parameters, openOut *Opening, chals, chalsOut []ring.Poly, opens []*Opening) {
+	for i := range opens {
+		for j := range opens[i].InCommit {
+			params.ringQOut.MulCoeffsMontgomeryThenAdd(opens[i].InCommit[j], chalsOut[i], openOut.InCommit[j])
+		}
+		for j := range opens[i].Encode {
+			for k := range opens[i].Encode[j] {
+				params.ringQ.MulCoeffsMontgomeryThenAdd(opens[i].Encode[j][k], chals[i], openOut.Encode[j][k])
+			}
+		}
+		for j := range opens[i].MLWE {
+			for k := range opens[i].MLWE[j] {
+				params.ringQ.MulCoeffsMontgomeryThenAdd(opens[i].MLWE[j][k], chals[i], openOut.MLWE[j][k])
+			}
+		}
+	}
+}
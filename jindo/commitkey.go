@@ -0,0 +1,344 @@
+package jindo
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"weak"
+
+	"github.com/sp301415/ringo-snark/math/csprng"
+	"github.com/tuneinsight/lattigo/v6/ring"
+)
+
+// commitKeyVersion is the version of the derivation of a [CommitKey] from the CRS.
+//
+// Version 1 expanded all entries from a single stream seeded by the CRS.
+// Version 2 expands each entry from its own seed, CRS || label || i || j,
+// so the entries, and hence all commitments, differ from version 1 for the same CRS.
+const commitKeyVersion = 2
+
+// commitKeyMagic is the header of a serialized [CommitKey], ending with its version.
+var commitKeyMagic = [8]byte{'j', 'i', 'n', 'd', 'o', 'c', 'k', commitKeyVersion}
+
+// Labels of the matrices of a [CommitKey], used for domain separation.
+const (
+	labelIn byte = iota
+	labelMLWE
+	labelOut
+)
+
+// CommitKey is the key for commitment.
+// Must be initialized with CRS.
+//
+// Each entry of the key is expanded from its own seed derived from the CRS,
+// so that it can be computed independently of the others.
+// The entries are in NTT and Montgomery form.
+//
+// A CommitKey is read-only after creation, so it can be shared by any number of
+// [Prover] and [Verifier] instances, including across goroutines.
+type CommitKey struct {
+	crs    []byte
+	digest [sha256.Size]byte
+
+	ringQ    *ring.Ring
+	ringQOut *ring.Ring
+	// dims are the dimensions of In, MLWE and Out, indexed by their labels.
+	dims [3][2]int
+
+	// In, MLWE and Out are nil if the key is lazy.
+	In   [][]ring.Poly
+	MLWE [][]ring.Poly
+	Out  [][]ring.Poly
+}
+
+// commitKeyCache caches commit keys by their digest.
+// Entries are held weakly, so a key is freed once no prover or verifier uses it.
+var commitKeyCache = struct {
+	sync.Mutex
+	keys map[[sha256.Size]byte]weak.Pointer[CommitKey]
+}{keys: make(map[[sha256.Size]byte]weak.Pointer[CommitKey])}
+
+// commitKeyDigest returns the digest of (params, crs),
+// which uniquely determines a [CommitKey].
+func commitKeyDigest(params Parameters, crs []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(commitKeyMagic[:])
+
+	var buf [8]byte
+	writeUint := func(x uint64) {
+		binary.BigEndian.PutUint64(buf[:], x)
+		h.Write(buf[:])
+	}
+
+	writeUint(uint64(params.ringQ.N()))
+	writeUint(uint64(params.inMSISRank))
+	writeUint(uint64(params.outMSISRank))
+	writeUint(uint64(params.mlweRank))
+	writeUint(uint64(params.rows))
+	writeUint(uint64(params.inComDcmpLen))
+	for _, r := range []*ring.Ring{params.ringQ, params.ringQOut} {
+		writeUint(uint64(r.ModuliChainLength()))
+		for _, q := range r.ModuliChain() {
+			writeUint(q)
+		}
+	}
+
+	writeUint(uint64(len(crs)))
+	h.Write(crs)
+
+	var digest [sha256.Size]byte
+	h.Sum(digest[:0])
+	return digest
+}
+
+// newCommitKey creates a new lazy [CommitKey], without expanding the entries.
+func newCommitKey(params Parameters, crs []byte) *CommitKey {
+	crsCopy := make([]byte, len(crs))
+	copy(crsCopy, crs)
+
+	return &CommitKey{
+		crs:    crsCopy,
+		digest: commitKeyDigest(params, crs),
+
+		ringQ:    params.ringQ,
+		ringQOut: params.ringQOut,
+		dims: [3][2]int{
+			labelIn:   {params.inMSISRank, params.rows},
+			labelMLWE: {params.inMSISRank, params.mlweRank},
+			labelOut:  {params.outMSISRank, params.inComDcmpLen},
+		},
+	}
+}
+
+// NewCommitKey creates a new [CommitKey], expanding all entries.
+func NewCommitKey(params Parameters, crs []byte) *CommitKey {
+	ck := newCommitKey(params, crs)
+
+	ck.In = ck.expandMatrix(ck.ringQ, labelIn)
+	ck.MLWE = ck.expandMatrix(ck.ringQ, labelMLWE)
+	ck.Out = ck.expandMatrix(ck.ringQOut, labelOut)
+
+	return ck
+}
+
+// NewLazyCommitKey creates a new [CommitKey] which does not store the entries.
+// Instead, each entry is expanded from the CRS whenever it is used.
+// This trades the memory of the key for the time of expanding it on every use,
+// which is useful for memory-constrained verifiers.
+func NewLazyCommitKey(params Parameters, crs []byte) *CommitKey {
+	return newCommitKey(params, crs)
+}
+
+// GetCommitKey returns a [CommitKey] for (params, crs).
+// If a key for (params, crs) is already in use, it is returned instead of creating a new one.
+// [NewProver] and [NewVerifier] use this function,
+// so the prover and verifier built from the same CRS share the key.
+func GetCommitKey(params Parameters, crs []byte) *CommitKey {
+	digest := commitKeyDigest(params, crs)
+
+	commitKeyCache.Lock()
+	defer commitKeyCache.Unlock()
+
+	if wp, ok := commitKeyCache.keys[digest]; ok {
+		if ck := wp.Value(); ck != nil {
+			return ck
+		}
+	}
+
+	ck := NewCommitKey(params, crs)
+	commitKeyCache.keys[digest] = weak.Make(ck)
+	return ck
+}
+
+// expandMatrix expands the matrix with the given label.
+func (ck *CommitKey) expandMatrix(r *ring.Ring, label byte) [][]ring.Poly {
+	m := make([][]ring.Poly, ck.dims[label][0])
+	for i := range m {
+		m[i] = make([]ring.Poly, ck.dims[label][1])
+		for j := range m[i] {
+			m[i][j] = r.NewPoly()
+			ck.expandTo(m[i][j], r, label, i, j)
+		}
+	}
+	return m
+}
+
+// expandTo expands the (i, j)-th entry of the matrix with the given label to pOut.
+func (ck *CommitKey) expandTo(pOut ring.Poly, r *ring.Ring, label byte, i, j int) {
+	seed := make([]byte, len(ck.crs)+9)
+	copy(seed, ck.crs)
+	seed[len(ck.crs)] = label
+	binary.BigEndian.PutUint32(seed[len(ck.crs)+1:], uint32(i))
+	binary.BigEndian.PutUint32(seed[len(ck.crs)+5:], uint32(j))
+
+	u := csprng.NewUniformSamplerWithSeed(seed)
+	for k := range r.N() {
+		for l := 0; l < r.ModuliChainLength(); l++ {
+			pOut.Coeffs[l][k] = u.SampleN(r.SubRings[l].Modulus)
+		}
+	}
+}
+
+// IsLazy returns true if the entries of ck are expanded on every use.
+func (ck *CommitKey) IsLazy() bool {
+	return ck.In == nil
+}
+
+// in returns the (i, j)-th entry of In.
+// If ck is lazy, the entry is expanded to buf and buf is returned.
+func (ck *CommitKey) in(i, j int, buf ring.Poly) ring.Poly {
+	if ck.IsLazy() {
+		ck.expandTo(buf, ck.ringQ, labelIn, i, j)
+		return buf
+	}
+	return ck.In[i][j]
+}
+
+// mlwe returns the (i, j)-th entry of MLWE.
+// If ck is lazy, the entry is expanded to buf and buf is returned.
+func (ck *CommitKey) mlwe(i, j int, buf ring.Poly) ring.Poly {
+	if ck.IsLazy() {
+		ck.expandTo(buf, ck.ringQ, labelMLWE, i, j)
+		return buf
+	}
+	return ck.MLWE[i][j]
+}
+
+// out returns the (i, j)-th entry of Out.
+// If ck is lazy, the entry is expanded to buf and buf is returned.
+func (ck *CommitKey) out(i, j int, buf ring.Poly) ring.Poly {
+	if ck.IsLazy() {
+		ck.expandTo(buf, ck.ringQOut, labelOut, i, j)
+		return buf
+	}
+	return ck.Out[i][j]
+}
+
+// Digest returns the digest of the parameters and CRS of ck.
+// Two keys with the same digest are equal.
+func (ck *CommitKey) Digest() [sha256.Size]byte {
+	return ck.digest
+}
+
+// matches returns true if ck is a key for params.
+func (ck *CommitKey) matches(params Parameters) bool {
+	return commitKeyDigest(params, ck.crs) == ck.digest
+}
+
+func (ck *CommitKey) WriteRawTo(w io.Writer) {
+	w.Write(ck.crs)
+}
+
+// WriteTo writes ck to w, including all entries in NTT and Montgomery form.
+// If ck is lazy, the entries are expanded while writing.
+// The result can be read by [ReadCommitKey].
+func (ck *CommitKey) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+
+	var hdr [8]byte
+	binary.BigEndian.PutUint64(hdr[:], uint64(len(ck.crs)))
+	for _, b := range [][]byte{commitKeyMagic[:], ck.digest[:], hdr[:], ck.crs} {
+		nn, err := bw.Write(b)
+		n += int64(nn)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	buf, bufOut := ck.ringQ.NewPoly(), ck.ringQOut.NewPoly()
+	write := func(label byte, entry func(i, j int) ring.Poly) error {
+		for i := range ck.dims[label][0] {
+			for j := range ck.dims[label][1] {
+				nn, err := entry(i, j).WriteTo(bw)
+				n += nn
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := write(labelIn, func(i, j int) ring.Poly { return ck.in(i, j, buf) }); err != nil {
+		return n, err
+	}
+	if err := write(labelMLWE, func(i, j int) ring.Poly { return ck.mlwe(i, j, buf) }); err != nil {
+		return n, err
+	}
+	if err := write(labelOut, func(i, j int) ring.Poly { return ck.out(i, j, bufOut) }); err != nil {
+		return n, err
+	}
+
+	return n, bw.Flush()
+}
+
+// ReadCommitKey reads a [CommitKey] for params written by [CommitKey.WriteTo].
+// It returns an error if the key was written for different parameters.
+// The entries are not recomputed from the CRS, so r must be trusted.
+func ReadCommitKey(params Parameters, r io.Reader) (*CommitKey, error) {
+	br := bufio.NewReader(r)
+
+	var magic [8]byte
+	var digest [sha256.Size]byte
+	var hdr [8]byte
+	for _, b := range [][]byte{magic[:], digest[:], hdr[:]} {
+		if _, err := io.ReadFull(br, b); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case [7]byte(magic[:7]) != [7]byte(commitKeyMagic[:7]):
+		return nil, errors.New("jindo: invalid commit key header")
+	case magic[7] != commitKeyVersion:
+		return nil, fmt.Errorf("jindo: unsupported commit key version %v", magic[7])
+	}
+
+	crsLen := binary.BigEndian.Uint64(hdr[:])
+	if crsLen > 1<<20 {
+		return nil, errors.New("jindo: invalid commit key header")
+	}
+	crs := make([]byte, crsLen)
+	if _, err := io.ReadFull(br, crs); err != nil {
+		return nil, err
+	}
+
+	ck := newCommitKey(params, crs)
+	if ck.digest != digest {
+		return nil, errors.New("jindo: commit key does not match parameters")
+	}
+
+	read := func(r *ring.Ring, label byte) ([][]ring.Poly, error) {
+		m := make([][]ring.Poly, ck.dims[label][0])
+		for i := range m {
+			m[i] = make([]ring.Poly, ck.dims[label][1])
+			for j := range m[i] {
+				m[i][j] = r.NewPoly()
+				if _, err := m[i][j].ReadFrom(br); err != nil {
+					return nil, err
+				}
+				if m[i][j].N() != r.N() || m[i][j].Level() != r.Level() {
+					return nil, errors.New("jindo: commit key does not match parameters")
+				}
+			}
+		}
+		return m, nil
+	}
+
+	var err error
+	if ck.In, err = read(ck.ringQ, labelIn); err != nil {
+		return nil, err
+	}
+	if ck.MLWE, err = read(ck.ringQ, labelMLWE); err != nil {
+		return nil, err
+	}
+	if ck.Out, err = read(ck.ringQOut, labelOut); err != nil {
+		return nil, err
+	}
+
+	return ck, nil
+}
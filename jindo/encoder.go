@@ -6,6 +6,7 @@ import (
 
 	"github.com/sp301415/ringo-snark/math/bignum"
 	"github.com/sp301415/ringo-snark/math/csprng"
+	"github.com/sp301415/ringo-snark/profile"
 	"github.com/tuneinsight/lattigo/v6/ring"
 )
 
@@ -24,6 +25,8 @@ type Encoder[E bignum.Uint[E]] struct {
 	deltaInv []float64
 
 	buf encoderBuffer[E]
+
+	prof *profile.Profiler
 }
 
 // encoderBuffer is a buffer for [Encoder].
@@ -114,6 +117,7 @@ func (e *Encoder[E]) encodeTo(pOut ring.Poly, v []E) {
 	e.baseEncodeTo(pOut, v)
 	e.params.ringQ.MFormLazy(pOut, pOut)
 	e.params.ringQ.NTT(pOut, pOut)
+	e.prof.Count(profile.OpNTT, 1)
 }
 
 // baseEncodeTo encodes v to pOut without NTT and MForm.
@@ -198,6 +202,8 @@ func (e *Encoder[E]) randEncodeTo(pOut ring.Poly, v []E, stdDev float64) {
 	e.params.ringQ.MForm(pOut, pOut)
 	e.params.ringQ.Add(pOut, e.buf.pSampleShift, pOut)
 	e.params.ringQ.NTT(pOut, pOut)
+	e.prof.Count(profile.OpGaussianSample, e.params.ringQ.N())
+	e.prof.Count(profile.OpNTT, 1)
 }
 
 // DecodeTo decodes p to vOut.
@@ -218,6 +224,21 @@ func (e *Encoder[E]) DecodeTo(vOut []E, p ring.Poly) {
 	}
 }
 
+// setSeed reseeds the samplers of e with seed.
+// If seed is nil, they are reseeded from crypto/rand.
+func (e *Encoder[E]) setSeed(seed []byte) {
+	if seed == nil {
+		e.twinCDT = e.twinCDT.SafeCopy()
+		e.cosac = csprng.NewCOSACSampler()
+		e.rounded = csprng.NewRoundedGaussianSampler()
+		return
+	}
+
+	e.twinCDT = e.twinCDT.SafeCopyWithSeed(csprng.DeriveSeed(seed, "jindo.Encoder.TwinCDT"))
+	e.cosac = csprng.NewCOSACSamplerWithSeed(csprng.DeriveSeed(seed, "jindo.Encoder.COSAC"))
+	e.rounded = csprng.NewRoundedGaussianSamplerWithSeed(csprng.DeriveSeed(seed, "jindo.Encoder.Rounded"))
+}
+
 // safeCopy returns a thread-safe copy.
 func (e *Encoder[E]) safeCopy() *Encoder[E] {
 	return &Encoder[E]{
@@ -232,5 +253,7 @@ func (e *Encoder[E]) safeCopy() *Encoder[E] {
 		deltaInv: e.deltaInv,
 
 		buf: newEncoderBuffer[E](e.params.ringQ),
+
+		prof: e.prof,
 	}
 }
@@ -1,81 +1,13 @@
 package jindo
 
 import (
+	"bytes"
+	"errors"
 	"io"
 
-	"github.com/sp301415/ringo-snark/math/csprng"
 	"github.com/tuneinsight/lattigo/v6/ring"
 )
 
-// CommitKey is the key for commitment.
-// Must be initialized with CRS.
-type CommitKey struct {
-	crs []byte
-
-	In   [][]ring.Poly
-	MLWE [][]ring.Poly
-	Out  [][]ring.Poly
-}
-
-// NewCommitKey creates a new [CommitKey].
-func NewCommitKey(params Parameters, crs []byte) *CommitKey {
-	u := csprng.NewUniformSamplerWithSeed(crs)
-
-	in := make([][]ring.Poly, params.inMSISRank)
-	for i := range in {
-		in[i] = make([]ring.Poly, params.rows)
-		for j := range in[i] {
-			in[i][j] = params.ringQ.NewPoly()
-			for k := range params.ringQOut.N() {
-				for l := 0; l < params.ringQ.ModuliChainLength(); l++ {
-					in[i][j].Coeffs[l][k] = u.SampleN(params.ringQ.SubRings[l].Modulus)
-				}
-			}
-		}
-	}
-
-	mlwe := make([][]ring.Poly, params.inMSISRank)
-	for i := range mlwe {
-		mlwe[i] = make([]ring.Poly, params.mlweRank)
-		for j := range mlwe[i] {
-			mlwe[i][j] = params.ringQ.NewPoly()
-			for k := range params.ringQOut.N() {
-				for l := 0; l < params.ringQ.ModuliChainLength(); l++ {
-					mlwe[i][j].Coeffs[l][k] = u.SampleN(params.ringQ.SubRings[l].Modulus)
-				}
-			}
-		}
-	}
-
-	out := make([][]ring.Poly, params.outMSISRank)
-	for i := range out {
-		out[i] = make([]ring.Poly, params.inComDcmpLen)
-		for j := range out[i] {
-			out[i][j] = params.ringQOut.NewPoly()
-			for k := range params.ringQOut.N() {
-				for l := 0; l < params.ringQOut.ModuliChainLength(); l++ {
-					out[i][j].Coeffs[l][k] = u.SampleN(params.ringQOut.SubRings[l].Modulus)
-				}
-			}
-		}
-	}
-
-	crsCopy := make([]byte, len(crs))
-	copy(crsCopy, crs)
-
-	return &CommitKey{
-		crs: crsCopy,
-
-		In:   in,
-		MLWE: mlwe,
-		Out:  out,
-	}
-}
-
-func (ck *CommitKey) WriteRawTo(w io.Writer) {
-	w.Write(ck.crs)
-}
-
 // Commitment is a commitment of a polynomial.
 type Commitment struct {
 	Value []ring.Poly
@@ -99,6 +31,30 @@ func (com *Commitment) WriteRawTo(w io.Writer) {
 	}
 }
 
+// MarshalBinary encodes com into bytes.
+func (com *Commitment) MarshalBinary() ([]byte, error) {
+	return appendPolys(nil, com.Value)
+}
+
+// UnmarshalBinary decodes com from bytes written by [Commitment.MarshalBinary].
+func (com *Commitment) UnmarshalBinary(b []byte) error {
+	r := bytes.NewReader(b)
+
+	var err error
+	if com.Value, err = readPolys(r); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return errors.New("jindo: trailing bytes")
+	}
+	return nil
+}
+
+// isValid returns true if com has the shape of a commitment for params.
+func (com *Commitment) isValid(params Parameters) bool {
+	return com != nil && len(com.Value) == params.outMSISRank && isPolysOf(params.ringQOut, com.Value)
+}
+
 // Opening is an opening of a commitment.
 type Opening struct {
 	InCommit []ring.Poly
@@ -137,6 +93,8 @@ func NewOpening(params Parameters) *Opening {
 }
 
 // Proof is a proof of evaluation.
+// Partial holds the partial evaluations at each point, cols per point,
+// and the other entries are shared by all points.
 type Proof struct {
 	InCommit    []ring.Poly
 	Partial     []ring.Poly
@@ -146,8 +104,13 @@ type Proof struct {
 	MLWE   []ring.Poly
 }
 
-// NewProof creates a new [Proof].
+// NewProof creates a new [Proof] of evaluation at a single point.
 func NewProof(params Parameters) *Proof {
+	return newProof(params, 1)
+}
+
+// newProof creates a new [Proof] of evaluation at the given number of points.
+func newProof(params Parameters, points int) *Proof {
 	resEcd := make([]ring.Poly, params.rows)
 	for i := 0; i < params.rows; i++ {
 		resEcd[i] = params.ringQ.NewPoly()
@@ -158,8 +121,8 @@ func NewProof(params Parameters) *Proof {
 		resMLWE[i] = params.ringQ.NewPoly()
 	}
 
-	partial := make([]ring.Poly, params.cols)
-	for i := 0; i < params.cols; i++ {
+	partial := make([]ring.Poly, points*params.cols)
+	for i := 0; i < points*params.cols; i++ {
 		partial[i] = params.ringQ.NewPoly()
 	}
 
@@ -177,3 +140,63 @@ func NewProof(params Parameters) *Proof {
 		InCommit: inCom,
 	}
 }
+
+// isValid returns true if pf has the shape of a proof for params at the given number of points.
+func (pf *Proof) isValid(params Parameters, points int) bool {
+	switch {
+	case pf == nil:
+		return false
+	case len(pf.InCommit) != params.inComDcmpLen || len(pf.Partial) != points*params.cols:
+		return false
+	case len(pf.Encode) != params.rows || len(pf.MLWE) != params.mlweRank+params.inMSISRank:
+		return false
+	}
+
+	return isPolysOf(params.ringQOut, pf.InCommit) &&
+		isPolysOf(params.ringQ, pf.Partial) &&
+		isPolysOf(params.ringQ, []ring.Poly{pf.PartialMask}) &&
+		isPolysOf(params.ringQ, pf.Encode) &&
+		isPolysOf(params.ringQ, pf.MLWE)
+}
+
+// MarshalBinary encodes pf into bytes.
+func (pf *Proof) MarshalBinary() ([]byte, error) {
+	var b []byte
+	var err error
+	for _, ps := range [][]ring.Poly{pf.InCommit, pf.Partial, {pf.PartialMask}, pf.Encode, pf.MLWE} {
+		if b, err = appendPolys(b, ps); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes pf from bytes written by [Proof.MarshalBinary].
+func (pf *Proof) UnmarshalBinary(b []byte) error {
+	r := bytes.NewReader(b)
+
+	var partialMask []ring.Poly
+	for _, ps := range []*[]ring.Poly{&pf.InCommit, &pf.Partial, &partialMask, &pf.Encode, &pf.MLWE} {
+		var err error
+		if *ps, err = readPolys(r); err != nil {
+			return err
+		}
+	}
+	if len(partialMask) != 1 {
+		return errors.New("jindo: invalid proof")
+	}
+	pf.PartialMask = partialMask[0]
+
+	if r.Len() != 0 {
+		return errors.New("jindo: trailing bytes")
+	}
+	return nil
+}
+
+// MultiProof is a proof of evaluation at multiple points.
+// It contains one [Proof] per class of polynomials evaluated at the same set of points,
+// which opens the class once at all of its points.
+// See [Prover.EvaluateMulti] for the classes.
+type MultiProof struct {
+	Proofs []*Proof
+}
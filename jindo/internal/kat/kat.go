@@ -0,0 +1,311 @@
+// Package kat defines the known-answer test vectors of Jindo.
+//
+// The vectors are generated by jindo/internal/katgen and checked by TestKAT.
+// A vector stores the inputs of the prover, but not the commitments and the proof,
+// which are reproduced from its seed.
+//
+// This package is test-only code, shared by TestKAT and katgen.
+package kat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"github.com/sp301415/ringo-snark/jindo"
+	"github.com/sp301415/ringo-snark/jindo/internal/zp"
+	"github.com/sp301415/ringo-snark/math/bignum"
+	"github.com/sp301415/ringo-snark/math/csprng"
+	"github.com/tuneinsight/lattigo/v6/ring"
+)
+
+// Vector is a known-answer test vector of Jindo.
+// All byte strings are hex encoded, and field elements are encoded by Marshal.
+type Vector struct {
+	Base          uint64 `json:"base"`
+	Exp           int    `json:"exp"`
+	TargetN       int    `json:"targetN"`
+	Batch         int    `json:"batch"`
+	SecurityLevel int    `json:"securityLevel"`
+
+	CRS  string `json:"crs"`
+	Seed string `json:"seed"`
+
+	Values [][]string `json:"values"`
+	Point  string     `json:"point"`
+	Evals  []string   `json:"evals"`
+
+	// Digest is the SHA-256 digest of the commitments and the proof,
+	// or empty if they are not pinned.
+	Digest string `json:"digest,omitempty"`
+	// Mutations are the changes of the statement and the proof
+	// which must be rejected by the verifier.
+	Mutations []Mutation `json:"mutations,omitempty"`
+}
+
+// Ops of a [Mutation].
+const (
+	// OpPerturb changes the first coefficient of the Index-th entry by one.
+	OpPerturb = "perturb"
+	// OpDrop removes the last entry.
+	OpDrop = "drop"
+	// OpDup appends a copy of the last entry.
+	OpDup = "dup"
+	// OpLevel removes the last level of the Index-th entry.
+	OpLevel = "level"
+)
+
+// Mutation is a field-level change of a valid statement and proof.
+//
+// Field is one of "Evals", "Commitment", which is the first commitment,
+// or "Proof.<Field>" for a field of [jindo.Proof].
+// "Evals" only supports [OpPerturb], and "Proof.PartialMask" does not support [OpDrop] and [OpDup].
+type Mutation struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Index int    `json:"index,omitempty"`
+}
+
+// Parameters returns the parameters of vec.
+func (vec Vector) Parameters() (jindo.Parameters, error) {
+	return jindo.NewParametersFromModulus(vec.Base, vec.Exp, jindo.ParametersLiteral{
+		TargetN:       vec.TargetN,
+		Batch:         vec.Batch,
+		SecurityLevel: vec.SecurityLevel,
+	})
+}
+
+// Decode returns the values, the point and the evaluations of vec.
+func (vec Vector) Decode() (values [][]*zp.Uint, x *zp.Uint, y []*zp.Uint, err error) {
+	values = make([][]*zp.Uint, len(vec.Values))
+	for i := range values {
+		if values[i], err = decodeElems(vec.Values[i]); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if x, err = decodeElem(vec.Point); err != nil {
+		return nil, nil, nil, err
+	}
+	if y, err = decodeElems(vec.Evals); err != nil {
+		return nil, nil, nil, err
+	}
+	return values, x, y, nil
+}
+
+// Prove commits the values of vec and evaluates them at its point,
+// with the prover seeded by its seed.
+func Prove(vec Vector) ([]*jindo.Commitment, []*zp.Uint, *jindo.Proof, error) {
+	params, err := vec.Parameters()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	crs, err := hex.DecodeString(vec.CRS)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	seed, err := hex.DecodeString(vec.Seed)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	values, x, _, err := vec.Decode()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	prv := jindo.NewProver[*zp.Uint](params, crs)
+	prv.SetSeed(seed)
+
+	com := make([]*jindo.Commitment, len(values))
+	open := make([]*jindo.Opening, len(values))
+	for i := range values {
+		com[i], open[i] = prv.Commit(values[i])
+	}
+	y, pf := prv.Evaluate(x, values, com, open)
+	return com, y, pf, nil
+}
+
+// Digest returns the SHA-256 digest of com and pf, as pinned by [Vector.Digest].
+func Digest(com []*jindo.Commitment, pf *jindo.Proof) (string, error) {
+	h := sha256.New()
+	for i := range com {
+		b, err := com[i].MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		h.Write(b)
+	}
+	b, err := pf.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Generate returns the known-answer test vectors.
+// Only the first vector pins the digest and holds the mutations.
+func Generate() ([]Vector, error) {
+	var vecs []Vector
+	for _, batch := range []int{1, 2} {
+		seed := []byte(fmt.Sprintf("Jindo KAT %v", batch))
+		vec := Vector{
+			Base:          60272,
+			Exp:           16,
+			TargetN:       16,
+			Batch:         batch,
+			SecurityLevel: 128,
+
+			CRS:  hex.EncodeToString([]byte("Jindo!")),
+			Seed: hex.EncodeToString(seed),
+		}
+		params, err := vec.Parameters()
+		if err != nil {
+			return nil, err
+		}
+
+		// The inputs are derived from the seed, so that the vectors are reproducible.
+		u := csprng.NewUniformSamplerWithSeed(seed)
+		vec.Values = make([][]string, batch)
+		for i := range vec.Values {
+			vec.Values[i] = make([]string, params.Rank())
+			for j := range vec.Values[i] {
+				vec.Values[i][j] = hex.EncodeToString(bignum.MustSetRandomFrom(new(zp.Uint), u).Marshal())
+			}
+		}
+		vec.Point = hex.EncodeToString(bignum.MustSetRandomFrom(new(zp.Uint), u).Marshal())
+
+		com, y, pf, err := Prove(vec)
+		if err != nil {
+			return nil, err
+		}
+		for i := range y {
+			vec.Evals = append(vec.Evals, hex.EncodeToString(y[i].Marshal()))
+		}
+
+		if batch == 1 {
+			if vec.Digest, err = Digest(com, pf); err != nil {
+				return nil, err
+			}
+			vec.Mutations = mutations()
+		}
+		vecs = append(vecs, vec)
+	}
+	return vecs, nil
+}
+
+// mutations returns the mutations of the first vector.
+func mutations() []Mutation {
+	m := []Mutation{
+		{Field: "Evals", Op: OpPerturb},
+		{Field: "Commitment", Op: OpPerturb},
+		{Field: "Commitment", Op: OpDrop},
+		{Field: "Proof.InCommit", Op: OpPerturb},
+		{Field: "Proof.InCommit", Op: OpDrop},
+		{Field: "Proof.InCommit", Op: OpDup},
+		{Field: "Proof.PartialMask", Op: OpPerturb},
+		{Field: "Proof.PartialMask", Op: OpLevel},
+	}
+	// The polynomials over ringQOut may hold more levels than ringQOut,
+	// so only the polynomials over ringQ lose a level.
+	for _, field := range []string{"Proof.Partial", "Proof.Encode", "Proof.MLWE"} {
+		m = append(m,
+			Mutation{Field: field, Op: OpPerturb},
+			Mutation{Field: field, Op: OpDrop},
+			Mutation{Field: field, Op: OpDup},
+			Mutation{Field: field, Op: OpLevel},
+		)
+	}
+	return m
+}
+
+// Apply applies m to com, y and pf in place.
+func (m Mutation) Apply(com []*jindo.Commitment, y []*zp.Uint, pf *jindo.Proof) error {
+	switch m.Field {
+	case "Evals":
+		if m.Op != OpPerturb || m.Index >= len(y) {
+			return m.invalid()
+		}
+		y[m.Index] = new(zp.Uint).Add(y[m.Index], new(zp.Uint).SetUint64(1))
+		return nil
+	case "Commitment":
+		return m.applyPolys(&com[0].Value)
+	case "Proof.InCommit":
+		return m.applyPolys(&pf.InCommit)
+	case "Proof.Partial":
+		return m.applyPolys(&pf.Partial)
+	case "Proof.PartialMask":
+		if m.Op == OpDrop || m.Op == OpDup {
+			return m.invalid()
+		}
+		p := []ring.Poly{pf.PartialMask}
+		if err := m.applyPolys(&p); err != nil {
+			return err
+		}
+		pf.PartialMask = p[0]
+		return nil
+	case "Proof.Encode":
+		return m.applyPolys(&pf.Encode)
+	case "Proof.MLWE":
+		return m.applyPolys(&pf.MLWE)
+	}
+	return m.invalid()
+}
+
+// applyPolys applies m to ps.
+func (m Mutation) applyPolys(ps *[]ring.Poly) error {
+	switch m.Op {
+	case OpDrop:
+		*ps = (*ps)[:len(*ps)-1]
+		return nil
+	case OpDup:
+		*ps = append(*ps, *(*ps)[len(*ps)-1].CopyNew())
+		return nil
+	}
+
+	if m.Index >= len(*ps) {
+		return m.invalid()
+	}
+	p := &(*ps)[m.Index]
+	switch m.Op {
+	case OpPerturb:
+		// Decrementing a nonzero coefficient keeps it below the modulus.
+		if p.Coeffs[0][0] > 0 {
+			p.Coeffs[0][0]--
+		} else {
+			p.Coeffs[0][0]++
+		}
+		return nil
+	case OpLevel:
+		p.Coeffs = slices.Clone(p.Coeffs[:len(p.Coeffs)-1])
+		return nil
+	}
+	return m.invalid()
+}
+
+// invalid returns the error of an unsupported mutation.
+func (m Mutation) invalid() error {
+	return fmt.Errorf("kat: unsupported mutation %+v", m)
+}
+
+func decodeElem(s string) (*zp.Uint, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	x := new(zp.Uint)
+	x.Unmarshal(b)
+	return x, nil
+}
+
+func decodeElems(s []string) ([]*zp.Uint, error) {
+	xs := make([]*zp.Uint, len(s))
+	for i := range s {
+		x, err := decodeElem(s[i])
+		if err != nil {
+			return nil, err
+		}
+		xs[i] = x
+	}
+	return xs, nil
+}
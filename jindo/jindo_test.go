@@ -1,12 +1,25 @@
 package jindo_test
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/sp301415/ringo-snark/jindo"
+	"github.com/sp301415/ringo-snark/jindo/internal/kat"
 	"github.com/sp301415/ringo-snark/jindo/internal/zp"
+	"github.com/sp301415/ringo-snark/jindo/security"
+	"github.com/sp301415/ringo-snark/math/bigpoly"
+	"github.com/sp301415/ringo-snark/profile"
+	"github.com/sp301415/ringo-snark/transcript"
 	"github.com/stretchr/testify/assert"
+	"github.com/tuneinsight/lattigo/v6/ring"
 )
 
 var (
@@ -15,17 +28,51 @@ var (
 
 func TestJindo(t *testing.T) {
 	t.Run("Single", func(t *testing.T) {
-		testJindo(t, 1)
+		testJindo(t, jindo.NewParameters[*zp.Uint](1<<10, 1))
 	})
 
 	t.Run("Batch", func(t *testing.T) {
-		testJindo(t, 8)
+		testJindo(t, jindo.NewParameters[*zp.Uint](1<<10, 8))
+	})
+
+	for _, lv := range []int{192, 256} {
+		t.Run(fmt.Sprintf("SecurityLevel=%v", lv), func(t *testing.T) {
+			params, err := jindo.NewParametersFromLiteral[*zp.Uint](jindo.ParametersLiteral{
+				TargetN:       1 << 10,
+				Batch:         2,
+				SecurityLevel: lv,
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, lv, params.SecurityLevel())
+			testJindo(t, params)
+		})
+	}
+
+	for _, batch := range []int{1, 2} {
+		t.Run(fmt.Sprintf("BatchVerify/Batch=%v", batch), func(t *testing.T) {
+			testBatchVerify(t, batch)
+		})
+	}
+
+	t.Run("Multi", func(t *testing.T) {
+		testMulti(t, jindo.NewParameters[*zp.Uint](1<<10, 4))
+	})
+
+	t.Run("MultiShift", func(t *testing.T) {
+		params, err := jindo.NewParametersFromLiteral[*zp.Uint](jindo.ParametersLiteral{
+			TargetN: 1 << 10,
+			Batch:   2,
+			Points:  2,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, params.Points())
+		testMultiShift(t, params)
 	})
 }
 
-func testJindo(t *testing.T, batch int) {
-	N := 1 << 10
-	params := jindo.NewParameters[*zp.Uint](N, batch)
+func testJindo(t *testing.T, params jindo.Parameters) {
+	N := params.Rank()
+	batch := params.Batch()
 	v := make([][]*zp.Uint, batch)
 	for i := range batch {
 		v[i] = make([]*zp.Uint, N)
@@ -51,6 +98,609 @@ func testJindo(t *testing.T, batch int) {
 	assert.True(t, ok)
 }
 
+func testMulti(t *testing.T, params jindo.Parameters) {
+	N := params.Rank()
+	batch := params.Batch()
+	v := make([][]*zp.Uint, batch)
+	for i := range batch {
+		v[i] = make([]*zp.Uint, N)
+		for j := range N {
+			v[i][j] = new(zp.Uint).New().MustSetRandom()
+		}
+	}
+
+	prv := jindo.NewProver[*zp.Uint](params, crs)
+	vrf := jindo.NewVerifier[*zp.Uint](params, crs)
+
+	com := make([]*jindo.Commitment, batch)
+	open := make([]*jindo.Opening, batch)
+
+	for i := range batch {
+		com[i], open[i] = prv.Commit(v[i])
+	}
+
+	x := new(zp.Uint).New().MustSetRandom()
+	xShift := new(zp.Uint).New().Mul(x, new(zp.Uint).New().SetUint64(2))
+	points := []*zp.Uint{x, xShift, new(zp.Uint).New().MustSetRandom()}
+	which := [][]int{{0, 3}, {1}, {2}}
+
+	y, pf := prv.EvaluateMulti(points, which, v, com, open)
+	for k := range points {
+		for l, i := range which[k] {
+			assert.Equal(t, (&bigpoly.Poly[*zp.Uint]{Coeffs: v[i]}).Evaluate(points[k]), y[k][l])
+		}
+	}
+	assert.True(t, vrf.VerifyMulti(points, which, com, y, pf))
+
+	y[1][0].Add(y[1][0], new(zp.Uint).New().SetUint64(1))
+	assert.False(t, vrf.VerifyMulti(points, which, com, y, pf))
+	y[1][0].Sub(y[1][0], new(zp.Uint).New().SetUint64(1))
+
+	whichWrong := [][]int{{3, 0}, {1}, {2}}
+	assert.False(t, vrf.VerifyMulti(points, whichWrong, com, y, pf))
+
+	whichOverlap := [][]int{{0, 3}, {1, 3}, {2}}
+	assert.Panics(t, func() { prv.EvaluateMulti(points, whichOverlap, v, com, open) })
+	assert.Panics(t, func() { vrf.VerifyMulti(points, whichOverlap, com, y, pf) })
+}
+
+func testMultiShift(t *testing.T, params jindo.Parameters) {
+	N := params.Rank()
+	v := make([][]*zp.Uint, params.Batch())
+	for i := range v {
+		v[i] = make([]*zp.Uint, N)
+		for j := range N {
+			v[i][j] = new(zp.Uint).New().MustSetRandom()
+		}
+	}
+
+	prv := jindo.NewProver[*zp.Uint](params, crs)
+	vrf := jindo.NewVerifier[*zp.Uint](params, crs)
+
+	com := make([]*jindo.Commitment, len(v))
+	open := make([]*jindo.Opening, len(v))
+	for i := range v {
+		com[i], open[i] = prv.Commit(v[i])
+	}
+
+	// v[0] is opened at x and ω·x in one proof, and v[1] at x alone.
+	x := new(zp.Uint).New().MustSetRandom()
+	omega := new(zp.Uint).New().MustSetRandom()
+	xShift := new(zp.Uint).New().Mul(omega, x)
+	points := []*zp.Uint{x, xShift}
+	which := [][]int{{0, 1}, {0}}
+
+	y, pf := prv.EvaluateMulti(points, which, v, com, open)
+	for k := range points {
+		for l, i := range which[k] {
+			assert.Equal(t, (&bigpoly.Poly[*zp.Uint]{Coeffs: v[i]}).Evaluate(points[k]), y[k][l])
+		}
+	}
+	assert.Len(t, pf.Proofs, 2)
+	assert.True(t, vrf.VerifyMulti(points, which, com, y, pf))
+
+	y[1][0].Add(y[1][0], new(zp.Uint).New().SetUint64(1))
+	assert.False(t, vrf.VerifyMulti(points, which, com, y, pf))
+	y[1][0].Sub(y[1][0], new(zp.Uint).New().SetUint64(1))
+
+	y[0][0], y[1][0] = y[1][0], y[0][0]
+	assert.False(t, vrf.VerifyMulti(points, which, com, y, pf))
+	y[0][0], y[1][0] = y[1][0], y[0][0]
+
+	whichThree := [][]int{{0}, {0}, {0}}
+	pointsThree := append(points, new(zp.Uint).New().MustSetRandom())
+	assert.Panics(t, func() { prv.EvaluateMulti(pointsThree, whichThree, v, com, open) })
+}
+
+func TestCombine(t *testing.T) {
+	params := jindo.NewParameters[*zp.Uint](1<<10, 1)
+
+	prv := jindo.NewProver[*zp.Uint](params, crs)
+	vrf := jindo.NewVerifier[*zp.Uint](params, crs)
+
+	v := make([][]*zp.Uint, 2)
+	com := make([]*jindo.Commitment, 2)
+	open := make([]*jindo.Opening, 2)
+	for i := range v {
+		v[i] = make([]*zp.Uint, params.Rank())
+		for j := range v[i] {
+			v[i][j] = new(zp.Uint).New().MustSetRandom()
+		}
+		com[i], open[i] = prv.Commit(v[i])
+	}
+
+	coeffs := [][]int64{{1, -2}, {3}}
+	assert.Equal(t, 6.0, jindo.CombinationNormGrowth(coeffs))
+
+	comComb := jindo.CombineCommitments(params, coeffs, com)
+	openComb := jindo.CombineOpenings(params, coeffs, open)
+
+	s0 := jindo.CombinationScalar[*zp.Uint](params, coeffs[0])
+	s1 := jindo.CombinationScalar[*zp.Uint](params, coeffs[1])
+	vComb := make([]*zp.Uint, params.Rank())
+	for j := range vComb {
+		vComb[j] = new(zp.Uint).New().Mul(s0, v[0][j])
+		vComb[j].Add(vComb[j], new(zp.Uint).New().Mul(s1, v[1][j]))
+	}
+
+	x := new(zp.Uint).New().MustSetRandom()
+	y, pf := prv.Evaluate(x, [][]*zp.Uint{vComb}, []*jindo.Commitment{comComb}, []*jindo.Opening{openComb})
+	assert.True(t, vrf.Verify(x, []*jindo.Commitment{comComb}, y, pf))
+
+	yWrong, pfWrong := prv.Evaluate(x, [][]*zp.Uint{v[0]}, []*jindo.Commitment{comComb}, []*jindo.Opening{openComb})
+	assert.False(t, vrf.Verify(x, []*jindo.Commitment{comComb}, yWrong, pfWrong))
+}
+
+func TestCommitPublic(t *testing.T) {
+	params := jindo.NewParameters[*zp.Uint](1<<10, 2)
+
+	prv := jindo.NewProver[*zp.Uint](params, crs)
+	vrf := jindo.NewVerifier[*zp.Uint](params, crs)
+
+	v := make([][]*zp.Uint, 2)
+	for i := range v {
+		v[i] = make([]*zp.Uint, params.Rank())
+		for j := range v[i] {
+			v[i][j] = new(zp.Uint).New().MustSetRandom()
+		}
+	}
+
+	com := make([]*jindo.Commitment, 2)
+	open := make([]*jindo.Opening, 2)
+	com[0], open[0] = prv.Commit(v[0])
+	com[1], open[1] = prv.CommitPublic(v[1])
+
+	comAgain, _ := prv.CommitPublic(v[1])
+	assert.Equal(t, com[1], comAgain)
+	assert.Equal(t, com[1], vrf.CommitPublic(v[1]))
+
+	// The public opening has no randomness: no MLWE term, blinding row or mask column.
+	zero := params.RingQ().NewPoly()
+	for i := range open[1].Encode {
+		assert.Equal(t, zero, open[1].Encode[i][len(open[1].Encode[i])-1])
+		for j := range open[1].MLWE[i] {
+			assert.Equal(t, zero, open[1].MLWE[i][j])
+		}
+	}
+	for j := range open[1].Encode[params.Cols()] {
+		assert.Equal(t, zero, open[1].Encode[params.Cols()][j])
+	}
+
+	// A fresh verifier has never used its samplers or prover state.
+	assert.Equal(t, com[1], jindo.NewVerifier[*zp.Uint](params, crs).CommitPublic(v[1]))
+
+	x := new(zp.Uint).New().MustSetRandom()
+	y, pf := prv.Evaluate(x, v, com, open)
+	assert.True(t, vrf.Verify(x, []*jindo.Commitment{com[0], vrf.CommitPublic(v[1])}, y, pf))
+
+	v[1][0].Add(v[1][0], new(zp.Uint).New().SetUint64(1))
+	assert.False(t, vrf.Verify(x, []*jindo.Commitment{com[0], vrf.CommitPublic(v[1])}, y, pf))
+}
+
+func TestSetSeed(t *testing.T) {
+	params := jindo.NewParameters[*zp.Uint](1<<10, 1)
+	prv := jindo.NewProver[*zp.Uint](params, crs)
+
+	v := make([]*zp.Uint, params.Rank())
+	for i := range v {
+		v[i] = new(zp.Uint).New().MustSetRandom()
+	}
+	x := new(zp.Uint).New().MustSetRandom()
+
+	prove := func() (*jindo.Commitment, *jindo.Proof) {
+		com, open := prv.Commit(v)
+		_, pf := prv.Evaluate(x, [][]*zp.Uint{v}, []*jindo.Commitment{com}, []*jindo.Opening{open})
+		return com, pf
+	}
+
+	prv.SetSeed([]byte("seed"))
+	com0, pf0 := prove()
+	prv.SetSeed([]byte("seed"))
+	com1, pf1 := prove()
+	assert.Equal(t, com0, com1)
+	assert.Equal(t, pf0, pf1)
+
+	prv.SetSeed(nil)
+	com2, _ := prove()
+	assert.NotEqual(t, com0, com2)
+}
+
+func TestTranscript(t *testing.T) {
+	params := jindo.NewParameters[*zp.Uint](1<<10, 2)
+	prv := jindo.NewProver[*zp.Uint](params, crs)
+	vrf := jindo.NewVerifier[*zp.Uint](params, crs)
+
+	v := make([][]*zp.Uint, params.Batch())
+	com := make([]*jindo.Commitment, params.Batch())
+	open := make([]*jindo.Opening, params.Batch())
+	for i := range v {
+		v[i] = make([]*zp.Uint, params.Rank())
+		for j := range v[i] {
+			v[i][j] = new(zp.Uint).New().MustSetRandom()
+		}
+		com[i], open[i] = prv.Commit(v[i])
+	}
+	x := new(zp.Uint).New().MustSetRandom()
+
+	t.Run("SHAKE", func(t *testing.T) {
+		y, pf, err := prv.EvaluateWithTranscript(x, v, com, open, transcript.NewSHAKE128())
+		assert.NoError(t, err)
+		assert.True(t, vrf.Verify(x, com, y, pf))
+		assert.True(t, vrf.VerifyWithTranscript(x, com, y, pf, transcript.NewSHAKE128()))
+	})
+
+	t.Run("SHA256", func(t *testing.T) {
+		y, pf, err := prv.EvaluateWithTranscript(x, v, com, open, transcript.NewSHA256())
+		assert.NoError(t, err)
+		assert.True(t, vrf.VerifyWithTranscript(x, com, y, pf, transcript.NewSHA256()))
+		assert.False(t, vrf.Verify(x, com, y, pf))
+	})
+
+	t.Run("Continue", func(t *testing.T) {
+		// outer returns the transcript of an outer protocol,
+		// which binds the commitments and draws the evaluation point.
+		outer := func() (transcript.Transcript, *zp.Uint) {
+			oracle := transcript.NewSHA256()
+			for i := range com {
+				var buf bytes.Buffer
+				com[i].WriteRawTo(&buf)
+				oracle.Append("x", buf.Bytes())
+			}
+			xBytes, err := oracle.Challenge("x", 32)
+			assert.NoError(t, err)
+			return oracle, new(zp.Uint).New().SetBytes(xBytes)
+		}
+
+		oracle, x := outer()
+		y, pf, err := prv.ContinueEvaluate(x, v, com, open, oracle)
+		assert.NoError(t, err)
+
+		oracle, x = outer()
+		assert.True(t, vrf.ContinueVerify(x, com, y, pf, oracle))
+
+		oracle, x = outer()
+		assert.False(t, vrf.VerifyWithTranscript(x, com, y, pf, oracle))
+
+		oracle, x = outer()
+		ok, _ := vrf.ContinueBatchVerify([]*zp.Uint{x}, [][]*jindo.Commitment{com}, [][]*zp.Uint{y}, []*jindo.Proof{pf}, []transcript.Transcript{oracle})
+		assert.True(t, ok)
+	})
+
+	t.Run("Interactive", func(t *testing.T) {
+		toVerifier := make(chan transcript.Message)
+		toProver := make(chan []byte)
+		chal := transcript.NewChallenger(toVerifier, toProver)
+
+		errCh := make(chan error)
+		go func() { errCh <- chal.Run() }()
+
+		y, pf, err := prv.EvaluateWithTranscript(x, v, com, open, transcript.NewChannel(toVerifier, toProver))
+		close(toVerifier)
+		assert.NoError(t, err)
+		assert.NoError(t, <-errCh)
+
+		assert.True(t, vrf.VerifyWithTranscript(x, com, y, pf, chal.Replay()))
+		assert.False(t, vrf.Verify(x, com, y, pf))
+
+		xInvalid := new(zp.Uint).New().MustSetRandom()
+		assert.False(t, vrf.VerifyWithTranscript(xInvalid, com, y, pf, chal.Replay()))
+	})
+}
+
+// responseStdDev returns the empirical standard deviation of the coefficients of ps,
+// which are in NTT and Montgomery form.
+func responseStdDev(params jindo.Parameters, ps []ring.Poly) float64 {
+	ringQ := params.RingQ()
+	q := ringQ.SubRings[0].Modulus
+
+	var sum, sqSum, cnt float64
+	pInv := ringQ.NewPoly()
+	for i := range ps {
+		ringQ.IMForm(ps[i], pInv)
+		ringQ.INTT(pInv, pInv)
+		for _, c := range pInv.Coeffs[0] {
+			cSigned := float64(c)
+			if c > q/2 {
+				cSigned = -float64(q - c)
+			}
+			sum += cSigned
+			sqSum += cSigned * cSigned
+			cnt++
+		}
+	}
+
+	mean := sum / cnt
+	return math.Sqrt(sqSum/cnt - mean*mean)
+}
+
+func TestSimulator(t *testing.T) {
+	for _, batch := range []int{1, 2} {
+		t.Run(fmt.Sprintf("Batch=%v", batch), func(t *testing.T) {
+			testSimulator(t, jindo.NewParameters[*zp.Uint](1<<10, batch))
+		})
+	}
+
+	t.Run("Points=2", func(t *testing.T) {
+		params, err := jindo.NewParametersFromLiteral[*zp.Uint](jindo.ParametersLiteral{TargetN: 1 << 10, Batch: 1, Points: 2})
+		assert.NoError(t, err)
+		testSimulator(t, params)
+	})
+}
+
+func testSimulator(t *testing.T, params jindo.Parameters) {
+	batch := params.Batch()
+	prv := jindo.NewProver[*zp.Uint](params, crs)
+	vrf := jindo.NewVerifier[*zp.Uint](params, crs)
+	sim := jindo.NewSimulator[*zp.Uint](params, crs)
+
+	lens := make([]int, batch)
+	for i := range lens {
+		lens[i] = params.Rank()
+	}
+
+	x := new(zp.Uint).New().MustSetRandom()
+	y := make([]*zp.Uint, batch)
+	for i := range y {
+		y[i] = new(zp.Uint).New().MustSetRandom()
+	}
+
+	sp := sim.Simulate(x, lens, y)
+	assert.True(t, vrf.VerifyWithTranscript(x, sp.Commitments, y, sp.Proof, sp.Transcript()))
+
+	com := sp.Commitments[0]
+	sp.Commitments[0] = sim.Simulate(x, lens, y).Commitments[0]
+	assert.False(t, vrf.VerifyWithTranscript(x, sp.Commitments, y, sp.Proof, sp.Transcript()))
+	sp.Commitments[0] = com
+
+	y[0].Add(y[0], new(zp.Uint).New().SetUint64(1))
+	assert.False(t, vrf.VerifyWithTranscript(x, sp.Commitments, y, sp.Proof, sp.Transcript()))
+
+	// responses returns the blinding rows, the other rows and the MLWE parts
+	// of the responses of samples proofs generated by prove.
+	// The variance of a response depends on the norm of the random challenges,
+	// so it is averaged over several proofs.
+	samples := 16
+	responses := func(prove func() *jindo.Proof) (blind, rows, mlwe []ring.Poly) {
+		for range samples {
+			pf := prove()
+			blind = append(blind, pf.Encode[0])
+			rows = append(rows, pf.Encode[1:]...)
+			mlwe = append(mlwe, pf.MLWE...)
+		}
+		return
+	}
+
+	// realProof proves the evaluation of batch copies of v.
+	realProof := func(v []*zp.Uint) func() *jindo.Proof {
+		return func() *jindo.Proof {
+			vs := make([][]*zp.Uint, batch)
+			com := make([]*jindo.Commitment, batch)
+			open := make([]*jindo.Opening, batch)
+			for i := range vs {
+				vs[i] = v
+				com[i], open[i] = prv.Commit(v)
+			}
+			_, pf := prv.Evaluate(x, vs, com, open)
+			return pf
+		}
+	}
+
+	zero := make([]*zp.Uint, params.Rank())
+	random := make([]*zp.Uint, params.Rank())
+	for i := range zero {
+		zero[i] = new(zp.Uint).New()
+		random[i] = new(zp.Uint).New().MustSetRandom()
+	}
+
+	blindZero, rowsZero, mlweZero := responses(realProof(zero))
+	blindRandom, rowsRandom, mlweRandom := responses(realProof(random))
+	blindSim, rowsSim, mlweSim := responses(func() *jindo.Proof { return sim.Simulate(x, lens, y).Proof })
+
+	// The responses must not depend on the witness,
+	// and the simulated responses must follow the same distribution.
+	for _, r := range []struct {
+		name              string
+		zero, random, sim []ring.Poly
+	}{
+		{"Blind", blindZero, blindRandom, blindSim},
+		{"Rows", rowsZero, rowsRandom, rowsSim},
+		{"MLWE", mlweZero, mlweRandom, mlweSim},
+	} {
+		stdDevZero := responseStdDev(params, r.zero)
+		assert.InEpsilon(t, stdDevZero, responseStdDev(params, r.random), 0.15, r.name)
+		assert.InEpsilon(t, stdDevZero, responseStdDev(params, r.sim), 0.15, r.name)
+	}
+}
+
+func TestParametersLiteral(t *testing.T) {
+	params, err := jindo.NewParametersFromLiteral[*zp.Uint](jindo.ParametersLiteral{TargetN: 1 << 10, Batch: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 128, params.SecurityLevel())
+	assert.Equal(t, 5.0, params.TailCut())
+	assert.Equal(t, jindo.NewParameters[*zp.Uint](1<<10, 1).Size(), params.Size())
+
+	for _, lit := range []jindo.ParametersLiteral{
+		{TargetN: 0, Batch: 1},
+		{TargetN: 1 << 10, Batch: 0},
+		{TargetN: 1 << 10, Batch: 1, SecurityLevel: 100},
+		{TargetN: 1 << 10, Batch: 1, TailCut: 0.5},
+		{TargetN: 1 << 10, Batch: 1, Points: -1},
+	} {
+		_, err := jindo.NewParametersFromLiteral[*zp.Uint](lit)
+		assert.Error(t, err)
+	}
+}
+
+func TestSecurityEstimate(t *testing.T) {
+	var prev float64
+	for _, lv := range []int{128, 192, 256} {
+		params, err := jindo.NewParametersFromLiteral[*zp.Uint](jindo.ParametersLiteral{
+			TargetN:       1 << 10,
+			Batch:         1,
+			SecurityLevel: lv,
+		})
+		assert.NoError(t, err)
+
+		classical := security.EstimateParameters(params, security.CoreSVPClassical)
+		quantum := security.EstimateParameters(params, security.CoreSVPQuantum)
+
+		assert.Positive(t, classical.InnerMSIS.BlockSize)
+		assert.Positive(t, classical.OuterMSIS.BlockSize)
+		assert.Positive(t, classical.MLWE.BlockSize)
+		assert.GreaterOrEqual(t, classical.BindingBits, float64(params.SecurityLevel()))
+		assert.GreaterOrEqual(t, classical.HidingBits, float64(params.SecurityLevel()))
+		assert.Equal(t, min(classical.BindingBits, classical.HidingBits), classical.Bits)
+		assert.Less(t, quantum.Bits, classical.Bits)
+		assert.Greater(t, classical.Bits, prev)
+		prev = classical.Bits
+
+		// The hiding instance has few samples, so it is estimated with unlimited samples.
+		assert.Zero(t, security.MLWE(params).M)
+	}
+}
+
+func TestCommitKey(t *testing.T) {
+	params := jindo.NewParameters[*zp.Uint](1<<10, 1)
+
+	t.Run("Shared", func(t *testing.T) {
+		prv := jindo.NewProver[*zp.Uint](params, crs)
+		vrf := jindo.NewVerifier[*zp.Uint](params, crs)
+		assert.Same(t, prv.CommitKey(), vrf.CommitKey())
+
+		other := jindo.NewVerifier[*zp.Uint](params, []byte("Other CRS"))
+		assert.NotEqual(t, prv.CommitKey().Digest(), other.CommitKey().Digest())
+	})
+
+	t.Run("Serialize", func(t *testing.T) {
+		ck := jindo.NewCommitKey(params, crs)
+
+		var buf bytes.Buffer
+		_, err := ck.WriteTo(&buf)
+		assert.NoError(t, err)
+
+		var lazyBuf bytes.Buffer
+		_, err = jindo.NewLazyCommitKey(params, crs).WriteTo(&lazyBuf)
+		assert.NoError(t, err)
+		assert.Equal(t, buf.Bytes(), lazyBuf.Bytes())
+
+		ckRead, err := jindo.ReadCommitKey(params, bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+		assert.Equal(t, ck.Digest(), ckRead.Digest())
+		assert.Equal(t, ck.In, ckRead.In)
+		assert.Equal(t, ck.MLWE, ckRead.MLWE)
+		assert.Equal(t, ck.Out, ckRead.Out)
+
+		_, err = jindo.ReadCommitKey(jindo.NewParameters[*zp.Uint](1<<11, 1), bytes.NewReader(buf.Bytes()))
+		assert.Error(t, err)
+
+		oldVersion := slices.Clone(buf.Bytes())
+		oldVersion[7] = 1
+		_, err = jindo.ReadCommitKey(params, bytes.NewReader(oldVersion))
+		assert.ErrorContains(t, err, "version")
+	})
+
+	t.Run("Lazy", func(t *testing.T) {
+		v := make([]*zp.Uint, params.Rank())
+		for i := range v {
+			v[i] = new(zp.Uint).New().MustSetRandom()
+		}
+
+		prv := jindo.NewProverWithKey[*zp.Uint](params, jindo.NewCommitKey(params, crs))
+		vrf := jindo.NewVerifierWithKey[*zp.Uint](params, jindo.NewLazyCommitKey(params, crs))
+		assert.True(t, vrf.CommitKey().IsLazy())
+
+		com, open := prv.Commit(v)
+		x := new(zp.Uint).New().MustSetRandom()
+		y, pf := prv.Evaluate(x, [][]*zp.Uint{v}, []*jindo.Commitment{com}, []*jindo.Opening{open})
+		assert.True(t, vrf.Verify(x, []*jindo.Commitment{com}, y, pf))
+	})
+}
+
+func testBatchVerify(t *testing.T, batch int) {
+	N := 1 << 10
+	params := jindo.NewParameters[*zp.Uint](N, batch)
+
+	prv := jindo.NewProver[*zp.Uint](params, crs)
+	vrf := jindo.NewVerifier[*zp.Uint](params, crs)
+
+	n := 4
+	xs := make([]*zp.Uint, n)
+	coms := make([][]*jindo.Commitment, n)
+	ys := make([][]*zp.Uint, n)
+	pfs := make([]*jindo.Proof, n)
+	for i := range n {
+		v := make([][]*zp.Uint, batch)
+		for j := range batch {
+			v[j] = make([]*zp.Uint, N)
+			for k := range N {
+				v[j][k] = new(zp.Uint).New().MustSetRandom()
+			}
+		}
+
+		coms[i] = make([]*jindo.Commitment, batch)
+		open := make([]*jindo.Opening, batch)
+		for j := range batch {
+			coms[i][j], open[j] = prv.Commit(v[j])
+		}
+
+		xs[i] = new(zp.Uint).New().MustSetRandom()
+		ys[i], pfs[i] = prv.Evaluate(xs[i], v, coms[i], open)
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		ok, res := vrf.BatchVerify(xs, coms, ys, pfs)
+		assert.True(t, ok)
+		assert.Equal(t, []bool{true, true, true, true}, res)
+	})
+
+	t.Run("Amortized", func(t *testing.T) {
+		prof := profile.NewProfiler()
+		vrf.SetProfiler(prof)
+		defer vrf.SetProfiler(nil)
+
+		assert.True(t, vrf.Verify(xs[0], coms[0], ys[0], pfs[0]))
+		single := prof.Report().Total
+
+		prof.Reset()
+		ok, _ := vrf.BatchVerify(xs, coms, ys, pfs)
+		assert.True(t, ok)
+		total := prof.Report().Total
+
+		assert.Less(t, total.RingMul, int64(n)*single.RingMul)
+		assert.Less(t, total.RNSReconstruct, int64(n)*single.RNSReconstruct)
+	})
+
+	t.Run("InvalidEval", func(t *testing.T) {
+		ysInvalid := slices.Clone(ys)
+		ysInvalid[1] = slices.Clone(ys[1])
+		ysInvalid[1][0] = new(zp.Uint).New().Add(ys[1][0], new(zp.Uint).New().SetUint64(1))
+
+		ok, res := vrf.BatchVerify(xs, coms, ysInvalid, pfs)
+		assert.False(t, ok)
+		assert.Equal(t, []bool{true, false, true, true}, res)
+	})
+
+	t.Run("InvalidCommitment", func(t *testing.T) {
+		comsInvalid := []([]*jindo.Commitment){coms[0], coms[2], coms[2], coms[0]}
+
+		ok, res := vrf.BatchVerify(xs, comsInvalid, ys, pfs)
+		assert.False(t, ok)
+		assert.Equal(t, []bool{true, false, true, false}, res)
+	})
+
+	t.Run("InvalidResponse", func(t *testing.T) {
+		pfInvalid := *pfs[2]
+		pfInvalid.Encode = slices.Clone(pfs[2].Encode)
+		pfInvalid.Encode[0] = *pfs[2].Encode[0].CopyNew()
+		params.RingQ().Add(pfInvalid.Encode[0], pfInvalid.Encode[0], pfInvalid.Encode[0])
+		pfsInvalid := []*jindo.Proof{pfs[0], pfs[1], &pfInvalid, pfs[3]}
+
+		ok, res := vrf.BatchVerify(xs, coms, ys, pfsInvalid)
+		assert.False(t, ok)
+		assert.Equal(t, []bool{true, true, false, true}, res)
+	})
+}
+
 func BenchmarkSingle(b *testing.B) {
 	crs := []byte("Jindo!")
 	for _, logN := range []int{13, 15, 17, 19} {
@@ -139,3 +789,68 @@ func BenchmarkBatch(b *testing.B) {
 		assert.True(b, ok)
 	}
 }
+
+// katPath is the path of the known-answer test vectors,
+// which are generated by jindo/internal/katgen.
+var katPath = filepath.Join("testdata", "kat.json")
+
+// katClone returns a deep copy of com and pf.
+func katClone(t *testing.T, com []*jindo.Commitment, pf *jindo.Proof) ([]*jindo.Commitment, *jindo.Proof) {
+	comClone := make([]*jindo.Commitment, len(com))
+	for i := range com {
+		b, err := com[i].MarshalBinary()
+		assert.NoError(t, err)
+		comClone[i] = &jindo.Commitment{}
+		assert.NoError(t, comClone[i].UnmarshalBinary(b))
+	}
+
+	b, err := pf.MarshalBinary()
+	assert.NoError(t, err)
+	pfClone := &jindo.Proof{}
+	assert.NoError(t, pfClone.UnmarshalBinary(b))
+
+	return comClone, pfClone
+}
+
+func TestKAT(t *testing.T) {
+	b, err := os.ReadFile(katPath)
+	assert.NoError(t, err)
+
+	var vecs []kat.Vector
+	assert.NoError(t, json.Unmarshal(b, &vecs))
+
+	for i, vec := range vecs {
+		t.Run(fmt.Sprintf("Vector=%v", i), func(t *testing.T) {
+			params, err := vec.Parameters()
+			assert.NoError(t, err)
+			crsKAT, err := hex.DecodeString(vec.CRS)
+			assert.NoError(t, err)
+			_, x, y, err := vec.Decode()
+			assert.NoError(t, err)
+
+			com, yKAT, pf, err := kat.Prove(vec)
+			assert.NoError(t, err)
+			assert.Equal(t, y, yKAT)
+
+			if vec.Digest != "" {
+				digest, err := kat.Digest(com, pf)
+				assert.NoError(t, err)
+				assert.Equal(t, vec.Digest, digest)
+			}
+
+			vrf := jindo.NewVerifier[*zp.Uint](params, crsKAT)
+			assert.True(t, vrf.Verify(x, com, y, pf))
+
+			for _, m := range vec.Mutations {
+				t.Run(fmt.Sprintf("%v/%v", m.Field, m.Op), func(t *testing.T) {
+					comMut, pfMut := katClone(t, com, pf)
+					yMut := slices.Clone(y)
+					assert.NoError(t, m.Apply(comMut, yMut, pfMut))
+					assert.NotPanics(t, func() {
+						assert.False(t, vrf.Verify(x, comMut, yMut, pfMut))
+					})
+				})
+			}
+		})
+	}
+}
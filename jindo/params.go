@@ -1,9 +1,11 @@
 package jindo
 
 import (
+	"fmt"
 	"math"
 	"math/big"
 
+	"github.com/sp301415/ringo-snark/jindo/security"
 	"github.com/sp301415/ringo-snark/math/bignum"
 	"github.com/tuneinsight/lattigo/v6/ring"
 )
@@ -40,30 +42,59 @@ func newEncodeParameters[E bignum.Uint[E]]() encodeParameters {
 }
 
 const (
-	// rlweRank is secure for stdDev = 2sqrt(2)eta.
-	rlweRank = 1 << 13
-	// maxLogQ is secure for stdDev = 2sqrt(2)eta.
-	maxLogQ = 240
 	// eta is the smoothing parameter.
 	eta = 6
-	// tailCut is the tail cut bound for Gaussian distribution.
-	tailCut = 5
+	// defaultSecurityLevel is the default target security level in bits.
+	defaultSecurityLevel = 128
+	// defaultTailCut is the default tail cut bound for Gaussian distribution.
+	defaultTailCut = 5
 )
 
-func findMSISRank(d, q, beta float64) int {
-	if beta > q {
-		panic("findMSISRank: beta > q")
-	}
-	logBeta := math.Log2(beta)
-	logQ := math.Log2(q)
-	logDelta := math.Log2(1.005)
-	return int(math.Ceil((logBeta * logBeta) / (4 * d * logQ * logDelta)))
+// maxModuleRank is the maximum rank of the MSIS and MLWE instances searched.
+const maxModuleRank = 64
+
+// isSecure returns true if BKZ with block size b costs at least level bits
+// in the classical core-SVP model.
+func isSecure(b int, level int) bool {
+	return security.CoreSVPClassical.Bits(b) >= float64(level)
+}
+
+// ParametersLiteral is a literal for creating [Parameters].
+type ParametersLiteral struct {
+	// TargetN is the minimum number of coefficients in the committing polynomial.
+	TargetN int
+	// Batch is the number of polynomials to be committed.
+	Batch int
+	// Points is the number of points at which a commitment can be opened,
+	// which is the number of blinding rows of its matrix.
+	// If zero, 1 is used.
+	Points int
+
+	// SecurityLevel is the target security level in bits.
+	// Must be one of 128, 192 or 256.
+	// The MSIS and MLWE ranks are the smallest ones for which
+	// [security.EstimateParameters] gives at least this many bits
+	// in the classical core-SVP model.
+	// If zero, 128 is used.
+	SecurityLevel int
+	// TailCut is the tail cut bound for Gaussian distribution,
+	// in units of the standard deviation.
+	// Larger tail cut gives smaller completeness error, but larger norm bounds.
+	// If zero, 5 is used.
+	TailCut float64
 }
 
 // Parameters is the parameters for the Jindo polynomial commitment scheme.
 type Parameters struct {
 	// batch is the number of polynomials to be committed.
 	batch int
+	// points is the number of points at which a commitment can be opened.
+	points int
+
+	// securityLevel is the target security level in bits.
+	securityLevel int
+	// tailCut is the tail cut bound for Gaussian distribution.
+	tailCut float64
 
 	// rank is the number of cofficients in the committing polynomial.
 	rank int
@@ -92,6 +123,11 @@ type Parameters struct {
 	// inComDcmpLen is the length of the decomposed inner commitment.
 	inComDcmpLen int
 
+	// inMSISBound is the two-norm bound of the inner MSIS instance.
+	inMSISBound float64
+	// outMSISBound is the two-norm bound of the outer MSIS instance.
+	outMSISBound float64
+
 	// ringQ is the commitment ring.
 	ringQ *ring.Ring
 	// ringQOut is the outer commitment ring.
@@ -122,31 +158,88 @@ type Parameters struct {
 	pfSize float64
 }
 
-// NewParameters creates a new [Parameters].
+// NewParameters creates a new [Parameters] with 128-bit security.
+// Panics if the parameters cannot be generated.
 func NewParameters[E bignum.Uint[E]](targetN, batch int) Parameters {
+	params, err := NewParametersFromLiteral[E](ParametersLiteral{
+		TargetN: targetN,
+		Batch:   batch,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("NewParameters: %v", err))
+	}
+	return params
+}
+
+// NewParametersFromLiteral creates a new [Parameters] from the literal.
+// It returns an error if the literal is invalid,
+// or if there are no parameters satisfying the target security level.
+func NewParametersFromLiteral[E bignum.Uint[E]](lit ParametersLiteral) (Parameters, error) {
+	return newParameters(newEncodeParameters[E](), lit)
+}
+
+// NewParametersFromModulus creates a new [Parameters] from the literal,
+// for the modulus p = base^exp + 1.
+// Unlike [NewParametersFromLiteral], it does not require a field type for the modulus,
+// which is useful for exploring the parameters.
+// The resulting parameters must not be used with a field whose modulus is different from p.
+func NewParametersFromModulus(base uint64, exp int, lit ParametersLiteral) (Parameters, error) {
+	switch {
+	case base < 2:
+		return Parameters{}, fmt.Errorf("base must be >= 2")
+	case exp < 1 || exp&(exp-1) != 0:
+		return Parameters{}, fmt.Errorf("exp must be a power of two")
+	}
+
+	return newParameters(encodeParameters{base: base, exp: exp}, lit)
+}
+
+// newParameters creates a new [Parameters] for the encoding parameters ecd.
+func newParameters(ecd encodeParameters, lit ParametersLiteral) (Parameters, error) {
+	if lit.SecurityLevel == 0 {
+		lit.SecurityLevel = defaultSecurityLevel
+	}
+	if lit.TailCut == 0 {
+		lit.TailCut = defaultTailCut
+	}
+	if lit.Points == 0 {
+		lit.Points = 1
+	}
+
 	switch {
-	case targetN < 1:
-		panic("NewParameters: targetN must be >= 1")
-	case batch < 1:
-		panic("NewParameters: batch must be >= 1")
+	case lit.TargetN < 1:
+		return Parameters{}, fmt.Errorf("targetN must be >= 1")
+	case lit.Batch < 1:
+		return Parameters{}, fmt.Errorf("batch must be >= 1")
+	case lit.Points < 1:
+		return Parameters{}, fmt.Errorf("points must be >= 1")
+	case lit.SecurityLevel != 128 && lit.SecurityLevel != 192 && lit.SecurityLevel != 256:
+		return Parameters{}, fmt.Errorf("unsupported security level %v", lit.SecurityLevel)
+	case lit.TailCut < 1:
+		return Parameters{}, fmt.Errorf("tail cut must be >= 1")
 	}
 
+	targetN, batch := lit.TargetN, lit.Batch
+	level, tailCut := lit.SecurityLevel, lit.TailCut
+
 	params := Parameters{}
-	ecd := newEncodeParameters[E]()
 
 	t := float64(batch)
+	pts := float64(lit.Points)
 	b := float64(ecd.base)
 	k := float64(ecd.exp)
 	d := float64(max(k, 256))
 	l := d / k
 
-	nu := rlweRank / d
-
 	maxCols := int(math.Ceil(float64(targetN) / l))
 	minSize := math.Inf(1)
 	for nn := 1; nn <= maxCols; nn <<= 1 {
 		n := float64(nn)
 		m := math.Ceil(float64(targetN) / (n * l))
+		// The blinding rows after the first one are compensated in the second row.
+		if pts > 1 && m < 2 {
+			continue
+		}
 
 		xOne := math.Sqrt(k) * b
 		cOne := math.Sqrt(k) * min(b, math.Exp2(120/k)) / 2
@@ -160,20 +253,20 @@ func NewParameters[E bignum.Uint[E]](targetN, batch int) Parameters {
 		maskMLWEStdDev := 2 * cOne * math.Sqrt2 * eta
 
 		fijInf := tailCut * (b + 1) * ecdStdDev
-		f0jInf := tailCut * (b + 1) * math.Sqrt(m+1) * ecdBlindStdDev
+		f0jInf := tailCut * (b + 1) * math.Sqrt(m+pts) * ecdBlindStdDev
 		finInf := tailCut * (b + 1) * math.Sqrt(n+1) * maskStdDev
-		f0nInf := tailCut * (b + 1) * math.Sqrt((m+1)*n+1) * maskBlindStdDev
+		f0nInf := tailCut * (b + 1) * math.Sqrt((m+pts)*n+1) * maskBlindStdDev
 
 		resEcdiInf := math.Sqrt(n)*cOne*fijInf + finInf
 		resEcd0Inf := math.Sqrt(n)*cOne*f0jInf + f0nInf
-		prInf := math.Sqrt(m)*xOne*fijInf + f0jInf
+		prInf := math.Sqrt(m+pts-1)*xOne*fijInf + f0jInf
 		if t > 1 {
 			resEcdiInf *= math.Sqrt(t) * cOne
 			resEcd0Inf *= math.Sqrt(t) * cOne
 			prInf *= math.Sqrt(t) * cOne
 		}
 
-		resEcdTwo := math.Sqrt(d * (m*resEcdiInf*resEcdiInf + resEcd0Inf*resEcd0Inf))
+		resEcdTwo := math.Sqrt(d * ((m+pts-1)*resEcdiInf*resEcdiInf + resEcd0Inf*resEcd0Inf))
 
 		mlweInf := tailCut * mlweStdDev
 		maskMLWEInf := tailCut * math.Sqrt(n+1) * maskMLWEStdDev
@@ -182,39 +275,63 @@ func NewParameters[E bignum.Uint[E]](targetN, batch int) Parameters {
 			resMLWEInf *= math.Sqrt(t) * cOne
 		}
 
-		var q, inMSISRank, inCutOffTwo float64
+		var q, inMSISRank, nu, inMSISBeta, inCutOffTwo float64
 		var resTwo, dExtOne float64
-		for mu := 1; ; mu++ {
-			resMLWETwo := math.Sqrt(d*(float64(mu)+nu)) * resMLWEInf
-			resTwo = math.Sqrt(resEcdTwo*resEcdTwo + resMLWETwo*resMLWETwo)
-			inCutOffTwo = resTwo
-
-			var extBeta, cExtOne float64
-			if t == 1 {
-				extBeta = 2 * (resTwo + inCutOffTwo)
-				cExtOne = 2 * cOne
-				dExtOne = 1
-			} else {
-				extBeta = 2 * (2 * cOne) * (resTwo + inCutOffTwo)
-				cExtOne = (2 * cOne) * (2 * cOne)
-				dExtOne = 2 * cOne
-			}
-
-			inMSISBeta := 2 * dExtOne * cExtOne * extBeta
-			logQ := math.Ceil(math.Log2(inMSISBeta))
-			qLimbs := int(math.Ceil(logQ / 60.0))
-			qBits := int(math.Ceil(logQ / float64(qLimbs)))
-			q = math.Exp2(float64(qBits * qLimbs))
-
-			if math.Log2(q) > maxLogQ {
-				continue
-			}
-
-			if findMSISRank(d, q, inMSISBeta) == mu {
-				inMSISRank = float64(mu)
-				break
+	rankLoop:
+		for mu := 1; mu <= maxModuleRank; mu++ {
+			// The modulus grows with mu, so the MLWE rank is searched for each mu.
+			for mlweRank := 1; mlweRank <= maxModuleRank; mlweRank++ {
+				resMLWETwo := math.Sqrt(d*float64(mu+mlweRank)) * resMLWEInf
+				resTwo = math.Sqrt(resEcdTwo*resEcdTwo + resMLWETwo*resMLWETwo)
+				inCutOffTwo = resTwo
+
+				var extBeta, cExtOne float64
+				if t == 1 {
+					extBeta = 2 * (resTwo + inCutOffTwo)
+					cExtOne = 2 * cOne
+					dExtOne = 1
+				} else {
+					extBeta = 2 * (2 * cOne) * (resTwo + inCutOffTwo)
+					cExtOne = (2 * cOne) * (2 * cOne)
+					dExtOne = 2 * cOne
+				}
+
+				inMSISBeta = 2 * dExtOne * cExtOne * extBeta
+				logQ := math.Ceil(math.Log2(inMSISBeta))
+				qLimbs := int(math.Ceil(logQ / 60.0))
+				qBits := int(math.Ceil(logQ / float64(qLimbs)))
+				q = math.Exp2(float64(qBits * qLimbs))
+
+				// The primes are slightly larger than q,
+				// so MLWE is estimated with one more bit of modulus.
+				// It has only mu*d samples, but is estimated with unlimited samples
+				// as in [security.MLWE], since the few-samples estimate is not reliable.
+				mlwe := security.MLWEInstance{
+					N:      mlweRank * int(d),
+					LogQ:   math.Log2(q) + 1,
+					StdDev: mlweStdDev / math.Sqrt(2*math.Pi),
+				}
+				if !isSecure(mlwe.BlockSize(), level) {
+					continue
+				}
+
+				msis := security.MSISInstance{
+					N:     mu * int(d),
+					M:     (int(m) + lit.Points + mlweRank + 2*mu) * int(d),
+					LogQ:  math.Log2(q),
+					Bound: inMSISBeta,
+				}
+				if isSecure(msis.BlockSize(), level) {
+					inMSISRank = float64(mu)
+					nu = float64(mlweRank)
+					break rankLoop
+				}
+				continue rankLoop
 			}
 		}
+		if inMSISRank == 0 {
+			continue
+		}
 
 		inCutOffInf := inCutOffTwo / ((1 + math.Sqrt(n)*cOne) * math.Sqrt(inMSISRank*d))
 		if t > 1 {
@@ -235,10 +352,22 @@ func NewParameters[E bignum.Uint[E]](targetN, batch int) Parameters {
 		qqLimbs := int(math.Ceil(logQQ / 60.0))
 		qqBits := int(math.Ceil(logQQ / float64(qqLimbs)))
 		qq := math.Exp2(float64(qqBits * qqLimbs))
-		if math.Log2(qq) > maxLogQ {
+		var outMSISRank float64
+		for mu := 1; mu <= maxModuleRank; mu++ {
+			msis := security.MSISInstance{
+				N:     mu * int(d),
+				M:     (int((n+1)*inMSISRank) + mu) * int(d),
+				LogQ:  math.Log2(qq),
+				Bound: outMSISBeta,
+			}
+			if isSecure(msis.BlockSize(), level) {
+				outMSISRank = float64(mu)
+				break
+			}
+		}
+		if outMSISRank == 0 {
 			continue
 		}
-		outMSISRank := float64(findMSISRank(d, qq, outMSISBeta))
 
 		outCutOffInf := outCutOffTwo / (math.Sqrt(outMSISRank * d))
 		if t > 1 {
@@ -250,31 +379,38 @@ func NewParameters[E bignum.Uint[E]](targetN, batch int) Parameters {
 		var pfSize float64
 		pfSize += n * d * math.Log2(prInf)                          // Partial
 		pfSize += d * math.Log2(q)                                  // Partial * Mask
-		pfSize += m * d * math.Log2(resEcdiInf)                     // Response 1 ~ m
+		pfSize += (m + pts - 1) * d * math.Log2(resEcdiInf)         // Response 1 ~ m+pts-1
 		pfSize += d * math.Log2(resEcd0Inf)                         // Response 0
 		pfSize += (inMSISRank + nu) * d * math.Log2(resMLWEInf)     // Response MLWE
 		pfSize += ((n + 1) * inMSISRank * d) * math.Log2(inDcmpInf) // Inner Commitments
 
 		if comSize+pfSize < minSize {
-			minSize = comSize + pfSize
+			var cand Parameters
 
-			params.batch = batch
+			cand.batch = batch
+			cand.points = lit.Points
 
-			params.rank = int(n) * int(m) * int(l)
-			params.rows = int(m) + 1
-			params.cols = int(n)
+			cand.securityLevel = lit.SecurityLevel
+			cand.tailCut = tailCut
 
-			params.ecd = ecd
-			params.slots = int(d) / ecd.exp
+			cand.rank = int(n) * int(m) * int(l)
+			cand.rows = int(m) + lit.Points
+			cand.cols = int(n)
 
-			params.inMSISRank = int(inMSISRank)
-			params.outMSISRank = int(outMSISRank)
-			params.mlweRank = int(nu)
+			cand.ecd = ecd
+			cand.slots = int(d) / ecd.exp
 
-			params.logInCutOff = uint64(math.Floor(math.Log2(inCutOffInf)))
-			params.logOutCutOff = uint64(math.Floor(math.Log2(outCutOffInf)))
+			cand.inMSISRank = int(inMSISRank)
+			cand.outMSISRank = int(outMSISRank)
+			cand.mlweRank = int(nu)
 
-			params.inComDcmpLen = int((n + 1) * inMSISRank)
+			cand.logInCutOff = uint64(math.Floor(math.Log2(inCutOffInf)))
+			cand.logOutCutOff = uint64(math.Floor(math.Log2(outCutOffInf)))
+
+			cand.inComDcmpLen = int((n + 1) * inMSISRank)
+
+			cand.inMSISBound = inMSISBeta
+			cand.outMSISBound = outMSISBeta
 
 			qLimbs := int(math.Ceil(math.Log2(q) / 60))
 			qBits := int(math.Ceil(math.Log2(q) / float64(qLimbs)))
@@ -283,7 +419,7 @@ func NewParameters[E bignum.Uint[E]](targetN, batch int) Parameters {
 			if err != nil {
 				continue
 			}
-			params.ringQ, err = ring.NewRing(int(d), q)
+			cand.ringQ, err = ring.NewRing(int(d), q)
 			if err != nil {
 				panic(err)
 			}
@@ -295,28 +431,40 @@ func NewParameters[E bignum.Uint[E]](targetN, batch int) Parameters {
 			if err != nil {
 				continue
 			}
-			params.ringQOut, err = ring.NewRing(int(d), qq)
+			cand.ringQOut, err = ring.NewRing(int(d), qq)
 			if err != nil {
 				panic(err)
 			}
 
-			params.ecdStdDev = ecdStdDev / math.Sqrt(2*math.Pi)
-			params.ecdBlindStdDev = ecdBlindStdDev / math.Sqrt(2*math.Pi)
-			params.maskStdDev = maskStdDev / math.Sqrt(2*math.Pi)
-			params.maskBlindStdDev = maskBlindStdDev / math.Sqrt(2*math.Pi)
+			cand.ecdStdDev = ecdStdDev / math.Sqrt(2*math.Pi)
+			cand.ecdBlindStdDev = ecdBlindStdDev / math.Sqrt(2*math.Pi)
+			cand.maskStdDev = maskStdDev / math.Sqrt(2*math.Pi)
+			cand.maskBlindStdDev = maskBlindStdDev / math.Sqrt(2*math.Pi)
 
-			params.mlweStdDev = mlweStdDev / math.Sqrt(2*math.Pi)
-			params.maskMLWEStdDev = maskMLWEStdDev / math.Sqrt(2*math.Pi)
+			cand.mlweStdDev = mlweStdDev / math.Sqrt(2*math.Pi)
+			cand.maskMLWEStdDev = maskMLWEStdDev / math.Sqrt(2*math.Pi)
 
-			params.resTwoNm = resTwo + inCutOffTwo
-			params.inComDcmpTwoNm = inDcmpTwo + outCutOffTwo
+			cand.resTwoNm = resTwo + inCutOffTwo
+			cand.inComDcmpTwoNm = inDcmpTwo + outCutOffTwo
 
-			params.comSize = comSize
-			params.pfSize = pfSize
+			cand.comSize = comSize
+			cand.pfSize = pfSize
+
+			// Rounding the moduli to primes must not drop below the security level.
+			if security.EstimateParameters(cand, security.CoreSVPClassical).Bits < float64(level) {
+				continue
+			}
+
+			minSize = comSize + pfSize
+			params = cand
 		}
 	}
 
-	return params
+	if params.ringQ == nil || params.ringQOut == nil {
+		return Parameters{}, fmt.Errorf("no parameters found for security level %v", lit.SecurityLevel)
+	}
+
+	return params, nil
 }
 
 // Batch is the number of polynomials to be committed.
@@ -324,6 +472,11 @@ func (p Parameters) Batch() int {
 	return p.batch
 }
 
+// Points is the number of points at which a commitment can be opened.
+func (p Parameters) Points() int {
+	return p.points
+}
+
 // Rank is the number of cofficients in the committing polynomial.
 func (p Parameters) Rank() int {
 	return p.rank
@@ -359,6 +512,21 @@ func (p Parameters) ChallengeBound() uint64 {
 	return min(p.ecd.base, 1<<(120/p.ecd.exp)) / 2
 }
 
+// LogChallengeSpace is the log2 of the size of the challenge space.
+func (p Parameters) LogChallengeSpace() float64 {
+	return float64(p.ecd.exp) * math.Log2(float64(p.ChallengeBound()))
+}
+
+// SecurityLevel is the target security level of the MSIS and MLWE instances in bits.
+func (p Parameters) SecurityLevel() int {
+	return p.securityLevel
+}
+
+// TailCut is the tail cut bound for Gaussian distribution.
+func (p Parameters) TailCut() float64 {
+	return p.tailCut
+}
+
 // InMSISRank is the rank of the inner MSIS commitment.
 func (p Parameters) InMSISRank() int {
 	return p.inMSISRank
@@ -384,6 +552,16 @@ func (p Parameters) OutCutOff() uint64 {
 	return p.logOutCutOff
 }
 
+// InMSISBound is the two-norm bound of the inner MSIS instance.
+func (p Parameters) InMSISBound() float64 {
+	return p.inMSISBound
+}
+
+// OutMSISBound is the two-norm bound of the outer MSIS instance.
+func (p Parameters) OutMSISBound() float64 {
+	return p.outMSISBound
+}
+
 // InCommitDecomposeLen is the length of the decomposed inner commitment.
 func (p Parameters) InCommitDecomposeLen() int {
 	return p.inComDcmpLen
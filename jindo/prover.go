@@ -1,13 +1,16 @@
 package jindo
 
 import (
-	"crypto/sha3"
+	"context"
 	"fmt"
 	"math/big"
+	"slices"
 
 	"github.com/sp301415/ringo-snark/math/bignum"
 	"github.com/sp301415/ringo-snark/math/bigpoly"
 	"github.com/sp301415/ringo-snark/math/csprng"
+	"github.com/sp301415/ringo-snark/profile"
+	"github.com/sp301415/ringo-snark/transcript"
 	"github.com/tuneinsight/lattigo/v6/ring"
 )
 
@@ -22,16 +25,29 @@ type Prover[E bignum.Uint[E]] struct {
 	uniformSampler *csprng.UniformSampler
 	roundedSampler *csprng.RoundedGaussianSampler
 	mlweSampler    *csprng.TwinCDTGaussianSampler
+
+	prof *profile.Profiler
 }
 
 // NewProver creates a new [Prover].
+// The commit key is shared with other provers and verifiers with the same params and crs.
 func NewProver[E bignum.Uint[E]](params Parameters, crs []byte) *Prover[E] {
+	return NewProverWithKey[E](params, GetCommitKey(params, crs))
+}
+
+// NewProverWithKey creates a new [Prover] with the given commit key.
+// Panics if ck is not a key for params.
+func NewProverWithKey[E bignum.Uint[E]](params Parameters, ck *CommitKey) *Prover[E] {
+	if !ck.matches(params) {
+		panic("commit key does not match parameters")
+	}
+
 	return &Prover[E]{
 		params: params,
 		ecd:    newEncoder[E](params),
 		rnsOut: newRNSReconstructor(params.ringQOut),
 
-		ck: NewCommitKey(params, crs),
+		ck: ck,
 
 		uniformSampler: csprng.NewUniformSampler(),
 		roundedSampler: csprng.NewRoundedGaussianSampler(),
@@ -39,83 +55,196 @@ func NewProver[E bignum.Uint[E]](params Parameters, crs []byte) *Prover[E] {
 	}
 }
 
+// Parameters returns the parameters of the prover.
+func (p *Prover[E]) Parameters() Parameters {
+	return p.params
+}
+
+// CommitKey returns the commit key of the prover.
+func (p *Prover[E]) CommitKey() *CommitKey {
+	return p.ck
+}
+
+// SetSeed makes the prover deterministic by drawing all of its randomness from seed,
+// so that the same sequence of calls with the same inputs gives bit-identical outputs.
+// If seed is nil, the prover draws its randomness from crypto/rand again.
+// Copies made by [Prover.SafeCopy] are not seeded.
+//
+// The commitments are hiding only if seed is secret and never reused for different inputs.
+//
+// All calls draw from one stream, so an output depends on the calls made before it.
+// A seeded prover must not be shared between goroutines.
+func (p *Prover[E]) SetSeed(seed []byte) {
+	if seed == nil {
+		p.uniformSampler = csprng.NewUniformSampler()
+		p.roundedSampler = csprng.NewRoundedGaussianSampler()
+		p.mlweSampler = p.mlweSampler.SafeCopy()
+	} else {
+		p.uniformSampler = csprng.NewUniformSamplerWithSeed(csprng.DeriveSeed(seed, "jindo.Uniform"))
+		p.roundedSampler = csprng.NewRoundedGaussianSamplerWithSeed(csprng.DeriveSeed(seed, "jindo.Rounded"))
+		p.mlweSampler = p.mlweSampler.SafeCopyWithSeed(csprng.DeriveSeed(seed, "jindo.MLWE"))
+	}
+	p.ecd.setSeed(seed)
+}
+
+// SetProfiler sets the profiler which records the measurements of Commit and Evaluate.
+// If prof is nil, profiling is disabled.
+func (p *Prover[E]) SetProfiler(prof *profile.Profiler) {
+	p.prof = prof
+	p.ecd.prof = prof
+	p.ecd.rns.prof = prof
+	p.rnsOut.prof = prof
+}
+
 // Commit commits v.
 // Panics if len(v) > params.rank.
 // Otherwise, it pads zero.
 func (p *Prover[E]) Commit(v []E) (*Commitment, *Opening) {
+	com, open, _ := p.CommitContext(context.Background(), v)
+	return com, open
+}
+
+// CommitContext commits v.
+// It checks ctx for cancellation between columns,
+// and returns ctx.Err() if ctx is done before the commitment is computed.
+// Panics if len(v) > params.rank.
+// Otherwise, it pads zero.
+func (p *Prover[E]) CommitContext(ctx context.Context, v []E) (*Commitment, *Opening, error) {
 	switch {
 	case len(v) > p.params.rank:
 		panic("len(v) > params.rank")
 	}
 
+	defer p.prof.Begin("jindo.Commit").End()
+
 	com := NewCommitment(p.params)
 	open := NewOpening(p.params)
 
-	firstRow, lastRow := p.genFirstLastRow(v)
+	firstRow, secondRow, blindRows := p.genBlindRows(v)
 	for i := range p.params.cols + 1 {
-		p.commitColTo(i, open, v, firstRow, lastRow)
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		p.commitColTo(i, open, v, firstRow, secondRow, blindRows)
 	}
 
 	p.outerCommitTo(com, open)
 
-	return com, open
+	return com, open, nil
 }
 
-// genFirstLastRow generates the first and last row for committing v.
-func (p *Prover[E]) genFirstLastRow(v []E) (firstRow, lastRow []E) {
+// CommitPublic commits v without hiding.
+// It samples no randomness, so the commitment is a deterministic function of v
+// and the verifier can recompute it by [Verifier.CommitPublic].
+// This is intended for public polynomials, such as preprocessed selectors or tables.
+//
+// It uses the same params and commit key as [Prover.Commit], with all randomness set to zero:
+// the MLWE term, the blinding rows and the mask column are zero,
+// so committing skips their encodings and products.
+// Since it shares params, it can be evaluated together with hiding commitments in a batch,
+// but the evaluation reveals v if every commitment in the batch is public.
+// It is not smaller or cheaper to open than a hiding commitment.
+// Panics if len(v) > params.rank.
+// Otherwise, it pads zero.
+func (p *Prover[E]) CommitPublic(v []E) (*Commitment, *Opening) {
+	defer p.prof.Begin("jindo.CommitPublic").End()
+
+	return commitPublic(p.params, p.ecd, p.rnsOut, p.ck, v, p.prof)
+}
+
+// genBlindRows generates the blinding rows for committing v,
+// and the first and second rows of the matrix, which compensate them.
+// The k-th blinding row, counting from one, is weighted by x^k in the evaluation,
+// so it is subtracted from the first row shifted by k entries,
+// and the entries shifted past the first row are subtracted from the second row.
+// With a single blinding row, its last entry is zero, so the second row is v as it is and secondRow is nil.
+func (p *Prover[E]) genBlindRows(v []E) (firstRow, secondRow []E, blindRows [][]E) {
 	var z E
 
-	lastRow = make([]E, p.params.cols*p.params.slots)
-	for i := 0; i < p.params.cols*p.params.slots-1; i++ {
-		lastRow[i] = z.New().MustSetRandom()
+	rowLen := p.params.cols * p.params.slots
+
+	blindRows = make([][]E, p.params.points)
+	for k := range blindRows {
+		blindRows[k] = make([]E, rowLen)
+		for i := range rowLen {
+			if p.params.points == 1 && i == rowLen-1 {
+				blindRows[k][i] = z.New()
+				continue
+			}
+			blindRows[k][i] = bignum.MustSetRandomFrom(z.New(), p.uniformSampler)
+		}
 	}
-	lastRow[p.params.cols*p.params.slots-1] = z.New()
 
-	firstRow = make([]E, p.params.cols*p.params.slots)
-	firstRow[0] = z.New().Set(v[0])
-	for i := 1; i < p.params.cols*p.params.slots; i++ {
+	firstRow = make([]E, rowLen)
+	for i := range rowLen {
 		firstRow[i] = z.New()
 		if i < len(v) {
-			firstRow[i].Sub(v[i], lastRow[i-1])
-		} else {
-			firstRow[i].Sub(firstRow[i], lastRow[i-1])
+			firstRow[i].Set(v[i])
+		}
+	}
+
+	if p.params.points > 1 {
+		secondRow = make([]E, rowLen)
+		for i := range rowLen {
+			secondRow[i] = z.New()
+			if rowLen+i < len(v) {
+				secondRow[i].Set(v[rowLen+i])
+			}
+		}
+	}
+
+	for k := range blindRows {
+		for i := range rowLen {
+			if j := i + k + 1; j < rowLen {
+				firstRow[j].Sub(firstRow[j], blindRows[k][i])
+			} else if secondRow != nil {
+				secondRow[j-rowLen].Sub(secondRow[j-rowLen], blindRows[k][i])
+			}
 		}
 	}
 
-	return firstRow, lastRow
+	return firstRow, secondRow, blindRows
 }
 
 // commitColTo commits the i-th column.
-func (p *Prover[E]) commitColTo(i int, open *Opening, v []E, firstRow, lastRow []E) {
+func (p *Prover[E]) commitColTo(i int, open *Opening, v []E, firstRow, secondRow []E, blindRows [][]E) {
 	rowStart := i * p.params.slots
 	rowEnd := (i + 1) * p.params.slots
+	dataRows := p.params.rows - p.params.points
 
 	if i == p.params.cols {
 		var z E
 		mask := make([]E, p.params.slots)
 		for j := range mask {
-			mask[j] = z.New().MustSetRandom()
+			mask[j] = bignum.MustSetRandomFrom(z.New(), p.uniformSampler)
 		}
 		p.ecd.randEncodeTo(open.Encode[i][0], mask, p.params.maskBlindStdDev)
 
-		for j := 1; j < p.params.rows-1; j++ {
+		for j := 1; j < dataRows; j++ {
 			idxStart := j * p.params.cols * p.params.slots
-			if idxStart > len(v) {
+			if idxStart > len(v) && (j != 1 || secondRow == nil) {
 				break
 			}
 			for k := range mask {
-				mask[k].MustSetRandom()
+				bignum.MustSetRandomFrom(mask[k], p.uniformSampler)
 			}
 			p.ecd.randEncodeTo(open.Encode[i][j], mask, p.params.maskStdDev)
 		}
 
-		for k := range mask {
-			mask[k].MustSetRandom()
+		for j := dataRows; j < p.params.rows; j++ {
+			for k := range mask {
+				bignum.MustSetRandomFrom(mask[k], p.uniformSampler)
+			}
+			p.ecd.randEncodeTo(open.Encode[i][j], mask, p.params.maskStdDev)
 		}
-		p.ecd.randEncodeTo(open.Encode[i][p.params.rows-1], mask, p.params.maskStdDev)
 	} else {
 		p.ecd.randEncodeTo(open.Encode[i][0], firstRow[rowStart:rowEnd], p.params.ecdBlindStdDev)
-		for j := 1; j < p.params.rows-1; j++ {
+		for j := 1; j < dataRows; j++ {
+			if j == 1 && secondRow != nil {
+				p.ecd.randEncodeTo(open.Encode[i][j], secondRow[rowStart:rowEnd], p.params.ecdStdDev)
+				continue
+			}
+
 			idxStart := (j * p.params.cols * p.params.slots) + rowStart
 			idxEnd := (j * p.params.cols * p.params.slots) + rowEnd
 			if idxStart > len(v) {
@@ -124,7 +253,9 @@ func (p *Prover[E]) commitColTo(i int, open *Opening, v []E, firstRow, lastRow [
 			p.ecd.randEncodeTo(open.Encode[i][j], v[idxStart:min(idxEnd, len(v))], p.params.ecdStdDev)
 		}
 
-		p.ecd.randEncodeTo(open.Encode[i][p.params.rows-1], lastRow[rowStart:rowEnd], p.params.ecdStdDev)
+		for j := dataRows; j < p.params.rows; j++ {
+			p.ecd.randEncodeTo(open.Encode[i][j], blindRows[j-dataRows][rowStart:rowEnd], p.params.ecdStdDev)
+		}
 	}
 
 	for j := range p.params.inMSISRank + p.params.mlweRank {
@@ -140,69 +271,93 @@ func (p *Prover[E]) commitColTo(i int, open *Opening, v []E, firstRow, lastRow [
 		p.params.ringQ.MForm(open.MLWE[i][j], open.MLWE[i][j])
 		p.params.ringQ.NTT(open.MLWE[i][j], open.MLWE[i][j])
 	}
+	p.prof.Count(profile.OpGaussianSample, (p.params.inMSISRank+p.params.mlweRank)*p.params.ringQ.N())
+	p.prof.Count(profile.OpNTT, p.params.inMSISRank+p.params.mlweRank)
 
+	p.innerCommitTo(i, open)
+}
+
+// innerCommitTo computes the inner commitment of the i-th column.
+func (p *Prover[E]) innerCommitTo(i int, open *Opening) {
 	com := make([]ring.Poly, p.params.inMSISRank)
 	for j := range p.params.inMSISRank {
 		com[j] = p.params.ringQ.NewPoly()
 	}
 
+	ckBuf := p.params.ringQ.NewPoly()
 	for j := range p.params.inMSISRank {
 		for k := range p.params.rows {
-			p.params.ringQ.MulCoeffsMontgomeryThenAdd(p.ck.In[j][k], open.Encode[i][k], com[j])
+			p.params.ringQ.MulCoeffsMontgomeryThenAdd(p.ck.in(j, k, ckBuf), open.Encode[i][k], com[j])
 		}
 		for k := range p.params.mlweRank {
-			p.params.ringQ.MulCoeffsMontgomeryThenAdd(p.ck.MLWE[j][k], open.MLWE[i][k], com[j])
+			p.params.ringQ.MulCoeffsMontgomeryThenAdd(p.ck.mlwe(j, k, ckBuf), open.MLWE[i][k], com[j])
 		}
 		p.params.ringQ.Add(open.MLWE[i][p.params.mlweRank+j], com[j], com[j])
 	}
+	p.prof.Count(profile.OpRingMul, p.params.inMSISRank*(p.params.rows+p.params.mlweRank))
+
+	roundInCommitTo(p.params, p.ecd.rns, p.rnsOut, open.InCommit[i*p.params.inMSISRank:(i+1)*p.params.inMSISRank], com, p.prof)
+}
 
-	inComBig := make([]*big.Int, p.params.ringQ.N())
+// roundInCommitTo rounds the inner commitment com of a column to inComOut.
+// com is consumed.
+func roundInCommitTo(params Parameters, rns, rnsOut *RNSReconstructor, inComOut, com []ring.Poly, prof *profile.Profiler) {
+	inComBig := make([]*big.Int, params.ringQ.N())
 	for j := range inComBig {
 		inComBig[j] = new(big.Int)
 	}
 
-	for j := range p.params.inMSISRank {
-		p.params.ringQ.IMForm(com[j], com[j])
-		p.params.ringQ.INTT(com[j], com[j])
+	for j := range com {
+		params.ringQ.IMForm(com[j], com[j])
+		params.ringQ.INTT(com[j], com[j])
 
-		p.ecd.rns.reconstructTo(inComBig, com[j])
-		for k := range p.params.ringQ.N() {
-			inComBig[k].Rsh(inComBig[k], uint(p.params.logInCutOff))
+		rns.reconstructTo(inComBig, com[j])
+		for k := range params.ringQ.N() {
+			inComBig[k].Rsh(inComBig[k], uint(params.logInCutOff))
 		}
-		p.rnsOut.setBigCoeffTo(open.InCommit[i*p.params.inMSISRank+j], inComBig)
+		rnsOut.setBigCoeffTo(inComOut[j], inComBig)
 
-		p.params.ringQOut.MForm(open.InCommit[i*p.params.inMSISRank+j], open.InCommit[i*p.params.inMSISRank+j])
-		p.params.ringQOut.NTT(open.InCommit[i*p.params.inMSISRank+j], open.InCommit[i*p.params.inMSISRank+j])
+		params.ringQOut.MForm(inComOut[j], inComOut[j])
+		params.ringQOut.NTT(inComOut[j], inComOut[j])
 	}
+	prof.Count(profile.OpNTT, 2*len(com))
 }
 
 // outerCommitTo computes the outer commitment.
 func (p *Prover[E]) outerCommitTo(com *Commitment, open *Opening) {
-	comBig := make([]*big.Int, p.params.ringQOut.N())
+	outerCommitTo(p.params, p.ck, p.rnsOut, com, open, p.prof)
+}
+
+// outerCommitTo computes the outer commitment of open to com.
+func outerCommitTo(params Parameters, ck *CommitKey, rnsOut *RNSReconstructor, com *Commitment, open *Opening, prof *profile.Profiler) {
+	comBig := make([]*big.Int, params.ringQOut.N())
 	for i := range comBig {
 		comBig[i] = new(big.Int)
 	}
 
-	for i := range p.params.outMSISRank {
-		for j := range p.params.inComDcmpLen {
-			p.params.ringQOut.MulCoeffsMontgomeryThenAdd(p.ck.Out[i][j], open.InCommit[j], com.Value[i])
+	ckBuf := params.ringQOut.NewPoly()
+	for i := range params.outMSISRank {
+		for j := range params.inComDcmpLen {
+			params.ringQOut.MulCoeffsMontgomeryThenAdd(ck.out(i, j, ckBuf), open.InCommit[j], com.Value[i])
 		}
-		p.params.ringQOut.IMForm(com.Value[i], com.Value[i])
-		p.params.ringQOut.INTT(com.Value[i], com.Value[i])
+		params.ringQOut.IMForm(com.Value[i], com.Value[i])
+		params.ringQOut.INTT(com.Value[i], com.Value[i])
 
-		p.rnsOut.reconstructTo(comBig, com.Value[i])
-		for k := range p.params.ringQOut.N() {
-			comBig[k].Rsh(comBig[k], uint(p.params.logOutCutOff))
+		rnsOut.reconstructTo(comBig, com.Value[i])
+		for k := range params.ringQOut.N() {
+			comBig[k].Rsh(comBig[k], uint(params.logOutCutOff))
 		}
-		p.rnsOut.setBigCoeffTo(com.Value[i], comBig)
+		rnsOut.setBigCoeffTo(com.Value[i], comBig)
 
-		p.params.ringQOut.MForm(com.Value[i], com.Value[i])
-		p.params.ringQOut.NTT(com.Value[i], com.Value[i])
+		params.ringQOut.MForm(com.Value[i], com.Value[i])
+		params.ringQOut.NTT(com.Value[i], com.Value[i])
 	}
+	prof.Count(profile.OpRingMul, params.outMSISRank*params.inComDcmpLen)
+	prof.Count(profile.OpNTT, 2*params.outMSISRank)
 }
 
-// Evaluate batch evaluates v at x using batch randomness batch and returns the result with proof.
-func (p *Prover[E]) Evaluate(x E, v [][]E, com []*Commitment, open []*Opening) ([]E, *Proof) {
+// checkEvaluate panics if v, com and open are not valid inputs for evaluation.
+func (p *Prover[E]) checkEvaluate(v [][]E, com []*Commitment, open []*Opening) {
 	switch {
 	case len(v) != len(com) || len(v) != len(open):
 		panic("len(v), len(com), len(open) are not equal")
@@ -216,90 +371,212 @@ func (p *Prover[E]) Evaluate(x E, v [][]E, com []*Commitment, open []*Opening) (
 			panic(fmt.Sprintf("len(v[%v]) > params.rank", i))
 		}
 	}
+}
 
-	oracle := sha3.NewSHAKE128()
-	p.ck.WriteRawTo(oracle)
-	for i := range p.params.batch {
-		com[i].WriteRawTo(oracle)
+// Evaluate batch evaluates v at x using batch randomness batch and returns the result with proof.
+func (p *Prover[E]) Evaluate(x E, v [][]E, com []*Commitment, open []*Opening) ([]E, *Proof) {
+	p.checkEvaluate(v, com, open)
+
+	defer p.prof.Begin("jindo.Evaluate").End()
+
+	oracle := transcript.NewSHAKE128()
+	appendStatement(oracle, p.ck, com, []E{x}, nil)
+	y, pf, err := p.evaluate(oracle, []E{x}, v, com, open)
+	if err != nil {
+		panic(err)
+	}
+	return y[0], pf
+}
+
+// EvaluateWithTranscript is the same as [Prover.Evaluate],
+// but draws the challenges from oracle instead of the default SHAKE128 transcript.
+// It returns an error if oracle fails.
+// The proof can be verified using [Verifier.VerifyWithTranscript] with the matching transcript.
+func (p *Prover[E]) EvaluateWithTranscript(x E, v [][]E, com []*Commitment, open []*Opening, oracle transcript.Transcript) ([]E, *Proof, error) {
+	p.checkEvaluate(v, com, open)
+
+	defer p.prof.Begin("jindo.Evaluate").End()
+
+	if err := appendStatement(oracle, p.ck, com, []E{x}, nil); err != nil {
+		return nil, nil, err
+	}
+	y, pf, err := p.evaluate(oracle, []E{x}, v, com, open)
+	if err != nil {
+		return nil, nil, err
+	}
+	return y[0], pf, nil
+}
+
+// ContinueEvaluate is the same as [Prover.EvaluateWithTranscript],
+// but continues oracle without binding the commit key, com and x again.
+// This is used when x is a challenge drawn from the transcript of an outer protocol,
+// which already binds the common reference string and com before x,
+// so that every challenge depends on the whole history.
+// The proof can be verified using [Verifier.ContinueVerify] with the matching transcript.
+func (p *Prover[E]) ContinueEvaluate(x E, v [][]E, com []*Commitment, open []*Opening, oracle transcript.Transcript) ([]E, *Proof, error) {
+	p.checkEvaluate(v, com, open)
+
+	defer p.prof.Begin("jindo.Evaluate").End()
+
+	y, pf, err := p.evaluate(oracle, []E{x}, v, com, open)
+	if err != nil {
+		return nil, nil, err
+	}
+	return y[0], pf, nil
+}
+
+// EvaluateMulti evaluates v at multiple points and returns the results with a [MultiProof].
+// For each k, the polynomials v[i] for i in which[k] are evaluated at points[k],
+// and the result y satisfies y[k][l] = v[which[k][l]](points[k]).
+//
+// Each polynomial can be evaluated at up to params.Points() points,
+// such as x and a shifted point ω·x.
+// The polynomials evaluated at the same set of points form a class,
+// which is opened once at all of its points:
+// the partial evaluations at each point are combined by a random challenge,
+// so that a single response proves all of them.
+// Different classes are batched with different constants,
+// so the [MultiProof] contains one proof per class, bound to a common statement.
+func (p *Prover[E]) EvaluateMulti(points []E, which [][]int, v [][]E, com []*Commitment, open []*Opening) ([][]E, *MultiProof) {
+	p.checkEvaluate(v, com, open)
+	checkWhich(p.params, points, which)
+
+	defer p.prof.Begin("jindo.EvaluateMulti").End()
+
+	stmt := multiStatement(p.ck, com, points, which)
+
+	y := make([][]E, len(points))
+	for k := range points {
+		y[k] = make([]E, len(which[k]))
+	}
+
+	members, classPoints := multiClasses(p.params.batch, which)
+	pf := &MultiProof{Proofs: make([]*Proof, len(members))}
+	for c := range members {
+		vSub := make([][]E, len(members[c]))
+		comSub := make([]*Commitment, len(members[c]))
+		openSub := make([]*Opening, len(members[c]))
+		for l, i := range members[c] {
+			vSub[l], comSub[l], openSub[l] = v[i], com[i], open[i]
+		}
+		xs := make([]E, len(classPoints[c]))
+		for s, k := range classPoints[c] {
+			xs[s] = points[k]
+		}
+
+		oracle := transcript.NewSHAKE128()
+		appendStatement(oracle, p.ck, comSub, xs, subStatement(stmt, c))
+		ySub, pfSub, err := p.evaluate(oracle, xs, vSub, comSub, openSub)
+		if err != nil {
+			panic(err)
+		}
+		pf.Proofs[c] = pfSub
+
+		for s, k := range classPoints[c] {
+			for l, i := range members[c] {
+				y[k][slices.Index(which[k], i)] = ySub[s][l]
+			}
+		}
 	}
-	oracle.Write(x.Marshal())
+
+	return y, pf
+}
+
+// evaluate batch evaluates v at each point of xs and returns the results with proof,
+// drawing the challenges from oracle, to which the statement is already bound.
+// The batch size is len(v), which is at most params.batch,
+// and the s-th result holds the evaluations at xs[s].
+//
+// At multiple points, the partial evaluations at each point are sent first,
+// and the partial evaluation of the mask is taken along the left vectors combined by the point challenges,
+// so that the response is shared by all points.
+func (p *Prover[E]) evaluate(oracle transcript.Transcript, xs []E, v [][]E, com []*Commitment, open []*Opening) ([][]E, *Proof, error) {
+	batchSize := len(v)
 
 	var batchOut, batch []ring.Poly
 
 	var openBatch *Opening
-	if p.params.batch > 1 {
-		batch = make([]ring.Poly, p.params.batch)
-		batchOut = make([]ring.Poly, p.params.batch)
-		batchBytes := make([]byte, p.params.batch*16)
-		for i := range p.params.batch {
-			oracle.Read(batchBytes[i*16 : (i+1)*16])
+	if batchSize > 1 {
+		batch = make([]ring.Poly, batchSize)
+		batchOut = make([]ring.Poly, batchSize)
+		batchBytes, err := oracle.Challenge(batchConstLabel, batchSize*16)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range batchSize {
 			batch[i] = p.params.ringQ.NewPoly()
 			encodeChallengeTo(p.params, p.params.ringQ, batch[i], batchBytes[i*16:(i+1)*16])
 			batchOut[i] = p.params.ringQOut.NewPoly()
 			encodeChallengeTo(p.params, p.params.ringQOut, batchOut[i], batchBytes[i*16:(i+1)*16])
 		}
-		oracle.Reset()
-
-		p.ck.WriteRawTo(oracle)
-		for i := range p.params.batch {
-			com[i].WriteRawTo(oracle)
-		}
-		oracle.Write(x.Marshal())
-		oracle.Write(batchBytes)
+		p.prof.Count(profile.OpNTT, 2*batchSize)
 
 		openBatch = NewOpening(p.params)
-		for i := range p.params.batch {
-			for j := range open[i].InCommit {
-				p.params.ringQOut.MulCoeffsMontgomeryThenAdd(open[i].InCommit[j], batchOut[i], openBatch.InCommit[j])
-			}
-			for j := range open[i].Encode {
-				for k := range open[i].Encode[j] {
-					p.params.ringQ.MulCoeffsMontgomeryThenAdd(open[i].Encode[j][k], batch[i], openBatch.Encode[j][k])
-				}
-			}
-			for j := range open[i].MLWE {
-				for k := range open[i].MLWE[j] {
-					p.params.ringQ.MulCoeffsMontgomeryThenAdd(open[i].MLWE[j][k], batch[i], openBatch.MLWE[j][k])
-				}
-			}
-		}
+		combineOpeningsTo(p.params, openBatch, batch, batchOut, open)
+		p.prof.Count(profile.OpRingMul, batchSize*(len(openBatch.InCommit)+(p.params.cols+1)*(p.params.rows+p.params.inMSISRank+p.params.mlweRank)))
 	} else {
 		openBatch = open[0]
 	}
 
-	pf := NewProof(p.params)
+	pf := newProof(p.params, len(xs))
 	for i := range openBatch.InCommit {
 		pf.InCommit[i].Copy(openBatch.InCommit[i])
 	}
 
-	leftE := leftVec(p.params, x)
-	left := make([]ring.Poly, p.params.rows)
-	for i := range left {
-		left[i] = p.ecd.encode([]E{leftE[i]})
+	left := make([][]ring.Poly, len(xs))
+	for s, x := range xs {
+		leftE := leftVec(p.params, x)
+		left[s] = make([]ring.Poly, p.params.rows)
+		for j := range left[s] {
+			left[s][j] = p.ecd.encode([]E{leftE[j]})
+		}
+
+		for i := range p.params.cols {
+			for j := range p.params.rows {
+				p.params.ringQ.MulCoeffsMontgomeryThenAdd(left[s][j], openBatch.Encode[i][j], pf.Partial[s*p.params.cols+i])
+			}
+		}
 	}
+	p.prof.Count(profile.OpRingMul, len(xs)*p.params.cols*p.params.rows)
 
-	for i := range p.params.cols {
-		for j := range p.params.rows {
-			p.params.ringQ.MulCoeffsMontgomeryThenAdd(left[j], openBatch.Encode[i][j], pf.Partial[i])
+	leftMask := left[0]
+	if len(xs) > 1 {
+		gamma, err := readPointChallenges(oracle, pf, len(xs))
+		if err != nil {
+			return nil, nil, err
+		}
+		leftMask = make([]ring.Poly, p.params.rows)
+		for j := range leftMask {
+			leftMask[j] = p.params.ringQ.NewPoly()
+			for s := range xs {
+				p.params.ringQ.MulScalarBigintThenAdd(left[s][j], gamma[s], leftMask[j])
+			}
 		}
 	}
 
 	for j := range p.params.rows {
-		p.params.ringQ.MulCoeffsMontgomeryThenAdd(left[j], openBatch.Encode[p.params.cols][j], pf.PartialMask)
+		p.params.ringQ.MulCoeffsMontgomeryThenAdd(leftMask[j], openBatch.Encode[p.params.cols][j], pf.PartialMask)
 	}
+	p.prof.Count(profile.OpRingMul, p.params.rows)
 
-	for i := range pf.Partial {
-		pf.Partial[i].WriteTo(oracle)
+	if len(xs) > 1 {
+		if err := appendPartialMask(oracle, pf); err != nil {
+			return nil, nil, err
+		}
+	} else if err := appendPartial(oracle, pf); err != nil {
+		return nil, nil, err
 	}
-	pf.PartialMask.WriteTo(oracle)
 
+	chalBytes, err := oracle.Challenge(colConstLabel, p.params.cols*16)
+	if err != nil {
+		return nil, nil, err
+	}
 	chals := make([]ring.Poly, p.params.cols)
-	chalBytes := make([]byte, 16)
 	for i := 0; i < p.params.cols; i++ {
 		chals[i] = p.params.ringQ.NewPoly()
-		oracle.Read(chalBytes)
-		encodeChallengeTo(p.params, p.params.ringQ, chals[i], chalBytes)
+		encodeChallengeTo(p.params, p.params.ringQ, chals[i], chalBytes[i*16:(i+1)*16])
 	}
+	p.prof.Count(profile.OpNTT, p.params.cols)
 
 	for i := range p.params.rows {
 		pf.Encode[i].Copy(openBatch.Encode[p.params.cols][i])
@@ -314,13 +591,17 @@ func (p *Prover[E]) Evaluate(x E, v [][]E, com []*Commitment, open []*Opening) (
 			p.params.ringQ.MulCoeffsMontgomeryThenAdd(chals[j], openBatch.MLWE[j][i], pf.MLWE[i])
 		}
 	}
+	p.prof.Count(profile.OpRingMul, p.params.cols*(p.params.rows+p.params.mlweRank+p.params.inMSISRank))
 
-	evals := make([]E, p.params.batch)
-	for i := 0; i < p.params.batch; i++ {
-		evals[i] = (&bigpoly.Poly[E]{Coeffs: v[i]}).Evaluate(x)
+	evals := make([][]E, len(xs))
+	for s, x := range xs {
+		evals[s] = make([]E, batchSize)
+		for i := 0; i < batchSize; i++ {
+			evals[s][i] = (&bigpoly.Poly[E]{Coeffs: v[i]}).Evaluate(x)
+		}
 	}
 
-	return evals, pf
+	return evals, pf, nil
 }
 
 // SafeCopy returns a thread-safe copy.
@@ -335,5 +616,7 @@ func (p *Prover[E]) SafeCopy() *Prover[E] {
 		uniformSampler: csprng.NewUniformSampler(),
 		roundedSampler: csprng.NewRoundedGaussianSampler(),
 		mlweSampler:    csprng.NewTwinCDTGaussianSampler(p.params.mlweStdDev),
+
+		prof: p.prof,
 	}
 }
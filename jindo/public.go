@@ -0,0 +1,87 @@
+package jindo
+
+import (
+	"github.com/sp301415/ringo-snark/math/bignum"
+	"github.com/sp301415/ringo-snark/profile"
+	"github.com/tuneinsight/lattigo/v6/ring"
+)
+
+// publicShape is the part of the parameters used by the non-hiding commitments of [Prover.CommitPublic].
+//
+// It is not a separate parameter set: a public commitment is a commitment under params
+// with all randomness set to zero, so it shares the commit key, the ranks and the bounds of params
+// and can be batched with hiding commitments.
+// Committing only skips the MLWE term, the blinding rows and the mask column, which are zero.
+type publicShape struct {
+	// rows are the number of rows holding v, without the blinding rows.
+	rows int
+	// cols are the number of columns holding v, without the mask column.
+	cols int
+	// slots is the number of slots.
+	slots int
+	// inMSISRank is the rank of the inner MSIS commitment.
+	inMSISRank int
+}
+
+// publicShape returns the part of p used by the non-hiding commitments.
+func (p Parameters) publicShape() publicShape {
+	return publicShape{
+		rows:       p.rows - p.points,
+		cols:       p.cols,
+		slots:      p.slots,
+		inMSISRank: p.inMSISRank,
+	}
+}
+
+// commitPublic commits v deterministically under params, with all randomness set to zero.
+// It needs no sampler, so it is shared by [Prover.CommitPublic] and [Verifier.CommitPublic].
+// The returned opening has the shape of params, with the randomness set to zero.
+// Panics if len(v) > params.rank.
+func commitPublic[E bignum.Uint[E]](params Parameters, ecd *Encoder[E], rnsOut *RNSReconstructor, ck *CommitKey, v []E, prof *profile.Profiler) (*Commitment, *Opening) {
+	switch {
+	case len(v) > params.rank:
+		panic("len(v) > params.rank")
+	}
+
+	pub := params.publicShape()
+
+	com := NewCommitment(params)
+	open := NewOpening(params)
+
+	inCom := make([]ring.Poly, pub.inMSISRank)
+	for j := range inCom {
+		inCom[j] = params.ringQ.NewPoly()
+	}
+	ckBuf := params.ringQ.NewPoly()
+
+	for i := range pub.cols {
+		rowStart := i * pub.slots
+		rowEnd := (i + 1) * pub.slots
+
+		// Row j holds v[j*cols*slots:(j+1)*cols*slots], and the rows past len(v) are zero.
+		rows := 0
+		for j := range pub.rows {
+			idxStart := (j * pub.cols * pub.slots) + rowStart
+			idxEnd := (j * pub.cols * pub.slots) + rowEnd
+			if idxStart >= len(v) {
+				break
+			}
+			ecd.encodeTo(open.Encode[i][j], v[idxStart:min(idxEnd, len(v))])
+			rows++
+		}
+
+		for j := range pub.inMSISRank {
+			inCom[j].Zero()
+			for k := range rows {
+				params.ringQ.MulCoeffsMontgomeryThenAdd(ck.in(j, k, ckBuf), open.Encode[i][k], inCom[j])
+			}
+		}
+		prof.Count(profile.OpRingMul, pub.inMSISRank*rows)
+
+		roundInCommitTo(params, ecd.rns, rnsOut, open.InCommit[i*pub.inMSISRank:(i+1)*pub.inMSISRank], inCom, prof)
+	}
+
+	outerCommitTo(params, ck, rnsOut, com, open, prof)
+
+	return com, open
+}
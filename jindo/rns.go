@@ -3,6 +3,7 @@ package jindo
 import (
 	"math/big"
 
+	"github.com/sp301415/ringo-snark/profile"
 	"github.com/tuneinsight/lattigo/v6/ring"
 )
 
@@ -15,6 +16,8 @@ type RNSReconstructor struct {
 	gad  []*big.Int
 
 	buf rnsReconstructorBuffer
+
+	prof *profile.Profiler
 }
 
 // rnsReconstructorBuffer is a buffer for [RNSReconstructor].
@@ -74,6 +77,7 @@ func toBalanced(x uint64, q uint64) int64 {
 
 // reconstructTo reconstructs a polynomial in RNS form to [*big.Int].
 func (r *RNSReconstructor) reconstructTo(vOut []*big.Int, p ring.Poly) {
+	r.prof.Count(profile.OpRNSReconstruct, 1)
 	for i := 0; i < r.ringQ.N(); i++ {
 		isSmall := true
 		cSigned := toBalanced(p.Coeffs[0][i], r.ringQ.SubRings[0].Modulus)
@@ -123,5 +127,7 @@ func (r *RNSReconstructor) safeCopy() *RNSReconstructor {
 		qBig: r.qBig,
 
 		buf: newRNSReconstructorBuffer(r.ringQ),
+
+		prof: r.prof,
 	}
 }
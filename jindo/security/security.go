@@ -0,0 +1,272 @@
+// Package security estimates the concrete hardness of the lattice problems
+// underlying the parameters of Jindo, in the core-SVP methodology.
+//
+// Each instance is reduced to the minimal BKZ block size b that solves it,
+// and the cost of b is given by a [CostModel].
+// The MSIS instances are attacked by finding a short vector in the kernel lattice,
+// and the MLWE instance by the primal uSVP attack.
+package security
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v6/ring"
+)
+
+// Parameters is the parameters of Jindo, implemented by jindo.Parameters.
+// It is an interface so that Jindo can use this package to choose its parameters.
+type Parameters interface {
+	// Rows are the number of rows in the matrix form of polynomial.
+	Rows() int
+	// InMSISRank is the rank of the inner MSIS commitment.
+	InMSISRank() int
+	// OutMSISRank is the rank of the outer MSIS commitment.
+	OutMSISRank() int
+	// MLWERank is the rank of the MLWE hiding term.
+	MLWERank() int
+	// InCommitDecomposeLen is the length of the decomposed inner commitment.
+	InCommitDecomposeLen() int
+	// InMSISBound is the two-norm bound of the inner MSIS instance.
+	InMSISBound() float64
+	// OutMSISBound is the two-norm bound of the outer MSIS instance.
+	OutMSISBound() float64
+	// MLWEStdDev is the standard deviation for MLWE.
+	MLWEStdDev() float64
+	// RingQ is the commitment ring.
+	RingQ() *ring.Ring
+	// RingQOut is the outer commitment ring.
+	RingQOut() *ring.Ring
+}
+
+// minBlockSize is the minimum BKZ block size considered.
+// The root Hermite factor estimate is not accurate below this.
+const minBlockSize = 50
+
+// CostModel is a cost model of BKZ with block size b.
+type CostModel int
+
+const (
+	// CoreSVPClassical is the classical core-SVP model, 2^(0.292b).
+	CoreSVPClassical CostModel = iota
+	// CoreSVPQuantum is the quantum core-SVP model, 2^(0.265b).
+	CoreSVPQuantum
+	// Sieving is the classical sieving model with polynomial factors, 2^(0.292b + 16.4).
+	Sieving
+)
+
+// String returns the name of the cost model.
+func (m CostModel) String() string {
+	switch m {
+	case CoreSVPClassical:
+		return "CoreSVPClassical"
+	case CoreSVPQuantum:
+		return "CoreSVPQuantum"
+	case Sieving:
+		return "Sieving"
+	}
+	return "Unknown"
+}
+
+// Bits returns the log2 of the cost of BKZ with block size b.
+func (m CostModel) Bits(b int) float64 {
+	switch m {
+	case CoreSVPClassical:
+		return 0.292 * float64(b)
+	case CoreSVPQuantum:
+		return 0.265 * float64(b)
+	case Sieving:
+		return 0.292*float64(b) + 16.4
+	}
+	panic(fmt.Sprintf("unknown cost model %v", int(m)))
+}
+
+// RootHermite returns the root Hermite factor achieved by BKZ with block size b.
+func RootHermite(b int) float64 {
+	bf := float64(b)
+	return math.Pow(bf/(2*math.Pi*math.E)*math.Pow(math.Pi*bf, 1/bf), 1/(2*(bf-1)))
+}
+
+// MSISInstance is an instance of the (two-norm) MSIS problem,
+// i.e. finding a nonzero x in Z^M with ||x|| <= Bound and Ax = 0 mod q for A in Z_q^(N x M).
+type MSISInstance struct {
+	// N is the number of rows over Z.
+	N int
+	// M is the number of columns over Z.
+	M int
+	// LogQ is log2(q).
+	LogQ float64
+	// Bound is the two-norm bound.
+	Bound float64
+}
+
+// BlockSize returns the minimal BKZ block size which solves the instance.
+// It returns 0 if the instance is trivially solvable.
+func (inst MSISInstance) BlockSize() int {
+	logBound := math.Log2(inst.Bound)
+	if logBound >= inst.LogQ {
+		return 0
+	}
+
+	for b := minBlockSize; b <= inst.M; b++ {
+		logDelta := math.Log2(RootHermite(b))
+
+		// The shortest vector found using m columns has length delta^m q^(N/m),
+		// which is minimized at m = sqrt(N log q / log delta).
+		m := math.Sqrt(float64(inst.N) * inst.LogQ / logDelta)
+		m = min(max(m, float64(b)), float64(inst.M))
+		if m*logDelta+float64(inst.N)/m*inst.LogQ <= logBound {
+			return b
+		}
+	}
+	return inst.M
+}
+
+// MLWEInstance is an instance of the MLWE problem over Z with secret dimension N,
+// M samples, modulus q and Gaussian secret and error with standard deviation StdDev.
+type MLWEInstance struct {
+	// N is the dimension of the secret over Z.
+	N int
+	// M is the number of samples over Z.
+	// If M is 0, the number of samples is unlimited.
+	M int
+	// LogQ is log2(q).
+	LogQ float64
+	// StdDev is the standard deviation of the secret and error.
+	StdDev float64
+}
+
+// BlockSize returns the minimal BKZ block size which solves the instance
+// using the primal uSVP attack, as estimated in
+// Alkim, Ducas, Pöppelmann and Schwabe, "Post-quantum key exchange - a new hope" (USENIX Security 2016).
+//
+// The attack may use any m <= M samples, and m is chosen optimally for each b.
+// When M is much smaller than N, the instance is in the few-samples regime,
+// where this estimate is not reliable;
+// there the attack may not succeed for any b below the dimension of the embedding lattice,
+// and N+M+1 is returned.
+// Set M to 0 to estimate the instance conservatively with unlimited samples instead.
+func (inst MLWEInstance) BlockSize() int {
+	logStdDev := math.Log2(inst.StdDev)
+
+	// With unlimited samples, the dimension of the embedding lattice is not bounded,
+	// and the attack always succeeds with b below the dimension it uses.
+	dMax, bMax := inst.N+inst.M+1, inst.N+inst.M+1
+	if inst.M == 0 {
+		dMax, bMax = math.MaxInt32, math.MaxInt32
+	}
+	for b := minBlockSize; b <= bMax; b++ {
+		logDelta := math.Log2(RootHermite(b))
+		lhs := logStdDev + 0.5*math.Log2(float64(b))
+
+		// The attack succeeds if StdDev * sqrt(b) <= delta^(2b-d) q^(m/d),
+		// where d = N + m + 1 is the dimension of the embedding lattice.
+		// The right hand side is concave in d,
+		// and maximized at d = sqrt((N+1) log q / log delta).
+		dOpt := math.Sqrt(float64(inst.N+1) * inst.LogQ / logDelta)
+		dMin := max(inst.N+2, b)
+		for _, d := range []int{int(math.Floor(dOpt)), int(math.Ceil(dOpt))} {
+			d = min(max(d, dMin), dMax)
+			m := d - inst.N - 1
+			if lhs <= (2*float64(b)-float64(d))*logDelta+float64(m)/float64(d)*inst.LogQ {
+				return b
+			}
+		}
+	}
+	return dMax
+}
+
+// InnerMSIS returns the MSIS instance for the binding of the inner commitment.
+func InnerMSIS(params Parameters) MSISInstance {
+	d := params.RingQ().N()
+	return MSISInstance{
+		N:     params.InMSISRank() * d,
+		M:     (params.Rows() + params.MLWERank() + 2*params.InMSISRank()) * d,
+		LogQ:  log2(params.RingQ().Modulus()),
+		Bound: params.InMSISBound(),
+	}
+}
+
+// OuterMSIS returns the MSIS instance for the binding of the outer commitment.
+func OuterMSIS(params Parameters) MSISInstance {
+	d := params.RingQOut().N()
+	return MSISInstance{
+		N:     params.OutMSISRank() * d,
+		M:     (params.InCommitDecomposeLen() + params.OutMSISRank()) * d,
+		LogQ:  log2(params.RingQOut().Modulus()),
+		Bound: params.OutMSISBound(),
+	}
+}
+
+// MLWE returns the MLWE instance for the hiding of the inner commitment.
+// The hiding instance has only InMSISRank * N samples, which is in the few-samples regime,
+// so it is estimated conservatively with unlimited samples.
+func MLWE(params Parameters) MLWEInstance {
+	d := params.RingQ().N()
+	return MLWEInstance{
+		N:      params.MLWERank() * d,
+		LogQ:   log2(params.RingQ().Modulus()),
+		StdDev: params.MLWEStdDev(),
+	}
+}
+
+// log2 returns log2(x).
+func log2(x *big.Int) float64 {
+	shift := max(x.BitLen()-53, 0)
+	mant, _ := new(big.Float).SetInt(new(big.Int).Rsh(x, uint(shift))).Float64()
+	return math.Log2(mant) + float64(shift)
+}
+
+// Estimate is the hardness estimate of a single instance.
+type Estimate struct {
+	// BlockSize is the minimal BKZ block size which solves the instance.
+	BlockSize int
+	// Bits is the log2 of the cost of the attack.
+	Bits float64
+}
+
+// Report is the hardness estimate of all instances of a [Parameters].
+type Report struct {
+	// Model is the cost model used.
+	Model CostModel
+
+	// InnerMSIS is the estimate for the binding of the inner commitment.
+	InnerMSIS Estimate
+	// OuterMSIS is the estimate for the binding of the outer commitment.
+	OuterMSIS Estimate
+	// MLWE is the estimate for the hiding of the inner commitment.
+	MLWE Estimate
+
+	// BindingBits is the bit-security of binding.
+	BindingBits float64
+	// HidingBits is the bit-security of hiding.
+	HidingBits float64
+	// Bits is the overall bit-security.
+	Bits float64
+}
+
+// newEstimate returns the estimate for block size b.
+func newEstimate(b int, model CostModel) Estimate {
+	if b == 0 {
+		return Estimate{}
+	}
+	return Estimate{BlockSize: b, Bits: model.Bits(b)}
+}
+
+// EstimateParameters estimates the hardness of the instances of params under model.
+func EstimateParameters(params Parameters, model CostModel) Report {
+	r := Report{
+		Model: model,
+
+		InnerMSIS: newEstimate(InnerMSIS(params).BlockSize(), model),
+		OuterMSIS: newEstimate(OuterMSIS(params).BlockSize(), model),
+		MLWE:      newEstimate(MLWE(params).BlockSize(), model),
+	}
+
+	r.BindingBits = min(r.InnerMSIS.Bits, r.OuterMSIS.Bits)
+	r.HidingBits = r.MLWE.Bits
+	r.Bits = min(r.BindingBits, r.HidingBits)
+
+	return r
+}
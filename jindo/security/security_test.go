@@ -0,0 +1,98 @@
+package security_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sp301415/ringo-snark/jindo/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootHermite(t *testing.T) {
+	// Root Hermite factors of BKZ from the asymptotic formula of Chen's thesis.
+	assert.InDelta(t, 1.0093, security.RootHermite(100), 1e-4)
+	assert.InDelta(t, 1.0050, security.RootHermite(285), 1e-4)
+	assert.InDelta(t, 1.0037, security.RootHermite(440), 1e-4)
+	assert.Greater(t, security.RootHermite(100), security.RootHermite(200))
+}
+
+func TestMLWE(t *testing.T) {
+	t.Run("KnownAnswer", func(t *testing.T) {
+		// Primal uSVP block sizes of the key recovery of Kyber and Dilithium,
+		// from the core-SVP tables of their round 3 specifications,
+		// which agree with LWE.primal_usvp of the lattice-estimator.
+		for _, tc := range []struct {
+			name      string
+			inst      security.MLWEInstance
+			blockSize int
+		}{
+			{"Kyber512", security.MLWEInstance{N: 512, M: 512, LogQ: math.Log2(3329), StdDev: math.Sqrt(1.5)}, 406},
+			{"Kyber768", security.MLWEInstance{N: 768, M: 768, LogQ: math.Log2(3329), StdDev: 1}, 623},
+			{"Kyber1024", security.MLWEInstance{N: 1024, M: 1024, LogQ: math.Log2(3329), StdDev: 1}, 876},
+			{"Dilithium2", security.MLWEInstance{N: 1024, M: 1024, LogQ: math.Log2(8380417), StdDev: math.Sqrt(2)}, 423},
+			{"Dilithium3", security.MLWEInstance{N: 1280, M: 1536, LogQ: math.Log2(8380417), StdDev: math.Sqrt(20.0 / 3)}, 624},
+			{"Dilithium5", security.MLWEInstance{N: 1792, M: 2048, LogQ: math.Log2(8380417), StdDev: math.Sqrt(2)}, 863},
+		} {
+			assert.InDelta(t, tc.blockSize, tc.inst.BlockSize(), 5, tc.name)
+		}
+	})
+
+	t.Run("UnlimitedSamples", func(t *testing.T) {
+		// Kyber has enough samples, so unlimited samples do not help the attack.
+		kyber := security.MLWEInstance{N: 768, M: 768, LogQ: math.Log2(3329), StdDev: 1}
+		unlimited := kyber
+		unlimited.M = 0
+		assert.Equal(t, kyber.BlockSize(), unlimited.BlockSize())
+
+		// With few samples, the attack degenerates to SVP in the full embedding lattice,
+		// which unlimited samples do not rely on.
+		fewSamples := security.MLWEInstance{N: 1 << 13, M: 1 << 8, LogQ: 32, StdDev: 3.2}
+		assert.Equal(t, fewSamples.N+fewSamples.M+1, fewSamples.BlockSize())
+		fewSamples.M = 0
+		assert.Less(t, fewSamples.BlockSize(), fewSamples.N)
+
+		// More samples never make the attack harder.
+		prev := math.MaxInt
+		for _, m := range []int{1 << 10, 1 << 12, 1 << 14, 0} {
+			inst := security.MLWEInstance{N: 1 << 12, M: m, LogQ: 109, StdDev: 3.2}
+			assert.LessOrEqual(t, inst.BlockSize(), prev, m)
+			prev = inst.BlockSize()
+		}
+	})
+}
+
+func TestMSIS(t *testing.T) {
+	t.Run("KnownAnswer", func(t *testing.T) {
+		// The example of SIS.lattice in the lattice-estimator with the Euclidean norm,
+		// which reports beta = 61 with unlimited columns.
+		inst := security.MSISInstance{N: 113, M: 1 << 16, LogQ: 11, Bound: 512}
+		assert.InDelta(t, 61, inst.BlockSize(), 1)
+
+		// If the optimal number of columns sqrt(N log q / log delta) is available,
+		// the block size is the minimal b with 2 sqrt(N log q log delta_b) <= log Bound.
+		for _, tc := range []struct {
+			inst      security.MSISInstance
+			blockSize int
+		}{
+			{security.MSISInstance{N: 1024, M: 1 << 16, LogQ: 40, Bound: 1 << 20}, 1278},
+			{security.MSISInstance{N: 2048, M: 1 << 16, LogQ: 64, Bound: 1 << 30}, 2006},
+			// Restricting the columns makes the attack harder.
+			{security.MSISInstance{N: 1024, M: 3000, LogQ: 40, Bound: 1 << 20}, 1538},
+		} {
+			assert.Equal(t, tc.blockSize, tc.inst.BlockSize())
+		}
+	})
+
+	t.Run("Trivial", func(t *testing.T) {
+		// The bound exceeds q, so q e_1 is a solution.
+		trivial := security.MSISInstance{N: 256, M: 1024, LogQ: 32, Bound: 1 << 33}
+		assert.Zero(t, trivial.BlockSize())
+	})
+}
+
+func TestCostModel(t *testing.T) {
+	assert.Equal(t, 0.292*400, security.CoreSVPClassical.Bits(400))
+	assert.Equal(t, 0.265*400, security.CoreSVPQuantum.Bits(400))
+	assert.Equal(t, 0.292*400+16.4, security.Sieving.Bits(400))
+	assert.Panics(t, func() { security.CostModel(-1).Bits(400) })
+}
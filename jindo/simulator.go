@@ -0,0 +1,429 @@
+package jindo
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"slices"
+
+	"github.com/sp301415/ringo-snark/math/bignum"
+	"github.com/sp301415/ringo-snark/math/csprng"
+	"github.com/sp301415/ringo-snark/transcript"
+	"github.com/tuneinsight/lattigo/v6/ring"
+)
+
+// Simulator simulates evaluation proofs from public inputs only.
+// It programs the oracle: the challenges are sampled first,
+// and the responses are sampled from the Gaussian distribution
+// which drowns the committed vectors in an honest proof.
+//
+// The inner commitments of the columns are hidden by MLWE, so they are sampled uniformly,
+// and the inner commitments of the masks are solved from the responses.
+// The commitments are then computed from the inner commitments,
+// so a simulated proof passes all checks of [Verifier.VerifyWithTranscript]
+// against the transcript returned by [SimulatedProof.Transcript].
+type Simulator[E bignum.Uint[E]] struct {
+	params Parameters
+	ecd    *Encoder[E]
+	vrf    *Verifier[E]
+
+	uniformSampler *csprng.UniformSampler
+	roundedSampler *csprng.RoundedGaussianSampler
+}
+
+// SimulatedProof is a proof generated by [Simulator],
+// together with the commitments and the challenges it programmed.
+type SimulatedProof struct {
+	// Commitments are the simulated commitments.
+	Commitments []*Commitment
+	// Proof is the simulated proof.
+	Proof *Proof
+
+	batchBytes []byte
+	chalBytes  []byte
+}
+
+// Transcript returns the transcript which outputs the programmed challenges of sp.
+// It ignores the appended messages, so it is only meaningful for sp.
+func (sp *SimulatedProof) Transcript() transcript.Transcript {
+	chals := map[string][]byte{colConstLabel: sp.chalBytes}
+	if sp.batchBytes != nil {
+		chals[batchConstLabel] = sp.batchBytes
+	}
+	return &programmedTranscript{chals: chals}
+}
+
+// programmedTranscript is a transcript whose challenges are programmed by [Simulator].
+type programmedTranscript struct {
+	chals map[string][]byte
+}
+
+// Append does nothing, since the challenges are programmed.
+func (t *programmedTranscript) Append(label string, msg []byte) error {
+	return nil
+}
+
+// Challenge returns the programmed challenge named label.
+func (t *programmedTranscript) Challenge(label string, n int) ([]byte, error) {
+	c, ok := t.chals[label]
+	if !ok || len(c) != n {
+		return nil, errors.New("jindo: challenge is not programmed")
+	}
+	return slices.Clone(c), nil
+}
+
+// NewSimulator creates a new [Simulator].
+// The commit key is shared with other provers and verifiers with the same params and crs.
+func NewSimulator[E bignum.Uint[E]](params Parameters, crs []byte) *Simulator[E] {
+	return NewSimulatorWithKey[E](params, GetCommitKey(params, crs))
+}
+
+// NewSimulatorWithKey creates a new [Simulator] with the given commit key.
+// Panics if ck is not a key for params.
+func NewSimulatorWithKey[E bignum.Uint[E]](params Parameters, ck *CommitKey) *Simulator[E] {
+	return &Simulator[E]{
+		params: params,
+		ecd:    newEncoder[E](params),
+		vrf:    NewVerifierWithKey[E](params, ck),
+
+		uniformSampler: csprng.NewUniformSampler(),
+		roundedSampler: csprng.NewRoundedGaussianSampler(),
+	}
+}
+
+// SetSeed makes the simulator deterministic by drawing all of its randomness from seed.
+// If seed is nil, the simulator draws its randomness from crypto/rand again.
+func (s *Simulator[E]) SetSeed(seed []byte) {
+	s.ecd.setSeed(seed)
+	if seed == nil {
+		s.uniformSampler = csprng.NewUniformSampler()
+		s.roundedSampler = csprng.NewRoundedGaussianSampler()
+		return
+	}
+	s.uniformSampler = csprng.NewUniformSamplerWithSeed(csprng.DeriveSeed(seed, "jindo.Simulator.Uniform"))
+	s.roundedSampler = csprng.NewRoundedGaussianSamplerWithSeed(csprng.DeriveSeed(seed, "jindo.Simulator.Rounded"))
+}
+
+// sampleChallenge samples a random challenge to chalBytes,
+// and returns it in ringQ with its coefficients.
+func (s *Simulator[E]) sampleChallenge(ringQ *ring.Ring, chalBytes []byte) (ring.Poly, []int64) {
+	s.uniformSampler.Read(chalBytes)
+
+	coeffs := challengeCoeffs(s.params, chalBytes)
+	chal := ringQ.NewPoly()
+	smallChallengeTo(s.params, ringQ, chal, coeffs)
+	return chal, coeffs
+}
+
+// sampleInCommitTo samples a rounded inner commitment of a column uniformly to pOut.
+func (s *Simulator[E]) sampleInCommitTo(pOut ring.Poly) {
+	q := s.params.ringQ.ModulusAtLevel[s.params.ringQ.Level()]
+	bound := new(big.Int).Sub(q, big.NewInt(1))
+	bound.Rsh(bound, uint(s.params.logInCutOff))
+	bound.Add(bound, big.NewInt(1))
+
+	coeffs := make([]*big.Int, s.params.ringQOut.N())
+	for i := range coeffs {
+		c, err := rand.Int(s.uniformSampler, bound)
+		if err != nil {
+			panic(err)
+		}
+		coeffs[i] = c
+	}
+	s.vrf.rnsOut.setBigCoeffTo(pOut, coeffs)
+
+	s.params.ringQOut.MForm(pOut, pOut)
+	s.params.ringQOut.NTT(pOut, pOut)
+}
+
+// maskInCommitTo computes the rounded inner commitments of the mask to inComOut,
+// such that the inner commitments of pf are consistent with its response under chals.
+// The inner commitments of the columns must already be set in pf.
+func (s *Simulator[E]) maskInCommitTo(inComOut []ring.Poly, chals []ring.Poly, pf *Proof) {
+	ringQ, ringQOut := s.params.ringQ, s.params.ringQOut
+
+	inComInv := ringQOut.NewPoly()
+	inComQ := ringQ.NewPoly()
+	ckBuf := ringQ.NewPoly()
+	com := make([]ring.Poly, s.params.inMSISRank)
+	for i := range com {
+		com[i] = ringQ.NewPoly()
+		for j := range s.params.cols {
+			ringQOut.IMForm(pf.InCommit[j*s.params.inMSISRank+i], inComInv)
+			ringQOut.INTT(inComInv, inComInv)
+			s.vrf.embQOutToQ.ModUpQtoP(ringQOut.Level(), ringQ.Level(), inComInv, inComQ)
+
+			ringQ.MForm(inComQ, inComQ)
+			ringQ.NTT(inComQ, inComQ)
+			ringQ.MulCoeffsMontgomeryThenAdd(inComQ, chals[j], com[i])
+		}
+		ringQ.MulRNSScalarMontgomery(com[i], s.vrf.inCutOff, com[i])
+		ringQ.Neg(com[i], com[i])
+
+		for j := range s.params.rows {
+			ringQ.MulCoeffsMontgomeryThenAdd(s.vrf.ck.in(i, j, ckBuf), pf.Encode[j], com[i])
+		}
+		for j := range s.params.mlweRank {
+			ringQ.MulCoeffsMontgomeryThenAdd(s.vrf.ck.mlwe(i, j, ckBuf), pf.MLWE[j], com[i])
+		}
+		ringQ.Add(pf.MLWE[s.params.mlweRank+i], com[i], com[i])
+	}
+
+	roundInCommitTo(s.params, s.ecd.rns, s.vrf.rnsOut, inComOut, com, nil)
+}
+
+// invertNTT sets pOut to the inverse of p in ringQ, where both are in NTT and Montgomery form.
+// It returns false if p is not invertible.
+func invertNTT(ringQ *ring.Ring, p, pOut ring.Poly) bool {
+	ringQ.IMForm(p, pOut)
+	for i, s := range ringQ.SubRings[:ringQ.Level()+1] {
+		for k, c := range pOut.Coeffs[i] {
+			if c == 0 {
+				return false
+			}
+			pOut.Coeffs[i][k] = ring.ModExp(c, s.Modulus-2, s.Modulus)
+		}
+	}
+	ringQ.MForm(pOut, pOut)
+	return true
+}
+
+// challengeSqNorm returns the squared two-norm of the product of small challenges c0 and c1,
+// which are polynomials of degree less than exp in X^slots modulo X^N + 1.
+func challengeSqNorm(exp int, c0, c1 []int64) float64 {
+	prod := make([]int64, exp)
+	for i := range c0 {
+		for j := range c1 {
+			if k := i + j; k < len(prod) {
+				prod[k] += c0[i] * c1[j]
+			} else {
+				prod[k-len(prod)] -= c0[i] * c1[j]
+			}
+		}
+	}
+
+	var sqNm float64
+	for _, c := range prod {
+		sqNm += float64(c) * float64(c)
+	}
+	return sqNm
+}
+
+// Simulate returns a simulated proof that the committed vectors,
+// whose lengths are lens, evaluate to y at x.
+func (s *Simulator[E]) Simulate(x E, lens []int, y []E) *SimulatedProof {
+	switch {
+	case len(lens) != len(y):
+		panic("len(lens) != len(y)")
+	case len(y) == 0 || len(y) > s.params.batch:
+		panic("invalid batch size")
+	}
+
+	var z E
+	sp := &SimulatedProof{
+		Commitments: make([]*Commitment, len(y)),
+		Proof:       NewProof(s.params),
+		chalBytes:   make([]byte, s.params.cols*16),
+	}
+
+	// batchCoeffs are the batching challenges, which are 1 for a single commitment.
+	batchCoeffs := [][]int64{{1}}
+	var batchOut []ring.Poly
+	batchInv := s.params.ringQOut.NewPoly()
+	yBatch := z.New().Set(y[0])
+	if len(y) > 1 {
+		sp.batchBytes = make([]byte, len(y)*16)
+		batchOut = make([]ring.Poly, len(y))
+		batchCoeffs = make([][]int64, len(y))
+		yBatch.SetUint64(0)
+		for k := range y {
+			kBytes := sp.batchBytes[k*16 : (k+1)*16]
+			batchOut[k], batchCoeffs[k] = s.sampleChallenge(s.params.ringQOut, kBytes)
+			// The inner commitment of the first mask is solved by dividing by its batching challenge,
+			// which is not invertible with negligible probability.
+			for k == 0 && !invertNTT(s.params.ringQOut, batchOut[k], batchInv) {
+				batchOut[k], batchCoeffs[k] = s.sampleChallenge(s.params.ringQOut, kBytes)
+			}
+			scalar := CombinationScalar[E](s.params, batchCoeffs[k])
+			yBatch.Add(yBatch, scalar.Mul(scalar, y[k]))
+		}
+	}
+
+	chals := make([]ring.Poly, s.params.cols)
+	chalCoeffs := make([][]int64, s.params.cols)
+	for i := range chals {
+		chals[i], chalCoeffs[i] = s.sampleChallenge(s.params.ringQ, sp.chalBytes[i*16:(i+1)*16])
+	}
+
+	// isUsed returns true if the j-th row of the i-th column of a vector of length n is used,
+	// where the cols-th column is the mask.
+	// The first row, the blinding rows, and with more than one blinding row the second row, are always used.
+	isUsed := func(i, j, n int) bool {
+		if j == 0 || j >= s.params.rows-s.params.points || (j == 1 && s.params.points > 1) {
+			return true
+		}
+		idxStart := j * s.params.cols * s.params.slots
+		if i < s.params.cols {
+			idxStart += i * s.params.slots
+		}
+		return idxStart <= n
+	}
+
+	// colVar[i][j] is the variance of the j-th row of the i-th column after batching,
+	// and resVar[j] is the variance of the j-th row of the response.
+	colVar := make([][]float64, s.params.cols)
+	resVar := make([]float64, s.params.rows)
+	var mlweVar float64
+	for k, n := range lens {
+		bSqNm := challengeSqNorm(s.params.ecd.exp, batchCoeffs[k], []int64{1})
+		for j := range s.params.rows {
+			if !isUsed(s.params.cols, j, n) {
+				continue
+			}
+			maskStdDev := s.params.maskStdDev
+			if j == 0 {
+				maskStdDev = s.params.maskBlindStdDev
+			}
+			resVar[j] += bSqNm * maskStdDev * maskStdDev
+		}
+		mlweVar += bSqNm * s.params.maskMLWEStdDev * s.params.maskMLWEStdDev
+
+		for i := range s.params.cols {
+			if colVar[i] == nil {
+				colVar[i] = make([]float64, s.params.rows)
+			}
+			bcSqNm := challengeSqNorm(s.params.ecd.exp, batchCoeffs[k], chalCoeffs[i])
+			for j := range s.params.rows {
+				if !isUsed(i, j, n) {
+					continue
+				}
+				ecdStdDev := s.params.ecdStdDev
+				if j == 0 {
+					ecdStdDev = s.params.ecdBlindStdDev
+				}
+				colVar[i][j] += bSqNm * ecdStdDev * ecdStdDev
+				resVar[j] += bcSqNm * ecdStdDev * ecdStdDev
+			}
+			mlweVar += bcSqNm * s.params.mlweStdDev * s.params.mlweStdDev
+		}
+	}
+
+	// The messages of the used rows of the columns are uniform,
+	// except the last entry of a single blinding row,
+	// and the first row of the last column is chosen to match the evaluation.
+	msgs := make([][][]E, s.params.cols)
+	for i := range msgs {
+		msgs[i] = make([][]E, s.params.rows)
+		for j := range msgs[i] {
+			msgs[i][j] = make([]E, s.params.slots)
+			for l := range msgs[i][j] {
+				msgs[i][j][l] = z.New()
+				if colVar[i][j] != 0 {
+					bignum.MustSetRandomFrom(msgs[i][j][l], s.uniformSampler)
+				}
+			}
+		}
+	}
+	if s.params.points == 1 {
+		msgs[s.params.cols-1][s.params.rows-1][s.params.slots-1].SetUint64(0)
+	}
+
+	left := leftVec(s.params, x)
+	right := rightVec(s.params, x)
+	eval, term, mul := z.New(), z.New(), z.New()
+	for i := range s.params.cols {
+		for l := range s.params.slots {
+			term.SetUint64(0)
+			for j := range s.params.rows {
+				term.Add(term, mul.Mul(left[j], msgs[i][j][l]))
+			}
+			eval.Add(eval, mul.Mul(right[i*s.params.slots+l], term))
+		}
+	}
+	diff := z.New().Sub(yBatch, eval)
+	diff.Mul(diff, z.New().Inverse(right[s.params.cols*s.params.slots-1]))
+	msgs[s.params.cols-1][0][s.params.slots-1].Add(msgs[s.params.cols-1][0][s.params.slots-1], diff)
+
+	leftEcd := make([]ring.Poly, s.params.rows)
+	for j := range leftEcd {
+		leftEcd[j] = s.ecd.encode([]E{left[j]})
+	}
+
+	ecd := s.params.ringQ.NewPoly()
+	for i := range s.params.cols {
+		for j := range s.params.rows {
+			if colVar[i][j] == 0 {
+				continue
+			}
+			s.ecd.randEncodeTo(ecd, msgs[i][j], math.Sqrt(colVar[i][j]))
+			s.params.ringQ.MulCoeffsMontgomeryThenAdd(leftEcd[j], ecd, sp.Proof.Partial[i])
+		}
+	}
+
+	msg := make([]E, s.params.slots)
+	for j := range s.params.rows {
+		if resVar[j] == 0 {
+			continue
+		}
+		for l := range msg {
+			msg[l] = bignum.MustSetRandomFrom(z.New(), s.uniformSampler)
+		}
+		s.ecd.randEncodeTo(sp.Proof.Encode[j], msg, math.Sqrt(resVar[j]))
+		s.params.ringQ.MulCoeffsMontgomeryThenAdd(leftEcd[j], sp.Proof.Encode[j], sp.Proof.PartialMask)
+	}
+	for i := range s.params.cols {
+		s.params.ringQ.MulCoeffsMontgomeryThenSub(chals[i], sp.Proof.Partial[i], sp.Proof.PartialMask)
+	}
+
+	mlweStdDev := math.Sqrt(mlweVar)
+	for j := range sp.Proof.MLWE {
+		for k := range s.params.ringQ.N() {
+			setCoeffSigned(s.params.ringQ, sp.Proof.MLWE[j], s.roundedSampler.Sample(0, mlweStdDev), k)
+		}
+		s.params.ringQ.MForm(sp.Proof.MLWE[j], sp.Proof.MLWE[j])
+		s.params.ringQ.NTT(sp.Proof.MLWE[j], sp.Proof.MLWE[j])
+	}
+
+	// The inner commitments of the columns are batched as in an honest proof,
+	// and the inner commitment of the first mask is solved from the batched one.
+	maskStart := s.params.cols * s.params.inMSISRank
+	inCom := make([][]ring.Poly, len(y))
+	for k := range inCom {
+		inCom[k] = make([]ring.Poly, s.params.inComDcmpLen)
+		for j := range inCom[k] {
+			inCom[k][j] = s.params.ringQOut.NewPoly()
+			if k != 0 || j < maskStart {
+				s.sampleInCommitTo(inCom[k][j])
+			}
+		}
+	}
+	for j := range maskStart {
+		if len(y) == 1 {
+			sp.Proof.InCommit[j].Copy(inCom[0][j])
+			continue
+		}
+		sp.Proof.InCommit[j].Zero()
+		for k := range y {
+			s.params.ringQOut.MulCoeffsMontgomeryThenAdd(inCom[k][j], batchOut[k], sp.Proof.InCommit[j])
+		}
+	}
+	s.maskInCommitTo(sp.Proof.InCommit[maskStart:], chals, sp.Proof)
+	for j := maskStart; j < s.params.inComDcmpLen; j++ {
+		inCom[0][j].Copy(sp.Proof.InCommit[j])
+		if len(y) == 1 {
+			continue
+		}
+		for k := 1; k < len(y); k++ {
+			s.params.ringQOut.MulCoeffsMontgomeryThenSub(inCom[k][j], batchOut[k], inCom[0][j])
+		}
+		s.params.ringQOut.MulCoeffsMontgomery(inCom[0][j], batchInv, inCom[0][j])
+	}
+
+	for k := range sp.Commitments {
+		sp.Commitments[k] = NewCommitment(s.params)
+		outerCommitTo(s.params, s.vrf.ck, s.vrf.rnsOut, sp.Commitments[k], &Opening{InCommit: inCom[k]}, nil)
+	}
+
+	return sp
+}
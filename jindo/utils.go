@@ -1,13 +1,29 @@
 package jindo
 
 import (
+	"bytes"
+	"crypto/sha3"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
 	"math/bits"
+	"slices"
 
 	"github.com/sp301415/ringo-snark/math/bignum"
+	"github.com/sp301415/ringo-snark/transcript"
 	"github.com/tuneinsight/lattigo/v6/ring"
 )
 
+const (
+	// batchConstLabel is the label of the batching challenges.
+	batchConstLabel = "jindoBatchConst"
+	// pointConstLabel is the label of the challenges combining the points.
+	pointConstLabel = "jindoPointConst"
+	// colConstLabel is the label of the challenges of the columns.
+	colConstLabel = "jindoColConst"
+)
+
 // divMod64 computes x = x / y and returns x mod y.
 func divMod64(x []uint64, y uint64) uint64 {
 	var r uint64
@@ -19,27 +35,36 @@ func divMod64(x []uint64, y uint64) uint64 {
 
 // encodeChallengeTo encodes c to pOut.
 func encodeChallengeTo(params Parameters, ringQ *ring.Ring, pOut ring.Poly, chalBytes []byte) {
-	pOut.Zero()
+	smallChallengeTo(params, ringQ, pOut, challengeCoeffs(params, chalBytes))
+}
 
+// challengeCoeffs returns the coefficients of the small challenge encoded in chalBytes.
+func challengeCoeffs(params Parameters, chalBytes []byte) []int64 {
 	c := []uint64{
 		binary.BigEndian.Uint64(chalBytes[:8]),
 		binary.BigEndian.Uint64(chalBytes[8:]),
 	}
 
 	cInfNm := uint64(params.ChallengeBound())
-	for i := 0; i < params.ecd.exp; i++ {
+	coeffs := make([]int64, params.ecd.exp)
+	for i := range coeffs {
 		r := divMod64(c, cInfNm)
 		if r > cInfNm/2 {
-			r = cInfNm - r
-			for k := 0; k <= pOut.Level(); k++ {
-				pOut.Coeffs[k][i*params.slots] = ringQ.SubRings[k].Modulus - r
-			}
+			coeffs[i] = -int64(cInfNm - r)
 		} else {
-			for k := 0; k <= pOut.Level(); k++ {
-				pOut.Coeffs[k][i*params.slots] = r
-			}
+			coeffs[i] = int64(r)
 		}
 	}
+	return coeffs
+}
+
+// smallChallengeTo sets pOut to the small challenge sum_i coeffs[i] X^(i*slots)
+// in NTT and Montgomery form.
+func smallChallengeTo(params Parameters, ringQ *ring.Ring, pOut ring.Poly, coeffs []int64) {
+	pOut.Zero()
+	for i, c := range coeffs {
+		setCoeffSigned(ringQ, pOut, c, i*params.slots)
+	}
 
 	ringQ.MForm(pOut, pOut)
 	ringQ.NTT(pOut, pOut)
@@ -60,14 +85,20 @@ func setCoeffSigned(ringQ *ring.Ring, pOut ring.Poly, c int64, i int) {
 }
 
 // leftVec computes the left vector during the evaluation protocol.
+// The rows holding the polynomial are weighted by the powers of x^(cols*slots),
+// and the k-th blinding row, counting from one, is weighted by x^k.
 func leftVec[E bignum.Uint[E]](params Parameters, x E) []E {
+	dataRows := params.rows - params.points
 	skip := bignum.Exp(x, uint64(params.cols*params.slots))
 	left := make([]E, params.rows)
 	left[0] = x.New().SetUint64(1)
-	for i := 1; i < params.rows; i++ {
+	for i := 1; i < dataRows; i++ {
 		left[i] = x.New().Mul(left[i-1], skip)
 	}
-	left[params.rows-1] = x.New().Set(x)
+	left[dataRows] = x.New().Set(x)
+	for i := dataRows + 1; i < params.rows; i++ {
+		left[i] = x.New().Mul(left[i-1], x)
+	}
 	return left
 }
 
@@ -80,3 +111,224 @@ func rightVec[E bignum.Uint[E]](params Parameters, x E) []E {
 	}
 	return right
 }
+
+// checkWhich checks that points and which are valid for multi-point evaluation.
+func checkWhich[E bignum.Uint[E]](params Parameters, points []E, which [][]int) {
+	switch {
+	case len(points) != len(which):
+		panic("len(points) != len(which)")
+	}
+
+	// Each point reveals a combination of the blinding rows of the matrix,
+	// so each index may appear at no more points than the blinding rows.
+	count := make([]int, params.batch)
+	for k := range which {
+		if len(which[k]) == 0 {
+			panic(fmt.Sprintf("which[%v] is empty", k))
+		}
+		for l, i := range which[k] {
+			if i < 0 || i >= params.batch {
+				panic(fmt.Sprintf("which[%v] has index %v out of range", k, i))
+			}
+			if slices.Contains(which[k][:l], i) {
+				panic(fmt.Sprintf("which[%v] has duplicate index %v", k, i))
+			}
+			if count[i]++; count[i] > params.points {
+				panic(fmt.Sprintf("index %v is opened at more than params.points points", i))
+			}
+		}
+	}
+}
+
+// multiClasses groups the polynomials of multi-point evaluation by the points at which they are evaluated.
+// The c-th class evaluates the polynomials members[c] at the points indexed by points[c],
+// and the classes are ordered by their first polynomial.
+func multiClasses(batch int, which [][]int) (members, points [][]int) {
+	pointsOf := make([][]int, batch)
+	for k := range which {
+		for _, i := range which[k] {
+			pointsOf[i] = append(pointsOf[i], k)
+		}
+	}
+
+	for i := range batch {
+		if len(pointsOf[i]) == 0 {
+			continue
+		}
+		c := slices.IndexFunc(points, func(pts []int) bool { return slices.Equal(pts, pointsOf[i]) })
+		if c < 0 {
+			c = len(points)
+			members = append(members, nil)
+			points = append(points, pointsOf[i])
+		}
+		members[c] = append(members[c], i)
+	}
+	return members, points
+}
+
+// multiStatement returns the digest of the statement of multi-point evaluation.
+func multiStatement[E bignum.Uint[E]](ck *CommitKey, com []*Commitment, points []E, which [][]int) []byte {
+	oracle := sha3.NewSHAKE128()
+	ck.WriteRawTo(oracle)
+	for i := range com {
+		com[i].WriteRawTo(oracle)
+	}
+
+	var buf [4]byte
+	for k := range points {
+		oracle.Write(points[k].Marshal())
+		binary.BigEndian.PutUint32(buf[:], uint32(len(which[k])))
+		oracle.Write(buf[:])
+		for _, i := range which[k] {
+			binary.BigEndian.PutUint32(buf[:], uint32(i))
+			oracle.Write(buf[:])
+		}
+	}
+
+	stmt := make([]byte, 32)
+	oracle.Read(stmt)
+	return stmt
+}
+
+// appendStatement binds the statement of the evaluation of com at xs to the first challenge in oracle,
+// which is the batching challenge if more than one commitment is evaluated,
+// and the challenge combining the points if more than one point is evaluated.
+// If stmt is not nil, it is also bound after xs.
+func appendStatement[E bignum.Uint[E]](oracle transcript.Transcript, ck *CommitKey, com []*Commitment, xs []E, stmt []byte) error {
+	var buf bytes.Buffer
+	ck.WriteRawTo(&buf)
+	for i := range com {
+		com[i].WriteRawTo(&buf)
+	}
+	for _, x := range xs {
+		buf.Write(x.Marshal())
+	}
+	buf.Write(stmt)
+
+	label := colConstLabel
+	switch {
+	case len(com) > 1:
+		label = batchConstLabel
+	case len(xs) > 1:
+		label = pointConstLabel
+	}
+	return oracle.Append(label, buf.Bytes())
+}
+
+// appendPartial binds the partial evaluations of pf to the challenges of the columns in oracle.
+func appendPartial(oracle transcript.Transcript, pf *Proof) error {
+	var buf bytes.Buffer
+	for i := range pf.Partial {
+		pf.Partial[i].WriteTo(&buf)
+	}
+	pf.PartialMask.WriteTo(&buf)
+	return oracle.Append(colConstLabel, buf.Bytes())
+}
+
+// readPointChallenges binds the partial evaluations of pf at multiple points
+// to the challenges combining the points in oracle, and returns the challenges.
+// The partial evaluation of the mask is sent after the challenges,
+// and is bound by [appendPartialMask].
+func readPointChallenges(oracle transcript.Transcript, pf *Proof, points int) ([]*big.Int, error) {
+	var buf bytes.Buffer
+	for i := range pf.Partial {
+		pf.Partial[i].WriteTo(&buf)
+	}
+	if err := oracle.Append(pointConstLabel, buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	gammaBytes, err := oracle.Challenge(pointConstLabel, points*16)
+	if err != nil {
+		return nil, err
+	}
+	gamma := make([]*big.Int, points)
+	for s := range gamma {
+		gamma[s] = new(big.Int).SetBytes(gammaBytes[s*16 : (s+1)*16])
+	}
+	return gamma, nil
+}
+
+// appendPartialMask binds the partial evaluation of the mask of pf to the challenges of the columns in oracle.
+func appendPartialMask(oracle transcript.Transcript, pf *Proof) error {
+	var buf bytes.Buffer
+	pf.PartialMask.WriteTo(&buf)
+	return oracle.Append(colConstLabel, buf.Bytes())
+}
+
+// subStatement returns the statement of the k-th class of multi-point evaluation.
+func subStatement(stmt []byte, k int) []byte {
+	return binary.BigEndian.AppendUint32(append([]byte(nil), stmt...), uint32(k))
+}
+
+// appendBytes appends p to b, prefixed by its length.
+func appendBytes(b, p []byte) []byte {
+	b = binary.BigEndian.AppendUint64(b, uint64(len(p)))
+	return append(b, p...)
+}
+
+// readBytes reads a byte slice written by appendBytes from r.
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	var n uint64
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, errors.New("jindo: invalid length")
+	}
+
+	p := make([]byte, n)
+	r.Read(p)
+	return p, nil
+}
+
+// appendPolys appends ps to b, prefixed by its length.
+func appendPolys(b []byte, ps []ring.Poly) ([]byte, error) {
+	b = binary.BigEndian.AppendUint64(b, uint64(len(ps)))
+	for i := range ps {
+		p, err := ps[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = appendBytes(b, p)
+	}
+	return b, nil
+}
+
+// isPolysOf returns true if all polynomials in ps hold the levels of ringQ with ringQ.N() coefficients.
+func isPolysOf(ringQ *ring.Ring, ps []ring.Poly) bool {
+	for _, p := range ps {
+		if len(p.Coeffs) <= ringQ.Level() {
+			return false
+		}
+		for _, c := range p.Coeffs[:ringQ.Level()+1] {
+			if len(c) != ringQ.N() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// readPolys reads polynomials written by appendPolys from r.
+func readPolys(r *bytes.Reader) ([]ring.Poly, error) {
+	var n uint64
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, errors.New("jindo: invalid length")
+	}
+
+	ps := make([]ring.Poly, n)
+	for i := range ps {
+		p, err := readBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := ps[i].UnmarshalBinary(p); err != nil {
+			return nil, err
+		}
+	}
+	return ps, nil
+}
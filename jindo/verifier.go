@@ -1,10 +1,16 @@
 package jindo
 
 import (
-	"crypto/sha3"
+	"errors"
+	"fmt"
+	"math"
 	"math/big"
+	"slices"
 
 	"github.com/sp301415/ringo-snark/math/bignum"
+	"github.com/sp301415/ringo-snark/math/csprng"
+	"github.com/sp301415/ringo-snark/profile"
+	"github.com/sp301415/ringo-snark/transcript"
 	"github.com/tuneinsight/lattigo/v6/ring"
 )
 
@@ -19,10 +25,23 @@ type Verifier[E bignum.Uint[E]] struct {
 	outCutOff ring.RNSScalar
 
 	ck *CommitKey
+
+	prof *profile.Profiler
 }
 
 // NewVerifier creates a new [Verifier].
+// The commit key is shared with other provers and verifiers with the same params and crs.
 func NewVerifier[E bignum.Uint[E]](params Parameters, crs []byte) *Verifier[E] {
+	return NewVerifierWithKey[E](params, GetCommitKey(params, crs))
+}
+
+// NewVerifierWithKey creates a new [Verifier] with the given commit key.
+// Panics if ck is not a key for params.
+func NewVerifierWithKey[E bignum.Uint[E]](params Parameters, ck *CommitKey) *Verifier[E] {
+	if !ck.matches(params) {
+		panic("commit key does not match parameters")
+	}
+
 	inCutOff := big.NewInt(1)
 	inCutOff.Lsh(inCutOff, uint(params.logInCutOff))
 	inCutOffRNS := params.ringQ.NewRNSScalarFromBigint(inCutOff)
@@ -42,10 +61,38 @@ func NewVerifier[E bignum.Uint[E]](params Parameters, crs []byte) *Verifier[E] {
 		inCutOff:  inCutOffRNS,
 		outCutOff: outCutOffRNS,
 
-		ck: NewCommitKey(params, crs),
+		ck: ck,
 	}
 }
 
+// Parameters returns the parameters of the verifier.
+func (v *Verifier[E]) Parameters() Parameters {
+	return v.params
+}
+
+// CommitKey returns the commit key of the verifier.
+func (v *Verifier[E]) CommitKey() *CommitKey {
+	return v.ck
+}
+
+// SetProfiler sets the profiler which records the measurements of Verify and BatchVerify.
+// If prof is nil, profiling is disabled.
+func (v *Verifier[E]) SetProfiler(prof *profile.Profiler) {
+	v.prof = prof
+	v.ecd.prof = prof
+	v.ecd.rns.prof = prof
+	v.rnsOut.prof = prof
+}
+
+// CommitPublic recomputes the commitment of v by [Prover.CommitPublic].
+// Panics if len(v) > params.rank.
+func (v *Verifier[E]) CommitPublic(vec []E) *Commitment {
+	defer v.prof.Begin("jindo.CommitPublic").End()
+
+	com, _ := commitPublic(v.params, v.ecd, v.rnsOut, v.ck, vec, v.prof)
+	return com
+}
+
 // Verify verfies the polynomial commitment.
 func (v *Verifier[E]) Verify(x E, com []*Commitment, y []E, pf *Proof) bool {
 	switch {
@@ -53,49 +100,454 @@ func (v *Verifier[E]) Verify(x E, com []*Commitment, y []E, pf *Proof) bool {
 		panic("len(v) != params.batch")
 	}
 
-	oracle := sha3.NewSHAKE128()
-	v.ck.WriteRawTo(oracle)
-	for i := 0; i < v.params.batch; i++ {
-		com[i].WriteRawTo(oracle)
+	defer v.prof.Begin("jindo.Verify").End()
+
+	oracle := transcript.NewSHAKE128()
+	appendStatement(oracle, v.ck, com, []E{x}, nil)
+	return v.verify(oracle, []E{x}, com, [][]E{y}, pf)
+}
+
+// VerifyWithTranscript verifies the proof generated by [Prover.EvaluateWithTranscript],
+// drawing the challenges from oracle.
+// For an interactive proof, oracle is the transcript returned by [transcript.Challenger.Replay].
+func (v *Verifier[E]) VerifyWithTranscript(x E, com []*Commitment, y []E, pf *Proof, oracle transcript.Transcript) bool {
+	switch {
+	case len(com) != v.params.batch || len(y) != v.params.batch:
+		panic("len(v) != params.batch")
+	}
+
+	defer v.prof.Begin("jindo.Verify").End()
+
+	if err := appendStatement(oracle, v.ck, com, []E{x}, nil); err != nil {
+		return false
+	}
+	return v.verify(oracle, []E{x}, com, [][]E{y}, pf)
+}
+
+// ContinueVerify verifies the proof generated by [Prover.ContinueEvaluate],
+// continuing oracle in which the commit key, com and x are already bound.
+func (v *Verifier[E]) ContinueVerify(x E, com []*Commitment, y []E, pf *Proof, oracle transcript.Transcript) bool {
+	switch {
+	case len(com) != v.params.batch || len(y) != v.params.batch:
+		panic("len(v) != params.batch")
+	}
+
+	defer v.prof.Begin("jindo.Verify").End()
+
+	return v.verify(oracle, []E{x}, com, [][]E{y}, pf)
+}
+
+// verify verifies the polynomial commitment with batch size len(com) at each point of xs,
+// drawing the challenges from oracle, to which the statement is already bound.
+// The evaluations at xs[s] are ys[s].
+func (v *Verifier[E]) verify(oracle transcript.Transcript, xs []E, com []*Commitment, ys [][]E, pf *Proof) bool {
+	batch, batchOut, gamma, chals, pfInv, err := v.readChallenges(oracle, com, len(xs), pf)
+	if err != nil {
+		return false
+	}
+
+	outTarget := v.outerTarget(batchOut, com)
+	if !v.verifyOuterCommitment([][]ring.Poly{outTarget}, [][]ring.Poly{pf.InCommit}, [][]ring.Poly{pfInv.InCommit}, []float64{v.params.inComDcmpTwoNm})[0] {
+		return false
+	}
+
+	inTarget := v.innerTarget(chals, pf, pfInv)
+	res := slices.Concat(pf.Encode, pf.MLWE)
+	resInv := slices.Concat(pfInv.Encode, pfInv.MLWE)
+	if !v.verifyInnerCommitment([][]ring.Poly{inTarget}, [][]ring.Poly{res}, [][]ring.Poly{resInv}, []float64{v.params.resTwoNm})[0] {
+		return false
+	}
+
+	if !v.verifyConsistency(xs, gamma, chals, pf) {
+		return false
+	}
+
+	for s := range xs {
+		if !v.verifyEval(xs[s], batch, ys[s], pfInv.Partial[s*v.params.cols:(s+1)*v.params.cols]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// VerifyMulti verifies the multi-point evaluation of the polynomial commitment.
+// See [Prover.EvaluateMulti] for the meaning of points, which and y.
+func (v *Verifier[E]) VerifyMulti(points []E, which [][]int, com []*Commitment, y [][]E, pf *MultiProof) bool {
+	switch {
+	case len(com) != v.params.batch:
+		panic("len(com) != params.batch")
+	case len(y) != len(points):
+		panic("len(points) != len(y)")
 	}
-	oracle.Write(x.Marshal())
+	checkWhich(v.params, points, which)
 
-	var batch, batchOut []ring.Poly
-	if v.params.batch > 1 {
-		batch = make([]ring.Poly, v.params.batch)
-		batchOut = make([]ring.Poly, v.params.batch)
-		batchBytes := make([]byte, v.params.batch*16)
-		for i := 0; i < v.params.batch; i++ {
-			oracle.Read(batchBytes[i*16 : (i+1)*16])
+	for k := range which {
+		switch {
+		case len(y[k]) != len(which[k]):
+			panic(fmt.Sprintf("len(y[%v]) != len(which[%v])", k, k))
+		}
+	}
+
+	members, classPoints := multiClasses(v.params.batch, which)
+	if pf == nil || len(pf.Proofs) != len(members) {
+		return false
+	}
+
+	defer v.prof.Begin("jindo.VerifyMulti").End()
+
+	stmt := multiStatement(v.ck, com, points, which)
+
+	for c := range members {
+		comSub := make([]*Commitment, len(members[c]))
+		for l, i := range members[c] {
+			comSub[l] = com[i]
+		}
+		xs := make([]E, len(classPoints[c]))
+		ySub := make([][]E, len(classPoints[c]))
+		for s, k := range classPoints[c] {
+			xs[s] = points[k]
+			ySub[s] = make([]E, len(members[c]))
+			for l, i := range members[c] {
+				ySub[s][l] = y[k][slices.Index(which[k], i)]
+			}
+		}
+
+		oracle := transcript.NewSHAKE128()
+		appendStatement(oracle, v.ck, comSub, xs, subStatement(stmt, c))
+		if !v.verify(oracle, xs, comSub, ySub, pf.Proofs[c]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// BatchVerify verifies multiple polynomial commitments at once.
+// The consistency and evaluation checks are combined
+// using a random linear combination chosen by the verifier.
+// The commitment checks are combined using random small challenges r_k chosen by the verifier,
+// from the same challenge space as the batching constants.
+// The combined check of a chunk of proofs computes the remainder of sum_k r_k times the equation of the k-th proof,
+// and bounds its norm by sum_k ||r_k||_1 times the bound of a single proof.
+// As with the batching constants, passing it only guarantees relaxed openings of the proofs in the chunk.
+// A chunk is closed before its bound reaches half of the modulus,
+// so the proofs are combined only where the norm analysis allows:
+// if a single proof already has no room, as for the outer commitments of small batches,
+// its commitment checks are done separately.
+// Each entry of the commit key is read once for all chunks.
+//
+// If a combined check fails, it is repeated on the halves of the chunk
+// using the terms of each proof computed for the combination,
+// until the failing proofs are found, which are then checked as in [Verifier.Verify].
+// It returns true only if all proofs are valid, and the result of each proof.
+func (v *Verifier[E]) BatchVerify(x []E, com [][]*Commitment, y [][]E, pf []*Proof) (bool, []bool) {
+	switch {
+	case len(x) != len(com) || len(x) != len(y) || len(x) != len(pf):
+		panic("len(x), len(com), len(y), len(pf) are not equal")
+	}
+
+	for i := range x {
+		switch {
+		case len(com[i]) != v.params.batch || len(y[i]) != v.params.batch:
+			panic("len(v) != params.batch")
+		}
+	}
+
+	if len(x) == 0 {
+		return true, []bool{}
+	}
+
+	defer v.prof.Begin("jindo.BatchVerify").End()
+
+	oracles := make([]transcript.Transcript, len(x))
+	for i := range oracles {
+		oracles[i] = transcript.NewSHAKE128()
+		appendStatement(oracles[i], v.ck, com[i], []E{x[i]}, nil)
+	}
+	return v.batchVerify(oracles, x, com, y, pf)
+}
+
+// ContinueBatchVerify is the same as [Verifier.BatchVerify],
+// but verifies the proofs generated by [Prover.ContinueEvaluate],
+// continuing oracles[i] for the i-th proof.
+func (v *Verifier[E]) ContinueBatchVerify(x []E, com [][]*Commitment, y [][]E, pf []*Proof, oracles []transcript.Transcript) (bool, []bool) {
+	switch {
+	case len(x) != len(com) || len(x) != len(y) || len(x) != len(pf) || len(x) != len(oracles):
+		panic("len(x), len(com), len(y), len(pf), len(oracles) are not equal")
+	}
+
+	for i := range x {
+		switch {
+		case len(com[i]) != v.params.batch || len(y[i]) != v.params.batch:
+			panic("len(v) != params.batch")
+		}
+	}
+
+	if len(x) == 0 {
+		return true, []bool{}
+	}
+
+	defer v.prof.Begin("jindo.BatchVerify").End()
+
+	return v.batchVerify(oracles, x, com, y, pf)
+}
+
+// batchVerify verifies multiple polynomial commitments at once,
+// drawing the challenges of the i-th proof from oracles[i].
+func (v *Verifier[E]) batchVerify(oracles []transcript.Transcript, x []E, com [][]*Commitment, y [][]E, pf []*Proof) (bool, []bool) {
+	isValid := make([]bool, len(x))
+	idx := make([]int, 0, len(x))
+
+	batch := make([][]ring.Poly, len(x))
+	chals := make([][]ring.Poly, len(x))
+	pfInv := make([]*Proof, len(x))
+	outTarget := make([][]ring.Poly, len(x))
+	inTarget := make([][]ring.Poly, len(x))
+	for i := range x {
+		var batchOut []ring.Poly
+		var err error
+		batch[i], batchOut, _, chals[i], pfInv[i], err = v.readChallenges(oracles[i], com[i], 1, pf[i])
+		if err != nil {
+			continue
+		}
+		isValid[i] = true
+		idx = append(idx, i)
+
+		outTarget[i] = v.outerTarget(batchOut, com[i])
+		inTarget[i] = v.innerTarget(chals[i], pf[i], pfInv[i])
+	}
+
+	if len(idx) == 0 {
+		return false, isValid
+	}
+
+	u := csprng.NewUniformSampler()
+	weight := make([]ring.Poly, len(x))
+	weightOut := make([]ring.Poly, len(x))
+	weightOne := make([]float64, len(x))
+	weightBytes := make([]byte, 16)
+	for _, i := range idx {
+		u.Read(weightBytes)
+		coeffs := challengeCoeffs(v.params, weightBytes)
+		for _, c := range coeffs {
+			weightOne[i] += math.Abs(float64(c))
+		}
+
+		weight[i] = v.params.ringQ.NewPoly()
+		smallChallengeTo(v.params, v.params.ringQ, weight[i], coeffs)
+		weightOut[i] = v.params.ringQOut.NewPoly()
+		smallChallengeTo(v.params, v.params.ringQOut, weightOut[i], coeffs)
+	}
+	v.prof.Count(profile.OpNTT, 2*len(idx))
+
+	inCom := make([][]ring.Poly, len(x))
+	inComInv := make([][]ring.Poly, len(x))
+	res := make([][]ring.Poly, len(x))
+	resInv := make([][]ring.Poly, len(x))
+	for _, i := range idx {
+		inCom[i], inComInv[i] = pf[i].InCommit, pfInv[i].InCommit
+		res[i] = slices.Concat(pf[i].Encode, pf[i].MLWE)
+		resInv[i] = slices.Concat(pfInv[i].Encode, pfInv[i].MLWE)
+	}
+
+	outChunks := chunkProofs(v.params.ringQOut, idx, weightOne, v.params.inComDcmpTwoNm)
+	bisectProofs(outChunks, isValid, func(chunks [][]int) []bool {
+		target, inComChunk, inComInvChunk, nm := v.combineChunks(v.params.ringQOut, chunks, weightOut, weightOne, outTarget, inCom, inComInv, v.params.inComDcmpTwoNm)
+		return v.verifyOuterCommitment(target, inComChunk, inComInvChunk, nm)
+	})
+
+	inChunks := chunkProofs(v.params.ringQ, idx, weightOne, v.params.resTwoNm)
+	bisectProofs(inChunks, isValid, func(chunks [][]int) []bool {
+		target, resChunk, resInvChunk, nm := v.combineChunks(v.params.ringQ, chunks, weight, weightOne, inTarget, res, resInv, v.params.resTwoNm)
+		return v.verifyInnerCommitment(target, resChunk, resInvChunk, nm)
+	})
+
+	wMod := v.params.ringQ.SubRings[0].Modulus
+	for i := range v.params.ringQ.SubRings {
+		wMod = min(wMod, v.params.ringQ.SubRings[i].Modulus)
+	}
+
+	consTest := make([]ring.Poly, len(x))
+	evalTest := make([]E, len(x))
+	consAcc := v.params.ringQ.NewPoly()
+	evalAcc := x[0].New()
+	mul := x[0].New()
+	scalar := x[0].New()
+	for _, i := range idx {
+		consTest[i] = v.params.ringQ.NewPoly()
+		v.consistencyTo(consTest[i], []E{x[i]}, nil, chals[i], pf[i])
+		v.params.ringQ.MulScalarThenAdd(consTest[i], u.SampleN(wMod), consAcc)
+
+		evalTest[i] = x[0].New()
+		v.evalDiffTo(evalTest[i], x[i], batch[i], y[i], pfInv[i].Partial)
+		scalar.MustSetRandom()
+		evalAcc.Add(evalAcc, mul.Mul(evalTest[i], scalar))
+	}
+
+	zero := v.params.ringQ.NewPoly()
+	if !consAcc.Equal(&zero) || evalAcc.Cmp(x[0].New()) != 0 {
+		for _, i := range idx {
+			if !consTest[i].Equal(&zero) || evalTest[i].Cmp(x[0].New()) != 0 {
+				isValid[i] = false
+			}
+		}
+	}
+
+	return !slices.Contains(isValid, false), isValid
+}
+
+// chunkProofs splits the proofs idx into consecutive chunks,
+// whose combined commitment checks are bounded by sum_k weightOne[k] * nm.
+// A chunk is closed before its bound reaches half of the modulus of ringQ,
+// and a proof with no room is put in a chunk alone.
+func chunkProofs(ringQ *ring.Ring, idx []int, weightOne []float64, nm float64) [][]int {
+	qHalf, _ := new(big.Float).SetInt(ringQ.ModulusAtLevel[ringQ.Level()]).Float64()
+	qHalf /= 2
+
+	var chunks [][]int
+	var chunk []int
+	var chunkNm float64
+	for _, i := range idx {
+		if len(chunk) > 0 && chunkNm+weightOne[i]*nm >= qHalf {
+			chunks = append(chunks, chunk)
+			chunk, chunkNm = nil, 0
+		}
+		chunk = append(chunk, i)
+		chunkNm += weightOne[i] * nm
+	}
+	return append(chunks, chunk)
+}
+
+// bisectProofs runs check on chunks, which returns the result of the check of each chunk,
+// and repeats it on the halves of the failed chunks
+// until the failed proofs are found, setting isValid to false for them.
+func bisectProofs(chunks [][]int, isValid []bool, check func(chunks [][]int) []bool) {
+	for len(chunks) > 0 {
+		ok := check(chunks)
+
+		var next [][]int
+		for c, chunk := range chunks {
+			switch {
+			case ok[c]:
+				continue
+			case len(chunk) == 1:
+				isValid[chunk[0]] = false
+			default:
+				next = append(next, chunk[:len(chunk)/2], chunk[len(chunk)/2:])
+			}
+		}
+		chunks = next
+	}
+}
+
+// combineChunks combines the terms of the commitment checks for each chunk,
+// where target[k] is the target of the k-th proof,
+// and res[k], resInv[k] are its response in NTT and coefficient form.
+// The terms of a chunk with one proof are returned as they are, with the norm bound nm.
+// Otherwise, they are combined with the weights, and the norm bound is scaled by the sum of weightOne.
+func (v *Verifier[E]) combineChunks(ringQ *ring.Ring, chunks [][]int, weight []ring.Poly, weightOne []float64, target, res, resInv [][]ring.Poly, nm float64) (targetOut, resOut, resInvOut [][]ring.Poly, nmOut []float64) {
+	targetOut = make([][]ring.Poly, len(chunks))
+	resOut = make([][]ring.Poly, len(chunks))
+	resInvOut = make([][]ring.Poly, len(chunks))
+	nmOut = make([]float64, len(chunks))
+	for c, chunk := range chunks {
+		if len(chunk) == 1 {
+			targetOut[c], resOut[c], resInvOut[c], nmOut[c] = target[chunk[0]], res[chunk[0]], resInv[chunk[0]], nm
+			continue
+		}
+
+		targetOut[c] = v.combine(ringQ, chunk, weight, target)
+		resOut[c] = v.combine(ringQ, chunk, weight, res)
+		resInvOut[c] = make([]ring.Poly, len(resOut[c]))
+		for i := range resOut[c] {
+			resInvOut[c][i] = ringQ.NewPoly()
+			ringQ.IMForm(resOut[c][i], resInvOut[c][i])
+			ringQ.INTT(resInvOut[c][i], resInvOut[c][i])
+		}
+		v.prof.Count(profile.OpNTT, len(resOut[c]))
+
+		for _, k := range chunk {
+			nmOut[c] += weightOne[k] * nm
+		}
+	}
+	return targetOut, resOut, resInvOut, nmOut
+}
+
+// combine returns sum_k weight[k] * p[k] for k in chunk.
+func (v *Verifier[E]) combine(ringQ *ring.Ring, chunk []int, weight []ring.Poly, p [][]ring.Poly) []ring.Poly {
+	pOut := make([]ring.Poly, len(p[chunk[0]]))
+	for i := range pOut {
+		pOut[i] = ringQ.NewPoly()
+		for _, k := range chunk {
+			ringQ.MulCoeffsMontgomeryThenAdd(weight[k], p[k][i], pOut[i])
+		}
+	}
+	v.prof.Count(profile.OpRingMul, len(chunk)*len(pOut))
+	return pOut
+}
+
+// readChallenges reads the challenges from oracle, to which the statement is already bound,
+// and returns the proof in coefficient form.
+// The batch size is len(com), and pf evaluates at the given number of points.
+// The challenges combining the points are nil for a single point.
+// It returns an error if pf or com do not have the shape of params.
+func (v *Verifier[E]) readChallenges(oracle transcript.Transcript, com []*Commitment, points int, pf *Proof) (batch, batchOut []ring.Poly, gamma []*big.Int, chals []ring.Poly, pfInv *Proof, err error) {
+	if !pf.isValid(v.params, points) {
+		return nil, nil, nil, nil, nil, errors.New("jindo: invalid proof")
+	}
+	for i := range com {
+		if !com[i].isValid(v.params) {
+			return nil, nil, nil, nil, nil, errors.New("jindo: invalid commitment")
+		}
+	}
+
+	if len(com) > 1 {
+		batch = make([]ring.Poly, len(com))
+		batchOut = make([]ring.Poly, len(com))
+		batchBytes, err := oracle.Challenge(batchConstLabel, len(com)*16)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		for i := 0; i < len(com); i++ {
 			batch[i] = v.params.ringQ.NewPoly()
 			encodeChallengeTo(v.params, v.params.ringQ, batch[i], batchBytes[i*16:(i+1)*16])
 			batchOut[i] = v.params.ringQOut.NewPoly()
 			encodeChallengeTo(v.params, v.params.ringQOut, batchOut[i], batchBytes[i*16:(i+1)*16])
 		}
-		oracle.Reset()
+		v.prof.Count(profile.OpNTT, 2*len(com))
+	}
 
-		v.ck.WriteRawTo(oracle)
-		for i := 0; i < v.params.batch; i++ {
-			com[i].WriteRawTo(oracle)
+	if points > 1 {
+		if gamma, err = readPointChallenges(oracle, pf, points); err != nil {
+			return nil, nil, nil, nil, nil, err
 		}
-		oracle.Write(x.Marshal())
-		oracle.Write(batchBytes)
+		if err := appendPartialMask(oracle, pf); err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+	} else if err := appendPartial(oracle, pf); err != nil {
+		return nil, nil, nil, nil, nil, err
 	}
 
-	for i := range pf.Partial {
-		pf.Partial[i].WriteTo(oracle)
+	chalBytes, err := oracle.Challenge(colConstLabel, v.params.cols*16)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
 	}
-	pf.PartialMask.WriteTo(oracle)
-
-	chals := make([]ring.Poly, v.params.cols)
-	chalBytes := make([]byte, 16)
+	chals = make([]ring.Poly, v.params.cols)
 	for i := 0; i < v.params.cols; i++ {
 		chals[i] = v.params.ringQ.NewPoly()
-		oracle.Read(chalBytes)
-		encodeChallengeTo(v.params, v.params.ringQ, chals[i], chalBytes)
+		encodeChallengeTo(v.params, v.params.ringQ, chals[i], chalBytes[i*16:(i+1)*16])
 	}
+	v.prof.Count(profile.OpNTT, v.params.cols)
 
-	pfInv := NewProof(v.params)
+	pfInv = v.invProof(pf)
+
+	return batch, batchOut, gamma, chals, pfInv, nil
+}
+
+// invProof returns pf in coefficient form.
+func (v *Verifier[E]) invProof(pf *Proof) *Proof {
+	pfInv := newProof(v.params, len(pf.Partial)/v.params.cols)
 	for i := range pf.Partial {
 		v.params.ringQ.IMForm(pf.Partial[i], pfInv.Partial[i])
 		v.params.ringQ.INTT(pfInv.Partial[i], pfInv.Partial[i])
@@ -112,63 +564,38 @@ func (v *Verifier[E]) Verify(x E, com []*Commitment, y []E, pf *Proof) bool {
 		v.params.ringQOut.IMForm(pf.InCommit[i], pfInv.InCommit[i])
 		v.params.ringQOut.INTT(pfInv.InCommit[i], pfInv.InCommit[i])
 	}
+	v.prof.Count(profile.OpNTT, len(pf.Partial)+len(pf.Encode)+len(pf.MLWE)+len(pf.InCommit))
 
-	if !v.verifyOuterCommitment(batchOut, com, pf, pfInv) {
-		return false
-	}
-
-	if !v.verifyInnerCommitment(chals, pf, pfInv) {
-		return false
-	}
-
-	if !v.verifyConsistency(x, chals, pf) {
-		return false
-	}
-
-	if !v.verifyEval(x, batch, y, pfInv) {
-		return false
-	}
-
-	return true
+	return pfInv
 }
 
-// verifyOuterCommitment verfies the outer commitment.
-func (v *Verifier[E]) verifyOuterCommitment(batch []ring.Poly, com []*Commitment, pf, pfInv *Proof) bool {
-	inDcmpInv := append(pfInv.InCommit, make([]ring.Poly, v.params.outMSISRank)...)
-
-	cutoff := inDcmpInv[v.params.inComDcmpLen:]
-	for i := range v.params.outMSISRank {
-		cutoff[i] = v.params.ringQOut.NewPoly()
-		if v.params.batch > 1 {
-			for j := range v.params.batch {
-				v.params.ringQOut.MulCoeffsMontgomeryThenAdd(com[j].Value[i], batch[j], cutoff[i])
+// outerTarget returns outCutOff * sum_j b_j com_j,
+// which the outer commitment check compares to the commit key applied to the inner commitments.
+func (v *Verifier[E]) outerTarget(batch []ring.Poly, com []*Commitment) []ring.Poly {
+	target := make([]ring.Poly, v.params.outMSISRank)
+	for i := range target {
+		target[i] = v.params.ringQOut.NewPoly()
+		if len(com) > 1 {
+			for j := range com {
+				v.params.ringQOut.MulCoeffsMontgomeryThenAdd(com[j].Value[i], batch[j], target[i])
 			}
+			v.prof.Count(profile.OpRingMul, len(com))
 		} else {
-			cutoff[i].Copy(com[0].Value[i])
-		}
-
-		v.params.ringQOut.MulRNSScalarMontgomery(cutoff[i], v.outCutOff, cutoff[i])
-
-		for j := range v.params.inComDcmpLen {
-			v.params.ringQOut.MulCoeffsMontgomeryThenSub(v.ck.Out[i][j], pf.InCommit[j], cutoff[i])
+			target[i].Copy(com[0].Value[i])
 		}
 
-		v.params.ringQOut.IMForm(cutoff[i], cutoff[i])
-		v.params.ringQOut.INTT(cutoff[i], cutoff[i])
+		v.params.ringQOut.MulRNSScalarMontgomery(target[i], v.outCutOff, target[i])
 	}
-
-	return v.verifyNorm(v.params.ringQOut, v.rnsOut, inDcmpInv, v.params.inComDcmpTwoNm)
+	return target
 }
 
-// verifyInnerCommitment verfies the inner commitment.
-func (v *Verifier[E]) verifyInnerCommitment(chals []ring.Poly, pf, pfInv *Proof) bool {
-	resInv := append(pfInv.Encode, pfInv.MLWE...)
-	resInv = append(resInv, make([]ring.Poly, v.params.inMSISRank)...)
-
+// innerTarget returns inCutOff * (sum_j c_j InCom_j + InCom_cols) - MLWE_{mlweRank+i},
+// which the inner commitment check compares to the commit key applied to the response.
+func (v *Verifier[E]) innerTarget(chals []ring.Poly, pf, pfInv *Proof) []ring.Poly {
+	target := make([]ring.Poly, v.params.inMSISRank)
 	inComQ := v.params.ringQ.NewPoly()
-	cutoff := resInv[v.params.rows+v.params.mlweRank+v.params.inMSISRank:]
-	for i := range v.params.inMSISRank {
-		cutoff[i] = v.params.ringQ.NewPoly()
+	for i := range target {
+		target[i] = v.params.ringQ.NewPoly()
 		for j := range v.params.cols + 1 {
 			v.embQOutToQ.ModUpQtoP(v.params.ringQOut.Level(), v.params.ringQ.Level(), pfInv.InCommit[j*v.params.inMSISRank+i], inComQ)
 
@@ -176,66 +603,181 @@ func (v *Verifier[E]) verifyInnerCommitment(chals []ring.Poly, pf, pfInv *Proof)
 			v.params.ringQ.NTT(inComQ, inComQ)
 
 			if j == v.params.cols {
-				v.params.ringQ.Add(inComQ, cutoff[i], cutoff[i])
+				v.params.ringQ.Add(inComQ, target[i], target[i])
 			} else {
-				v.params.ringQ.MulCoeffsMontgomeryThenAdd(inComQ, chals[j], cutoff[i])
+				v.params.ringQ.MulCoeffsMontgomeryThenAdd(inComQ, chals[j], target[i])
 			}
 		}
 
-		v.params.ringQ.MulRNSScalarMontgomery(cutoff[i], v.inCutOff, cutoff[i])
+		v.params.ringQ.MulRNSScalarMontgomery(target[i], v.inCutOff, target[i])
+		v.params.ringQ.Sub(target[i], pf.MLWE[v.params.mlweRank+i], target[i])
+	}
+	v.prof.Count(profile.OpRingMul, v.params.inMSISRank*v.params.cols)
+	v.prof.Count(profile.OpNTT, v.params.inMSISRank*(v.params.cols+1))
 
+	return target
+}
+
+// verifyOuterCommitment verfies the outer commitment checks,
+// where target[k] is given by [Verifier.outerTarget],
+// and inCom[k], inComInv[k] are the inner commitments in NTT and coefficient form.
+// The k-th check passes if the norm of the inner commitments and the remainder is less than nm[k].
+// Each entry of the commit key is read once for all checks.
+func (v *Verifier[E]) verifyOuterCommitment(target, inCom, inComInv [][]ring.Poly, nm []float64) []bool {
+	cutoff := make([][]ring.Poly, len(target))
+	for k := range target {
+		cutoff[k] = make([]ring.Poly, v.params.outMSISRank)
+		for i := range cutoff[k] {
+			cutoff[k][i] = *target[k][i].CopyNew()
+		}
+	}
+
+	ckBuf := v.params.ringQOut.NewPoly()
+	for i := range v.params.outMSISRank {
+		for j := range v.params.inComDcmpLen {
+			ck := v.ck.out(i, j, ckBuf)
+			for k := range target {
+				v.params.ringQOut.MulCoeffsMontgomeryThenSub(ck, inCom[k][j], cutoff[k][i])
+			}
+		}
+
+		for k := range target {
+			v.params.ringQOut.IMForm(cutoff[k][i], cutoff[k][i])
+			v.params.ringQOut.INTT(cutoff[k][i], cutoff[k][i])
+		}
+	}
+	v.prof.Count(profile.OpRingMul, len(target)*v.params.outMSISRank*v.params.inComDcmpLen)
+	v.prof.Count(profile.OpNTT, len(target)*v.params.outMSISRank)
+
+	ok := make([]bool, len(target))
+	for k := range target {
+		ok[k] = v.verifyNorm(v.params.ringQOut, v.rnsOut, slices.Concat(inComInv[k], cutoff[k]), nm[k])
+	}
+	return ok
+}
+
+// verifyInnerCommitment verfies the inner commitment checks,
+// where target[k] is given by [Verifier.innerTarget],
+// and res[k], resInv[k] are the encodings followed by the MLWE responses in NTT and coefficient form.
+// The k-th check passes if the norm of the response and the remainder is less than nm[k].
+// Each entry of the commit key is read once for all checks.
+func (v *Verifier[E]) verifyInnerCommitment(target, res, resInv [][]ring.Poly, nm []float64) []bool {
+	cutoff := make([][]ring.Poly, len(target))
+	for k := range target {
+		cutoff[k] = make([]ring.Poly, v.params.inMSISRank)
+		for i := range cutoff[k] {
+			cutoff[k][i] = *target[k][i].CopyNew()
+		}
+	}
+
+	ckBuf := v.params.ringQ.NewPoly()
+	for i := range v.params.inMSISRank {
 		for j := range v.params.rows {
-			v.params.ringQ.MulCoeffsMontgomeryThenSub(v.ck.In[i][j], pf.Encode[j], cutoff[i])
+			ck := v.ck.in(i, j, ckBuf)
+			for k := range target {
+				v.params.ringQ.MulCoeffsMontgomeryThenSub(ck, res[k][j], cutoff[k][i])
+			}
 		}
 		for j := range v.params.mlweRank {
-			v.params.ringQ.MulCoeffsMontgomeryThenSub(v.ck.MLWE[i][j], pf.MLWE[j], cutoff[i])
+			ck := v.ck.mlwe(i, j, ckBuf)
+			for k := range target {
+				v.params.ringQ.MulCoeffsMontgomeryThenSub(ck, res[k][v.params.rows+j], cutoff[k][i])
+			}
 		}
-		v.params.ringQ.Sub(cutoff[i], pf.MLWE[v.params.mlweRank+i], cutoff[i])
 
-		v.params.ringQ.IMForm(cutoff[i], cutoff[i])
-		v.params.ringQ.INTT(cutoff[i], cutoff[i])
+		for k := range target {
+			v.params.ringQ.IMForm(cutoff[k][i], cutoff[k][i])
+			v.params.ringQ.INTT(cutoff[k][i], cutoff[k][i])
+		}
 	}
+	v.prof.Count(profile.OpRingMul, len(target)*v.params.inMSISRank*(v.params.rows+v.params.mlweRank))
+	v.prof.Count(profile.OpNTT, len(target)*v.params.inMSISRank)
 
-	return v.verifyNorm(v.params.ringQ, v.ecd.rns, resInv, v.params.resTwoNm)
+	ok := make([]bool, len(target))
+	for k := range target {
+		ok[k] = v.verifyNorm(v.params.ringQ, v.ecd.rns, slices.Concat(resInv[k], cutoff[k]), nm[k])
+	}
+	return ok
 }
 
-// verifyConsistency verifies the consistency of the proof.
-func (v *Verifier[E]) verifyConsistency(x E, challenge []ring.Poly, pf *Proof) bool {
+// verifyConsistency verifies the consistency of the proof at each point of xs.
+func (v *Verifier[E]) verifyConsistency(xs []E, gamma []*big.Int, challenge []ring.Poly, pf *Proof) bool {
 	zero := v.params.ringQ.NewPoly()
 	test := v.params.ringQ.NewPoly()
+	v.consistencyTo(test, xs, gamma, challenge, pf)
+	return test.Equal(&zero)
+}
+
+// consistencyTo computes the consistency test polynomial to testOut,
+// which is zero if the proof is consistent.
+// At multiple points, the tests of each point are combined by gamma,
+// which are the challenges combining the points.
+func (v *Verifier[E]) consistencyTo(testOut ring.Poly, xs []E, gamma []*big.Int, challenge []ring.Poly, pf *Proof) {
+	testOut.Zero()
 
-	left := leftVec(v.params, x)
+	left := make([][]E, len(xs))
+	for s, x := range xs {
+		left[s] = leftVec(v.params, x)
+	}
 	leftEcd := v.params.ringQ.NewPoly()
+	leftComb := v.params.ringQ.NewPoly()
 
 	for i := 0; i < v.params.rows; i++ {
-		v.ecd.encodeTo(leftEcd, []E{left[i]})
-		v.params.ringQ.MulCoeffsMontgomeryThenAdd(leftEcd, pf.Encode[i], test)
+		if gamma == nil {
+			v.ecd.encodeTo(leftComb, []E{left[0][i]})
+		} else {
+			leftComb.Zero()
+			for s := range xs {
+				v.ecd.encodeTo(leftEcd, []E{left[s][i]})
+				v.params.ringQ.MulScalarBigintThenAdd(leftEcd, gamma[s], leftComb)
+			}
+		}
+		v.params.ringQ.MulCoeffsMontgomeryThenAdd(leftComb, pf.Encode[i], testOut)
 	}
 
-	for i := 0; i < v.params.cols; i++ {
-		v.params.ringQ.MulCoeffsMontgomeryThenSub(challenge[i], pf.Partial[i], test)
+	partial := v.params.ringQ.NewPoly()
+	gammaNeg := new(big.Int)
+	for s := range xs {
+		partial.Zero()
+		for i := 0; i < v.params.cols; i++ {
+			v.params.ringQ.MulCoeffsMontgomeryThenAdd(challenge[i], pf.Partial[s*v.params.cols+i], partial)
+		}
+		if gamma == nil {
+			v.params.ringQ.Sub(testOut, partial, testOut)
+		} else {
+			v.params.ringQ.MulScalarBigintThenAdd(partial, gammaNeg.Neg(gamma[s]), testOut)
+		}
 	}
-	v.params.ringQ.Sub(test, pf.PartialMask, test)
+	v.params.ringQ.Sub(testOut, pf.PartialMask, testOut)
+	v.prof.Count(profile.OpRingMul, v.params.rows+len(xs)*v.params.cols)
+}
 
-	return test.Equal(&zero)
+// verifyEval verifies the evaluation at x, whose partial evaluations in coefficient form are partial.
+func (v *Verifier[E]) verifyEval(x E, batch []ring.Poly, y []E, partial []ring.Poly) bool {
+	test := x.New()
+	v.evalDiffTo(test, x, batch, y, partial)
+	return test.Cmp(x.New()) == 0
 }
 
-// verifyEval verifies the evaluation.
-func (v *Verifier[E]) verifyEval(x E, batch []ring.Poly, y []E, pfInv *Proof) bool {
+// evalDiffTo computes the difference between the evaluation
+// claimed by the partial evaluations at x in coefficient form and y to testOut,
+// which is zero if the evaluation is correct.
+func (v *Verifier[E]) evalDiffTo(testOut E, x E, batch []ring.Poly, y []E, partial []ring.Poly) {
 	right := rightVec(v.params, x)
 
 	yBatch := x.New()
 	mul := x.New()
-	if v.params.batch > 1 {
+	if len(y) > 1 {
 		batchInv := v.params.ringQ.NewPoly()
 		batchDcd := make([]E, v.params.slots)
 		for i := 0; i < v.params.slots; i++ {
 			batchDcd[i] = x.New()
 		}
 
-		for i := 0; i < v.params.batch; i++ {
+		for i := 0; i < len(y); i++ {
 			v.params.ringQ.IMForm(batch[i], batchInv)
 			v.params.ringQ.INTT(batchInv, batchInv)
+			v.prof.Count(profile.OpNTT, 1)
 			v.ecd.DecodeTo(batchDcd, batchInv)
 			yBatch.Add(yBatch, mul.Mul(batchDcd[0], y[i]))
 		}
@@ -243,19 +785,18 @@ func (v *Verifier[E]) verifyEval(x E, batch []ring.Poly, y []E, pfInv *Proof) bo
 		yBatch.Set(y[0])
 	}
 
-	test := x.New()
+	testOut.SetUint64(0)
 	dcd := make([]E, v.params.slots)
 	for i := 0; i < v.params.slots; i++ {
 		dcd[i] = x.New()
 	}
 	for i := 0; i < v.params.cols; i++ {
-		v.ecd.DecodeTo(dcd, pfInv.Partial[i])
+		v.ecd.DecodeTo(dcd, partial[i])
 		for j := 0; j < v.params.slots; j++ {
-			test.Add(test, mul.Mul(right[i*v.params.slots+j], dcd[j]))
+			testOut.Add(testOut, mul.Mul(right[i*v.params.slots+j], dcd[j]))
 		}
 	}
-
-	return test.Cmp(yBatch) == 0
+	testOut.Sub(testOut, yBatch)
 }
 
 // verifyNorm checks the norm of the given polynomials.
@@ -1,7 +1,10 @@
 // Package bignum implements various utility functions regarding numeric types.
 package bignum
 
-import "math/big"
+import (
+	"io"
+	"math/big"
+)
 
 // Uint is an interface of elements in bigpoly.
 // This captures the code generated by gnark-crypto/goff.
@@ -44,3 +47,28 @@ func Exp[E Uint[E]](x E, e uint64) E {
 	}
 	return r
 }
+
+// Modulus returns the modulus of the field E,
+// i.e. the modulus of the integers embedded by SetInt64 and SetBigInt.
+func Modulus[E Uint[E]]() *big.Int {
+	var z E
+	pNegOne := z.New().SetInt64(-1).BigInt(new(big.Int))
+	return pNegOne.Add(pNegOne, big.NewInt(1))
+}
+
+// MustSetRandomFrom sets z to a uniformly random element using the randomness read from r, and returns z.
+// It reads 128 bits more than the size of E, so the statistical distance from uniform is at most 2^-128.
+// If r is nil, it calls z.MustSetRandom.
+//
+// Panics if reading from r fails.
+func MustSetRandomFrom[E Uint[E]](z E, r io.Reader) E {
+	if r == nil {
+		return z.MustSetRandom()
+	}
+
+	buf := make([]byte, 8*z.Limb()+16)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		panic(err)
+	}
+	return z.SetBigInt(new(big.Int).SetBytes(buf))
+}
@@ -20,6 +20,10 @@ type CyclicTransformer[E bignum.Uint[E]] struct {
 	tw      []E
 	twInv   []E
 	rankInv E
+
+	// points are the roots of unity where the NTT evaluates,
+	// in the order of the NTT output.
+	points []E
 }
 
 // NewCyclicTransformer creates a new [CyclicTransformer].
@@ -86,11 +90,24 @@ func NewCyclicTransformer[E bignum.Uint[E]](rank int) *CyclicTransformer[E] {
 	rankInv := z.New().SetUint64(uint64(rank))
 	rankInv.Inverse(rankInv)
 
+	// The NTT of X is the list of the evaluation points.
+	points := make([]E, rank)
+	for i := range points {
+		points[i] = z.New()
+	}
+	if rank == 1 {
+		points[0].SetUint64(1)
+	} else {
+		points[1].SetUint64(1)
+		nttInPlace(points, tw)
+	}
+
 	return &CyclicTransformer[E]{
 		rank:    rank,
 		tw:      tw,
 		twInv:   twInv,
 		rankInv: rankInv,
+		points:  points,
 	}
 }
 
@@ -135,6 +152,63 @@ func (ntt *CyclicTransformer[E]) InvNTTTo(vOut, v []E) {
 	scalarMulVecTo(vOut, vOut, ntt.rankInv)
 }
 
+// LagrangeBasisAt returns the Lagrange basis of the NTT domain evaluated at x.
+// The inner product of v and the output is INTT(v) evaluated at x,
+// so the output can be reused to evaluate the interpolants of many vectors at x.
+//
+// For the points w_i, it uses the barycentric formula
+// L_i(x) = w_i (x^N - 1) / (N (x - w_i)), with a single inversion.
+func (ntt *CyclicTransformer[E]) LagrangeBasisAt(x E) []E {
+	var z E
+
+	basis := make([]E, ntt.rank)
+	for i := range basis {
+		basis[i] = z.New().Sub(x, ntt.points[i])
+	}
+
+	zero := z.New()
+	for i := range basis {
+		if basis[i].Cmp(zero) == 0 {
+			for j := range basis {
+				basis[j].SetUint64(0)
+			}
+			basis[i].SetUint64(1)
+			return basis
+		}
+	}
+
+	// Batch inversion of x - w_i.
+	prefix := make([]E, ntt.rank)
+	prefix[0] = z.New().Set(basis[0])
+	for i := 1; i < ntt.rank; i++ {
+		prefix[i] = z.New().Mul(prefix[i-1], basis[i])
+	}
+	inv := z.New().Inverse(prefix[ntt.rank-1])
+	tmp := z.New()
+	for i := ntt.rank - 1; i > 0; i-- {
+		tmp.Mul(inv, prefix[i-1])
+		inv.Mul(inv, basis[i])
+		basis[i].Set(tmp)
+	}
+	basis[0].Set(inv)
+
+	scale := bignum.Exp(x, uint64(ntt.rank))
+	scale.Sub(scale, z.New().SetUint64(1))
+	scale.Mul(scale, ntt.rankInv)
+	for i := range basis {
+		basis[i].Mul(basis[i], ntt.points[i])
+		basis[i].Mul(basis[i], scale)
+	}
+
+	return basis
+}
+
+// EvaluateInterpolant returns INTT(v) evaluated at x, without computing INTT(v).
+// To evaluate many vectors at the same point, use [CyclicTransformer.LagrangeBasisAt] and [InnerProduct].
+func (ntt *CyclicTransformer[E]) EvaluateInterpolant(v []E, x E) E {
+	return InnerProduct(v, ntt.LagrangeBasisAt(x))
+}
+
 // Rank returns the rank of the transformer.
 func (ntt CyclicTransformer[E]) Rank() int {
 	return ntt.rank
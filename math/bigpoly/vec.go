@@ -119,6 +119,17 @@ func mulVecTo[E bignum.Uint[E]](xOut, x0, x1 []E) {
 	}
 }
 
+// InnerProduct returns the inner product of x0 and x1.
+func InnerProduct[E bignum.Uint[E]](x0, x1 []E) E {
+	var z E
+	out, term := z.New(), z.New()
+	for i := range x0 {
+		term.Mul(x0[i], x1[i])
+		out.Add(out, term)
+	}
+	return out
+}
+
 // bitReverseInPlace reorders v into bit-reversal order in-place.
 func bitReverseInPlace[T any](v []T) {
 	var bit, j int
@@ -19,6 +19,16 @@ func NewCOSACSampler() *COSACSampler {
 	}
 }
 
+// NewCOSACSamplerWithSeed creates a new [COSACSampler], with user supplied seed.
+//
+// Panics when AES initialization fails.
+func NewCOSACSamplerWithSeed(seed []byte) *COSACSampler {
+	return &COSACSampler{
+		baseSampler:    NewUniformSamplerWithSeed(DeriveSeed(seed, "base")),
+		roundedSampler: NewRoundedGaussianSamplerWithSeed(DeriveSeed(seed, "rounded")),
+	}
+}
+
 // sampleRound samples a rounded Gaussian distribution.
 func (s *COSACSampler) sampleRound(cFrac, stdDev float64) int64 {
 	for {
@@ -49,6 +49,18 @@ type TwinCDTGaussianSampler struct {
 //
 // Panics when read from crypto/rand or AES initialization fails.
 func NewTwinCDTGaussianSampler(stdDev float64) *TwinCDTGaussianSampler {
+	return newTwinCDTGaussianSampler(stdDev, NewUniformSampler())
+}
+
+// NewTwinCDTGaussianSamplerWithSeed creates a new [TwinCDTGaussianSampler], with user supplied seed.
+//
+// Panics when AES initialization fails.
+func NewTwinCDTGaussianSamplerWithSeed(stdDev float64, seed []byte) *TwinCDTGaussianSampler {
+	return newTwinCDTGaussianSampler(stdDev, NewUniformSamplerWithSeed(seed))
+}
+
+// newTwinCDTGaussianSampler creates a new [TwinCDTGaussianSampler] with baseSampler.
+func newTwinCDTGaussianSampler(stdDev float64, baseSampler *UniformSampler) *TwinCDTGaussianSampler {
 	tables := [blockSize][]uint64{}
 	for i := range blockSize {
 		tables[i] = computeCDT(float64(i)/blockSize, stdDev)
@@ -58,7 +70,7 @@ func NewTwinCDTGaussianSampler(stdDev float64) *TwinCDTGaussianSampler {
 	tailLo := -tailHi
 
 	return &TwinCDTGaussianSampler{
-		baseSampler: NewUniformSampler(),
+		baseSampler: baseSampler,
 
 		stdDev: stdDev,
 		tables: tables,
@@ -117,8 +129,21 @@ func (s *TwinCDTGaussianSampler) SampleCoset(center float64) float64 {
 
 // SafeCopy returns a thread-safe copy.
 func (s *TwinCDTGaussianSampler) SafeCopy() *TwinCDTGaussianSampler {
+	return s.copyWithBase(NewUniformSampler())
+}
+
+// SafeCopyWithSeed returns a thread-safe copy, with user supplied seed.
+// Unlike [NewTwinCDTGaussianSamplerWithSeed], it reuses the tables of s.
+//
+// Panics when AES initialization fails.
+func (s *TwinCDTGaussianSampler) SafeCopyWithSeed(seed []byte) *TwinCDTGaussianSampler {
+	return s.copyWithBase(NewUniformSamplerWithSeed(seed))
+}
+
+// copyWithBase returns a copy of s with baseSampler.
+func (s *TwinCDTGaussianSampler) copyWithBase(baseSampler *UniformSampler) *TwinCDTGaussianSampler {
 	return &TwinCDTGaussianSampler{
-		baseSampler: NewUniformSampler(),
+		baseSampler: baseSampler,
 
 		stdDev: s.stdDev,
 		tables: s.tables,
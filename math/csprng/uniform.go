@@ -4,7 +4,9 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/binary"
 	"math"
 )
 
@@ -53,6 +55,18 @@ func NewUniformSamplerWithSeed(seed []byte) *UniformSampler {
 	}
 }
 
+// DeriveSeed derives an independent seed from seed and label.
+// Different labels give independent seeds, so a single seed can drive several samplers.
+func DeriveSeed(seed []byte, label string) []byte {
+	h := sha256.New()
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(seed)))
+	h.Write(n[:])
+	h.Write(seed)
+	h.Write([]byte(label))
+	return h.Sum(nil)
+}
+
 // Read implements the [io.Reader] interface.
 // It always succeeds, with n == len(p) and err == nil.
 func (s *UniformSampler) Read(p []byte) (n int, err error) {
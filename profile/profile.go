@@ -0,0 +1,262 @@
+// Package profile implements opt-in instrumentation for the prover and verifier.
+//
+// A [Profiler] records the elapsed time, allocations and operation counts of each phase.
+// Each phase is also emitted as a [runtime/trace] region,
+// so it shows up in `go tool trace` without any further setup.
+//
+// All methods of [*Profiler] are nil-safe, so the instrumented code
+// can call them unconditionally when profiling is disabled.
+package profile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/trace"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// Op is a kind of operation counted by the [Profiler].
+type Op int
+
+const (
+	// OpNTT is a forward or inverse NTT of a single polynomial.
+	OpNTT Op = iota
+	// OpRingMul is a coefficient-wise multiplication of two polynomials.
+	OpRingMul
+	// OpGaussianSample is a single discrete Gaussian sample.
+	OpGaussianSample
+	// OpRNSReconstruct is a reconstruction of a single polynomial from RNS form.
+	OpRNSReconstruct
+
+	opCount
+)
+
+// String returns the name of the operation.
+func (op Op) String() string {
+	switch op {
+	case OpNTT:
+		return "NTT"
+	case OpRingMul:
+		return "RingMul"
+	case OpGaussianSample:
+		return "GaussianSample"
+	case OpRNSReconstruct:
+		return "RNSReconstruct"
+	}
+	return "Unknown"
+}
+
+// Counts is the number of operations of each kind.
+type Counts struct {
+	NTT            int64 `json:"ntt"`
+	RingMul        int64 `json:"ringMul"`
+	GaussianSample int64 `json:"gaussianSample"`
+	RNSReconstruct int64 `json:"rnsReconstruct"`
+}
+
+// sub returns c - d.
+func (c Counts) sub(d Counts) Counts {
+	return Counts{
+		NTT:            c.NTT - d.NTT,
+		RingMul:        c.RingMul - d.RingMul,
+		GaussianSample: c.GaussianSample - d.GaussianSample,
+		RNSReconstruct: c.RNSReconstruct - d.RNSReconstruct,
+	}
+}
+
+// PhaseReport is the measurement of a single phase.
+// The measurement is inclusive, i.e. it contains the nested phases.
+type PhaseReport struct {
+	// Name is the name of the phase.
+	Name string `json:"name"`
+	// Depth is the nesting depth of the phase.
+	Depth int `json:"depth"`
+
+	// Elapsed is the wall-clock duration of the phase.
+	Elapsed time.Duration `json:"elapsed"`
+	// Allocs is the number of heap allocations during the phase.
+	Allocs uint64 `json:"allocs"`
+	// AllocBytes is the number of bytes allocated during the phase.
+	AllocBytes uint64 `json:"allocBytes"`
+
+	// Counts is the operation counts during the phase.
+	Counts Counts `json:"counts"`
+}
+
+// Report is the structured output of a [Profiler].
+type Report struct {
+	// Phases is the list of phases, in the order they started.
+	Phases []PhaseReport `json:"phases"`
+	// Total is the total operation counts.
+	Total Counts `json:"total"`
+}
+
+// WriteTo writes the report as a human-readable table to w.
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "Phase\tElapsed\tAllocs\tAllocBytes\tNTT\tRingMul\tGaussianSample\tRNSReconstruct\t")
+	for _, ph := range r.Phases {
+		fmt.Fprintf(tw, "%*s%s\t%v\t%d\t%d\t%d\t%d\t%d\t%d\t\n",
+			2*ph.Depth, "", ph.Name, ph.Elapsed, ph.Allocs, ph.AllocBytes,
+			ph.Counts.NTT, ph.Counts.RingMul, ph.Counts.GaussianSample, ph.Counts.RNSReconstruct)
+	}
+	fmt.Fprintf(tw, "Total\t\t\t\t%d\t%d\t%d\t%d\t\n",
+		r.Total.NTT, r.Total.RingMul, r.Total.GaussianSample, r.Total.RNSReconstruct)
+
+	err := tw.Flush()
+	return cw.n, err
+}
+
+// countWriter counts the number of bytes written to w.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements [io.Writer].
+func (cw *countWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	return n, err
+}
+
+// Profiler records per-phase measurements.
+// It is safe for concurrent use.
+type Profiler struct {
+	counts [opCount]atomic.Int64
+
+	mu     sync.Mutex
+	depth  int
+	phases []PhaseReport
+}
+
+// NewProfiler creates a new [Profiler].
+func NewProfiler() *Profiler {
+	return &Profiler{}
+}
+
+// Count adds n to the counter of op.
+func (p *Profiler) Count(op Op, n int) {
+	if p == nil {
+		return
+	}
+	p.counts[op].Add(int64(n))
+}
+
+// snapshot returns the current operation counts.
+func (p *Profiler) snapshot() Counts {
+	return Counts{
+		NTT:            p.counts[OpNTT].Load(),
+		RingMul:        p.counts[OpRingMul].Load(),
+		GaussianSample: p.counts[OpGaussianSample].Load(),
+		RNSReconstruct: p.counts[OpRNSReconstruct].Load(),
+	}
+}
+
+// Region is an active phase started by [Profiler.Begin].
+type Region struct {
+	p *Profiler
+
+	idx    int
+	region *trace.Region
+
+	start      time.Time
+	mallocs    uint64
+	allocBytes uint64
+	counts     Counts
+}
+
+// Begin starts a new phase with the given name.
+// The phase ends when [Region.End] is called.
+// Phases may be nested, and the measurement of a phase contains its nested phases.
+//
+// If p is nil, Begin returns nil, which is a valid [*Region].
+func (p *Profiler) Begin(name string) *Region {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	idx := len(p.phases)
+	p.phases = append(p.phases, PhaseReport{Name: name, Depth: p.depth})
+	p.depth++
+	p.mu.Unlock()
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return &Region{
+		p: p,
+
+		idx:    idx,
+		region: trace.StartRegion(context.Background(), name),
+
+		start:      time.Now(),
+		mallocs:    m.Mallocs,
+		allocBytes: m.TotalAlloc,
+		counts:     p.snapshot(),
+	}
+}
+
+// End ends the phase.
+func (r *Region) End() {
+	if r == nil {
+		return
+	}
+
+	elapsed := time.Since(r.start)
+	r.region.End()
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	counts := r.p.snapshot().sub(r.counts)
+
+	r.p.mu.Lock()
+	defer r.p.mu.Unlock()
+
+	r.p.depth--
+	ph := &r.p.phases[r.idx]
+	ph.Elapsed = elapsed
+	ph.Allocs = m.Mallocs - r.mallocs
+	ph.AllocBytes = m.TotalAlloc - r.allocBytes
+	ph.Counts = counts
+}
+
+// Report returns the measurements recorded so far.
+// If p is nil, it returns an empty report.
+func (p *Profiler) Report() Report {
+	if p == nil {
+		return Report{}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return Report{
+		Phases: append([]PhaseReport(nil), p.phases...),
+		Total:  p.snapshot(),
+	}
+}
+
+// Reset clears all measurements.
+func (p *Profiler) Reset() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := range p.counts {
+		p.counts[i].Store(0)
+	}
+	p.depth = 0
+	p.phases = nil
+}
@@ -0,0 +1,102 @@
+package profile_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sp301415/ringo-snark/profile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfiler(t *testing.T) {
+	t.Run("Phases", func(t *testing.T) {
+		prof := profile.NewProfiler()
+
+		outer := prof.Begin("Outer")
+		prof.Count(profile.OpNTT, 2)
+
+		inner := prof.Begin("Inner")
+		prof.Count(profile.OpRingMul, 3)
+		time.Sleep(10 * time.Millisecond)
+		inner.End()
+
+		next := prof.Begin("Next")
+		prof.Count(profile.OpGaussianSample, 5)
+		next.End()
+
+		outer.End()
+
+		last := prof.Begin("Last")
+		prof.Count(profile.OpRNSReconstruct, 7)
+		last.End()
+
+		r := prof.Report()
+		assert.Equal(t, []string{"Outer", "Inner", "Next", "Last"}, phaseNames(r))
+		assert.Equal(t, []int{0, 1, 1, 0}, phaseDepths(r))
+
+		assert.GreaterOrEqual(t, r.Phases[1].Elapsed, 10*time.Millisecond)
+		assert.GreaterOrEqual(t, r.Phases[0].Elapsed, r.Phases[1].Elapsed+r.Phases[2].Elapsed)
+
+		assert.Equal(t, profile.Counts{NTT: 2, RingMul: 3, GaussianSample: 5}, r.Phases[0].Counts)
+		assert.Equal(t, profile.Counts{RingMul: 3}, r.Phases[1].Counts)
+		assert.Equal(t, profile.Counts{GaussianSample: 5}, r.Phases[2].Counts)
+		assert.Equal(t, profile.Counts{RNSReconstruct: 7}, r.Phases[3].Counts)
+		assert.Equal(t, profile.Counts{NTT: 2, RingMul: 3, GaussianSample: 5, RNSReconstruct: 7}, r.Total)
+	})
+
+	t.Run("Report", func(t *testing.T) {
+		prof := profile.NewProfiler()
+		prof.Begin("Phase").End()
+
+		r := prof.Report()
+		prof.Begin("Later").End()
+		assert.Len(t, r.Phases, 1)
+
+		var buf bytes.Buffer
+		n, err := r.WriteTo(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(buf.Len()), n)
+		assert.Contains(t, buf.String(), "Phase")
+		assert.Contains(t, buf.String(), "Total")
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		prof := profile.NewProfiler()
+		prof.Begin("Phase").End()
+		prof.Count(profile.OpNTT, 1)
+
+		prof.Reset()
+		assert.Equal(t, profile.Report{}, prof.Report())
+
+		prof.Begin("Phase").End()
+		assert.Equal(t, []int{0}, phaseDepths(prof.Report()))
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		var prof *profile.Profiler
+		assert.NotPanics(t, func() {
+			r := prof.Begin("Phase")
+			prof.Count(profile.OpNTT, 1)
+			r.End()
+			prof.Reset()
+		})
+		assert.Equal(t, profile.Report{}, prof.Report())
+	})
+}
+
+func phaseNames(r profile.Report) []string {
+	names := make([]string, len(r.Phases))
+	for i, ph := range r.Phases {
+		names[i] = ph.Name
+	}
+	return names
+}
+
+func phaseDepths(r profile.Report) []int {
+	depths := make([]int, len(r.Phases))
+	for i, ph := range r.Phases {
+		depths[i] = ph.Depth
+	}
+	return depths
+}
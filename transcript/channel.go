@@ -0,0 +1,171 @@
+package transcript
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Message is a message sent from the prover to the verifier in an interactive protocol.
+// If N is positive, it requests N bytes of the challenge named Label.
+// Otherwise, it is a prover message Data bound to the challenge named Label.
+type Message struct {
+	Label string
+	Data  []byte
+	N     int
+}
+
+// Channel is the transcript of the prover in an interactive protocol.
+// It sends the messages of the prover and receives the challenges over channels,
+// which are answered by a [Challenger] on the side of the verifier.
+type Channel struct {
+	send chan<- Message
+	recv <-chan []byte
+}
+
+// NewChannel creates a new [Channel] which sends the messages to send,
+// and receives the challenges from recv.
+// The caller closes send after the protocol is finished.
+func NewChannel(send chan<- Message, recv <-chan []byte) *Channel {
+	return &Channel{
+		send: send,
+		recv: recv,
+	}
+}
+
+// Append sends msg bound to the challenge named label.
+func (t *Channel) Append(label string, msg []byte) error {
+	t.send <- Message{Label: label, Data: append([]byte(nil), msg...)}
+	return nil
+}
+
+// Challenge requests n bytes of the challenge named label, and waits for the answer.
+func (t *Channel) Challenge(label string, n int) ([]byte, error) {
+	if n <= 0 {
+		return nil, errInvalidLength
+	}
+
+	t.send <- Message{Label: label, N: n}
+	chal, ok := <-t.recv
+	switch {
+	case !ok:
+		return nil, errors.New("transcript: channel closed")
+	case len(chal) != n:
+		return nil, errInvalidLength
+	}
+	return chal, nil
+}
+
+// Challenger is the verifier in an interactive protocol.
+// It answers the challenge requests of a [Channel] with fresh random challenges,
+// and records the interaction so that the verifier can replay it using [Challenger.Replay].
+type Challenger struct {
+	recv <-chan Message
+	send chan<- []byte
+
+	msgs  map[string][][]byte
+	chals map[string][]byte
+}
+
+// NewChallenger creates a new [Challenger] which receives the messages from recv,
+// and sends the challenges to send.
+func NewChallenger(recv <-chan Message, send chan<- []byte) *Challenger {
+	return &Challenger{
+		recv: recv,
+		send: send,
+
+		msgs:  make(map[string][][]byte),
+		chals: make(map[string][]byte),
+	}
+}
+
+// Run answers the challenge requests until recv is closed, and then closes send.
+// It returns an error if the prover binds a message to a challenge which is already computed,
+// or requests a challenge twice.
+// In that case, Run closes send immediately, which aborts the prover,
+// and keeps draining recv until it is closed.
+func (c *Challenger) Run() error {
+	var err error
+	for msg := range c.recv {
+		if err != nil {
+			continue
+		}
+
+		if _, ok := c.chals[msg.Label]; ok {
+			err = fmt.Errorf("%w: %v", errChallengeComputed, msg.Label)
+			close(c.send)
+			continue
+		}
+
+		if msg.N <= 0 {
+			c.msgs[msg.Label] = append(c.msgs[msg.Label], msg.Data)
+			continue
+		}
+
+		chal := make([]byte, msg.N)
+		rand.Read(chal)
+		c.chals[msg.Label] = chal
+		c.send <- append([]byte(nil), chal...)
+	}
+
+	if err == nil {
+		close(c.send)
+	}
+	return err
+}
+
+// Replay returns the transcript of the verifier, which replays the recorded interaction.
+// It must be called after [Challenger.Run] returns.
+//
+// The replayed transcript checks that the messages bound to each challenge
+// are the ones received before the challenge was sent,
+// and returns the challenges that were sent.
+func (c *Challenger) Replay() Transcript {
+	return &replay{
+		msgs:  c.msgs,
+		chals: c.chals,
+		pos:   make(map[string]int),
+		done:  make(map[string]bool),
+	}
+}
+
+// replay is the transcript returned by [Challenger.Replay].
+type replay struct {
+	msgs  map[string][][]byte
+	chals map[string][]byte
+	pos   map[string]int
+	done  map[string]bool
+}
+
+// Append checks that msg is the next message bound to the challenge named label.
+func (t *replay) Append(label string, msg []byte) error {
+	if t.done[label] {
+		return fmt.Errorf("%w: %v", errChallengeComputed, label)
+	}
+
+	pos := t.pos[label]
+	if pos >= len(t.msgs[label]) || !bytes.Equal(t.msgs[label][pos], msg) {
+		return fmt.Errorf("transcript: message mismatch for %v", label)
+	}
+	t.pos[label]++
+	return nil
+}
+
+// Challenge returns the recorded challenge named label,
+// after checking that all messages bound to it are appended.
+func (t *replay) Challenge(label string, n int) ([]byte, error) {
+	chal, ok := t.chals[label]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("transcript: challenge %v not recorded", label)
+	case t.done[label]:
+		return nil, fmt.Errorf("%w: %v", errChallengeComputed, label)
+	case len(chal) != n:
+		return nil, errInvalidLength
+	case t.pos[label] != len(t.msgs[label]):
+		return nil, fmt.Errorf("transcript: message mismatch for %v", label)
+	}
+	t.done[label] = true
+	return append([]byte(nil), chal...), nil
+}
@@ -0,0 +1,158 @@
+// Package transcript implements the public-coin transcripts shared by buckler and jindo.
+//
+// A [Transcript] receives the messages of the prover and answers the challenges of the verifier.
+// The same prover and verifier code can run non-interactively using [SHA256] or [SHAKE],
+// interactively using [Channel] and [Challenger],
+// or inside the transcript of an outer protocol by implementing [Transcript].
+package transcript
+
+import (
+	"crypto/sha256"
+	"crypto/sha3"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Transcript is a public-coin transcript between a prover and a verifier.
+//
+// Each message is bound to a named challenge, which depends on all messages bound to it.
+// A message cannot be bound to a challenge after it is computed,
+// and each challenge is computed at most once.
+//
+// The implementations in this package length-prefix the labels and the messages,
+// so that no two different sequences of (label, message) pairs hash the same input.
+// They do not separate different protocols by themselves;
+// a protocol binds its own identifier, such as a CRS, as its first message.
+type Transcript interface {
+	// Append binds msg to the challenge named label.
+	Append(label string, msg []byte) error
+	// Challenge returns n bytes of the challenge named label.
+	Challenge(label string, n int) ([]byte, error)
+}
+
+var (
+	errChallengeComputed = errors.New("transcript: challenge already computed")
+	errInvalidLength     = errors.New("transcript: invalid challenge length")
+)
+
+// SHA256 is a Fiat-Shamir transcript using SHA-256.
+//
+// A challenge is SHA-256(frame(label) || frame(previous challenge) || frame(msg_0) || frame(msg_1) || ...),
+// where frame(b) is len(b) as a big-endian uint64 followed by b,
+// and the previous challenge is empty for the first challenge.
+// Challenges longer than 32 bytes are expanded by
+// SHA-256(challenge || i) for i = 0, 1, ...
+type SHA256 struct {
+	msgs     map[string][][]byte
+	computed map[string]bool
+	prev     []byte
+}
+
+// NewSHA256 creates a new [SHA256] transcript.
+func NewSHA256() *SHA256 {
+	return &SHA256{
+		msgs:     make(map[string][][]byte),
+		computed: make(map[string]bool),
+	}
+}
+
+// Append binds msg to the challenge named label.
+func (t *SHA256) Append(label string, msg []byte) error {
+	if t.computed[label] {
+		return fmt.Errorf("%w: %v", errChallengeComputed, label)
+	}
+	t.msgs[label] = append(t.msgs[label], append([]byte(nil), msg...))
+	return nil
+}
+
+// Challenge returns n bytes of the challenge named label.
+func (t *SHA256) Challenge(label string, n int) ([]byte, error) {
+	switch {
+	case n <= 0:
+		return nil, errInvalidLength
+	case t.computed[label]:
+		return nil, fmt.Errorf("%w: %v", errChallengeComputed, label)
+	}
+
+	h := sha256.New()
+	writeFrame(h, []byte(label))
+	writeFrame(h, t.prev)
+	for _, msg := range t.msgs[label] {
+		writeFrame(h, msg)
+	}
+	chal := h.Sum(nil)
+
+	t.computed[label] = true
+	delete(t.msgs, label)
+	t.prev = chal
+
+	if n <= len(chal) {
+		return chal[:n], nil
+	}
+
+	out := make([]byte, 0, n+sha256.Size)
+	var buf [4]byte
+	for i := 0; len(out) < n; i++ {
+		h.Reset()
+		h.Write(chal)
+		binary.BigEndian.PutUint32(buf[:], uint32(i))
+		h.Write(buf[:])
+		out = h.Sum(out)
+	}
+	return out[:n], nil
+}
+
+// SHAKE is a Fiat-Shamir transcript using SHAKE128 as a duplex sponge.
+// The messages are absorbed in the order they are appended as frame(label) || frame(msg),
+// and a challenge of n bytes is squeezed after absorbing frame(label) || n,
+// and then absorbed back. See [SHA256] for frame.
+//
+// Unlike [SHA256], the challenges depend on all messages appended before them,
+// so the prover and the verifier must append the messages in the same order.
+type SHAKE struct {
+	xof *sha3.SHAKE
+}
+
+// NewSHAKE128 creates a new [SHAKE] transcript.
+func NewSHAKE128() *SHAKE {
+	return &SHAKE{xof: sha3.NewSHAKE128()}
+}
+
+// Append absorbs label and msg.
+func (t *SHAKE) Append(label string, msg []byte) error {
+	writeFrame(t.xof, []byte(label))
+	writeFrame(t.xof, msg)
+	return nil
+}
+
+// Challenge squeezes n bytes from the current state, and absorbs them.
+func (t *SHAKE) Challenge(label string, n int) ([]byte, error) {
+	if n <= 0 {
+		return nil, errInvalidLength
+	}
+
+	writeFrame(t.xof, []byte(label))
+	t.xof.Write(binary.BigEndian.AppendUint64(nil, uint64(n)))
+
+	state, err := t.xof.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	fork := sha3.NewSHAKE128()
+	if err := fork.UnmarshalBinary(state); err != nil {
+		return nil, err
+	}
+
+	chal := make([]byte, n)
+	fork.Read(chal)
+	t.xof.Write(chal)
+	return chal, nil
+}
+
+// writeFrame writes len(b) as a big-endian uint64 followed by b to w.
+func writeFrame(w io.Writer, b []byte) {
+	w.Write(binary.BigEndian.AppendUint64(nil, uint64(len(b))))
+	w.Write(b)
+}
@@ -0,0 +1,223 @@
+package transcript_test
+
+import (
+	"testing"
+
+	"github.com/sp301415/ringo-snark/transcript"
+	"github.com/stretchr/testify/assert"
+)
+
+type message struct {
+	label string
+	msg   string
+}
+
+// challenge appends msgs to a fresh transcript and returns n bytes of the challenge named label.
+func challenge(t *testing.T, newTranscript func() transcript.Transcript, msgs []message, label string, n int) []byte {
+	tr := newTranscript()
+	for _, m := range msgs {
+		assert.NoError(t, tr.Append(m.label, []byte(m.msg)))
+	}
+	chal, err := tr.Challenge(label, n)
+	assert.NoError(t, err)
+	return chal
+}
+
+func TestTranscript(t *testing.T) {
+	transcripts := []struct {
+		name          string
+		newTranscript func() transcript.Transcript
+	}{
+		{"SHA256", func() transcript.Transcript { return transcript.NewSHA256() }},
+		{"SHAKE", func() transcript.Transcript { return transcript.NewSHAKE128() }},
+	}
+
+	for _, tc := range transcripts {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("Determinism", func(t *testing.T) {
+				msgs := []message{{"a", "hello"}, {"a", "world"}}
+				chal0 := challenge(t, tc.newTranscript, msgs, "a", 32)
+				chal1 := challenge(t, tc.newTranscript, msgs, "a", 32)
+				assert.Equal(t, chal0, chal1)
+
+				chal2 := challenge(t, tc.newTranscript, []message{{"a", "hello"}, {"a", "World"}}, "a", 32)
+				assert.NotEqual(t, chal0, chal2)
+
+				chal3 := challenge(t, tc.newTranscript, msgs, "b", 32)
+				assert.NotEqual(t, chal0, chal3)
+			})
+
+			t.Run("Sequence", func(t *testing.T) {
+				tr0, tr1 := tc.newTranscript(), tc.newTranscript()
+				for _, tr := range []transcript.Transcript{tr0, tr1} {
+					assert.NoError(t, tr.Append("a", []byte("msg")))
+				}
+				chal0, err := tr0.Challenge("a", 16)
+				assert.NoError(t, err)
+				chal1, err := tr1.Challenge("a", 16)
+				assert.NoError(t, err)
+				assert.Equal(t, chal0, chal1)
+
+				// The second challenge depends on the first one.
+				chal0, err = tr0.Challenge("b", 16)
+				assert.NoError(t, err)
+				chal1 = challenge(t, tc.newTranscript, nil, "b", 16)
+				assert.NotEqual(t, chal0, chal1)
+			})
+
+			t.Run("Framing", func(t *testing.T) {
+				splits := [][]message{
+					{{"a", "bc"}},
+					{{"ab", "c"}},
+					{{"a", "b"}, {"a", "c"}},
+					{{"a", ""}, {"a", "bc"}},
+					{{"a", "bc"}, {"a", ""}},
+					{{"a", "\x00\x00\x00\x00\x00\x00\x00\x02bc"}},
+				}
+				seen := make(map[string]int)
+				for i, msgs := range splits {
+					chal := string(challenge(t, tc.newTranscript, msgs, "a", 32))
+					j, ok := seen[chal]
+					assert.False(t, ok, "splits %v and %v give the same challenge", j, i)
+					seen[chal] = i
+				}
+			})
+
+			t.Run("Expand", func(t *testing.T) {
+				msgs := []message{{"a", "msg"}}
+				for _, n := range []int{1, 31, 32, 33, 64, 100} {
+					chal := challenge(t, tc.newTranscript, msgs, "a", n)
+					assert.Len(t, chal, n)
+				}
+
+				chal := challenge(t, tc.newTranscript, msgs, "a", 100)
+				assert.NotEqual(t, chal[:32], chal[32:64])
+				assert.NotEqual(t, chal[32:64], chal[64:96])
+				assert.Equal(t, chal, challenge(t, tc.newTranscript, msgs, "a", 100))
+			})
+
+			t.Run("InvalidLength", func(t *testing.T) {
+				tr := tc.newTranscript()
+				_, err := tr.Challenge("a", 0)
+				assert.Error(t, err)
+			})
+		})
+	}
+
+	t.Run("SHA256/ChallengeComputed", func(t *testing.T) {
+		tr := transcript.NewSHA256()
+		assert.NoError(t, tr.Append("a", []byte("msg")))
+		_, err := tr.Challenge("a", 32)
+		assert.NoError(t, err)
+
+		assert.ErrorContains(t, tr.Append("a", []byte("msg")), "already computed")
+		_, err = tr.Challenge("a", 32)
+		assert.ErrorContains(t, err, "already computed")
+
+		assert.NoError(t, tr.Append("b", []byte("msg")))
+	})
+}
+
+// runChannel runs prover against a [transcript.Challenger], and returns the challenger and the error of Run.
+func runChannel(prover func(tr *transcript.Channel)) (*transcript.Challenger, error) {
+	msgs := make(chan transcript.Message)
+	chals := make(chan []byte)
+
+	go func() {
+		defer close(msgs)
+		prover(transcript.NewChannel(msgs, chals))
+	}()
+
+	c := transcript.NewChallenger(msgs, chals)
+	return c, c.Run()
+}
+
+func TestChannel(t *testing.T) {
+	t.Run("Replay", func(t *testing.T) {
+		var chalA, chalB []byte
+		c, err := runChannel(func(tr *transcript.Channel) {
+			assert.NoError(t, tr.Append("a", []byte("hello")))
+			assert.NoError(t, tr.Append("a", []byte("world")))
+			chalA, _ = tr.Challenge("a", 16)
+			assert.NoError(t, tr.Append("b", []byte("msg")))
+			chalB, _ = tr.Challenge("b", 64)
+		})
+		assert.NoError(t, err)
+		assert.Len(t, chalA, 16)
+		assert.Len(t, chalB, 64)
+
+		// The replayed transcript is deterministic.
+		for range 2 {
+			tr := c.Replay()
+			assert.NoError(t, tr.Append("a", []byte("hello")))
+			assert.NoError(t, tr.Append("a", []byte("world")))
+			chal, err := tr.Challenge("a", 16)
+			assert.NoError(t, err)
+			assert.Equal(t, chalA, chal)
+			assert.NoError(t, tr.Append("b", []byte("msg")))
+			chal, err = tr.Challenge("b", 64)
+			assert.NoError(t, err)
+			assert.Equal(t, chalB, chal)
+
+			_, err = tr.Challenge("a", 16)
+			assert.ErrorContains(t, err, "already computed")
+			assert.ErrorContains(t, tr.Append("b", []byte("msg")), "already computed")
+		}
+
+		t.Run("Reordered", func(t *testing.T) {
+			tr := c.Replay()
+			assert.Error(t, tr.Append("a", []byte("world")))
+		})
+
+		t.Run("Missing", func(t *testing.T) {
+			tr := c.Replay()
+			assert.NoError(t, tr.Append("a", []byte("hello")))
+			_, err := tr.Challenge("a", 16)
+			assert.Error(t, err)
+		})
+
+		t.Run("Extra", func(t *testing.T) {
+			tr := c.Replay()
+			assert.NoError(t, tr.Append("a", []byte("hello")))
+			assert.NoError(t, tr.Append("a", []byte("world")))
+			assert.Error(t, tr.Append("a", []byte("!")))
+		})
+
+		t.Run("Length", func(t *testing.T) {
+			tr := c.Replay()
+			assert.NoError(t, tr.Append("a", []byte("hello")))
+			assert.NoError(t, tr.Append("a", []byte("world")))
+			_, err := tr.Challenge("a", 32)
+			assert.Error(t, err)
+		})
+
+		t.Run("NotRecorded", func(t *testing.T) {
+			tr := c.Replay()
+			_, err := tr.Challenge("c", 16)
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		var errChal error
+		_, err := runChannel(func(tr *transcript.Channel) {
+			_, _ = tr.Challenge("a", 16)
+			// Binding a message to a computed challenge aborts the prover.
+			assert.NoError(t, tr.Append("a", []byte("late")))
+			_, errChal = tr.Challenge("b", 16)
+			assert.NoError(t, tr.Append("b", []byte("drained")))
+		})
+		assert.ErrorContains(t, err, "already computed")
+		assert.ErrorContains(t, errChal, "channel closed")
+	})
+
+	t.Run("AbortTwice", func(t *testing.T) {
+		var errChal error
+		_, err := runChannel(func(tr *transcript.Channel) {
+			_, _ = tr.Challenge("a", 16)
+			_, errChal = tr.Challenge("a", 16)
+		})
+		assert.ErrorContains(t, err, "already computed")
+		assert.ErrorContains(t, errChal, "channel closed")
+	})
+}